@@ -1,21 +1,28 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
 	"github.com/gofiber/fiber/v3/middleware/static"
 	"github.com/illusi03/golearn/internal/database"
+	"github.com/illusi03/golearn/internal/event"
 	"github.com/illusi03/golearn/internal/handler"
+	"github.com/illusi03/golearn/internal/money"
 	"github.com/illusi03/golearn/internal/repository"
 	"github.com/illusi03/golearn/internal/service"
+	"github.com/illusi03/golearn/internal/storage"
 	"github.com/illusi03/golearn/template"
 	"github.com/illusi03/golearn/utils/middleware"
 )
@@ -31,13 +38,91 @@ type ApiDeamon struct {
 	transactionRepository *repository.TransactionRepository
 	reportService         *service.ReportService
 	reportRepository      *repository.ReportRepository
+	shiftService          *service.ShiftService
+	shiftRepository       *repository.ShiftRepository
+	promotionService      *service.PromotionService
+	promotionRepository   *repository.PromotionRepository
+	couponService         *service.CouponService
+	couponRepository      *repository.CouponRepository
+	taxRateService        *service.TaxRateService
+	taxRateRepository     *repository.TaxRateRepository
+	variantService        *service.VariantService
+	variantRepository     *repository.VariantRepository
+	modifierService       *service.ModifierService
+	modifierRepository    *repository.ModifierRepository
+	barcodeRepository     *repository.BarcodeRepository
+	productImageService   *service.ProductImageService
+	productImageRepo      *repository.ProductImageRepository
+	productImportService  *service.ProductImportService
+	productBatchService   *service.ProductBatchService
+	priceScheduleService  *service.PriceScheduleService
+	priceHistoryRepo      *repository.PriceHistoryRepository
+	storeService          *service.StoreService
+	storeRepository       *repository.StoreRepository
+	transferService       *service.StockTransferService
+	transferRepository    *repository.StockTransferRepository
+	stocktakeService      *service.StocktakeService
+	stocktakeRepository   *repository.StocktakeRepository
+	customerService       *service.CustomerService
+	customerRepository    *repository.CustomerRepository
+	loyaltyService        *service.LoyaltyService
+	loyaltyRepository     *repository.LoyaltyRepository
+	orderService          *service.OrderService
+	orderRepository       *repository.OrderRepository
+	cartService           *service.CartService
+	cartRepository        *repository.CartRepository
+	storage               storage.Storage
+	bus                   *event.Bus
+
+	// stopWorkers stops the background workers on shutdown
+	stopWorkers context.CancelFunc
 }
 
 type ApiConfig struct {
-	Port   int    `mapstructure:"PORT"`
-	DBConn string `mapstructure:"DB_CONN"`
+	Port     int    `mapstructure:"PORT"`
+	DBConn   string `mapstructure:"DB_CONN"`
+	Currency string `mapstructure:"CURRENCY"`
+
+	// Requests sending this token in the X-Admin-Token header may see and
+	// restore deleted records. Leaving it empty disables admin access.
+	AdminToken string `mapstructure:"ADMIN_TOKEN"`
+
+	// Uploaded files go to the local filesystem by default, or to an
+	// S3-compatible bucket when StorageDriver is "s3"
+	StorageDriver string `mapstructure:"STORAGE_DRIVER"`
+	StorageDir    string `mapstructure:"STORAGE_DIR"`
+	StorageURL    string `mapstructure:"STORAGE_URL"`
+	S3Endpoint    string `mapstructure:"S3_ENDPOINT"`
+	S3Region      string `mapstructure:"S3_REGION"`
+	S3Bucket      string `mapstructure:"S3_BUCKET"`
+	S3AccessKey   string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey   string `mapstructure:"S3_SECRET_KEY"`
+	S3PublicURL   string `mapstructure:"S3_PUBLIC_URL"`
+	ImageMaxBytes int64  `mapstructure:"IMAGE_MAX_BYTES"`
+
+	// How often the price worker looks for scheduled price changes to apply
+	PriceWorkerInterval time.Duration `mapstructure:"PRICE_WORKER_INTERVAL"`
+
+	// How often the loyalty worker expires points past their expiry
+	LoyaltyWorkerInterval time.Duration `mapstructure:"LOYALTY_WORKER_INTERVAL"`
+
+	// How long a cart holds its stock after its last change, and how often
+	// the cart worker releases the stock of carts that ran out
+	CartReservationTTL time.Duration `mapstructure:"CART_RESERVATION_TTL"`
+	CartWorkerInterval time.Duration `mapstructure:"CART_WORKER_INTERVAL"`
 }
 
+const (
+	defaultStorageDir    = "uploads"
+	defaultStorageURL    = "/media"
+	defaultImageMaxBytes = 5 << 20
+
+	defaultPriceWorkerInterval   = time.Minute
+	defaultLoyaltyWorkerInterval = time.Hour
+	defaultCartReservationTTL    = 15 * time.Minute
+	defaultCartWorkerInterval    = 30 * time.Second
+)
+
 func InitApi(config *ApiConfig) *ApiDeamon {
 	api := &ApiDeamon{
 		config: config,
@@ -51,12 +136,64 @@ func (a *ApiDeamon) runApi() {
 }
 
 func (a *ApiDeamon) registerProvider() {
+	a.registerCurrency()
+	a.registerStorage()
 	a.registerDb()
+	a.registerBus()
 	a.registerRepository()
 	a.registerService()
+	a.registerWorker()
 	a.registerHandler()
 }
 
+func (a *ApiDeamon) registerCurrency() {
+	if a.config.Currency == "" {
+		return
+	}
+	currency := strings.ToUpper(a.config.Currency)
+	if !money.IsSupported(currency) {
+		log.Fatal("Unsupported CURRENCY:", a.config.Currency)
+	}
+	money.DefaultCurrency = currency
+}
+
+func (a *ApiDeamon) registerStorage() {
+	config := a.config
+	if config.ImageMaxBytes <= 0 {
+		config.ImageMaxBytes = defaultImageMaxBytes
+	}
+
+	switch config.StorageDriver {
+	case "", "local":
+		if config.StorageDir == "" {
+			config.StorageDir = defaultStorageDir
+		}
+		if config.StorageURL == "" {
+			config.StorageURL = defaultStorageURL
+		}
+		local, err := storage.NewLocalStorage(config.StorageDir, config.StorageURL)
+		if err != nil {
+			log.Fatal("Failed to initialize storage:", err)
+		}
+		a.storage = local
+	case "s3":
+		s3, err := storage.NewS3Storage(storage.S3Config{
+			Endpoint:  config.S3Endpoint,
+			Region:    config.S3Region,
+			Bucket:    config.S3Bucket,
+			AccessKey: config.S3AccessKey,
+			SecretKey: config.S3SecretKey,
+			PublicURL: config.S3PublicURL,
+		})
+		if err != nil {
+			log.Fatal("Failed to initialize storage:", err)
+		}
+		a.storage = s3
+	default:
+		log.Fatal("Unsupported STORAGE_DRIVER:", config.StorageDriver)
+	}
+}
+
 func (a *ApiDeamon) registerDb() {
 	db, err := database.NewDatabaseConnection(a.config.DBConn)
 	if err != nil {
@@ -65,18 +202,124 @@ func (a *ApiDeamon) registerDb() {
 	a.db = db
 }
 
+func (a *ApiDeamon) registerBus() {
+	a.bus = event.NewBus()
+}
+
 func (a *ApiDeamon) registerRepository() {
 	a.categoryRepository = repository.NewCategoryRepository(a.db.Pool)
 	a.productRepository = repository.NewProductRepository(a.db.Pool)
 	a.transactionRepository = repository.NewTransactionRepository(a.db.Pool)
 	a.reportRepository = repository.NewReportRepository(a.db.Pool)
+	a.shiftRepository = repository.NewShiftRepository(a.db.Pool)
+	a.promotionRepository = repository.NewPromotionRepository(a.db.Pool)
+	a.couponRepository = repository.NewCouponRepository(a.db.Pool)
+	a.taxRateRepository = repository.NewTaxRateRepository(a.db.Pool)
+	a.variantRepository = repository.NewVariantRepository(a.db.Pool)
+	a.modifierRepository = repository.NewModifierRepository(a.db.Pool)
+	a.barcodeRepository = repository.NewBarcodeRepository(a.db.Pool)
+	a.productImageRepo = repository.NewProductImageRepository(a.db.Pool)
+	a.priceHistoryRepo = repository.NewPriceHistoryRepository(a.db.Pool)
+	a.storeRepository = repository.NewStoreRepository(a.db.Pool)
+	a.transferRepository = repository.NewStockTransferRepository(a.db.Pool)
+	a.stocktakeRepository = repository.NewStocktakeRepository(a.db.Pool)
+	a.customerRepository = repository.NewCustomerRepository(a.db.Pool)
+	a.loyaltyRepository = repository.NewLoyaltyRepository(a.db.Pool)
+	a.orderRepository = repository.NewOrderRepository(a.db.Pool)
+	a.cartRepository = repository.NewCartRepository(a.db.Pool)
 }
 
 func (a *ApiDeamon) registerService() {
 	a.categoryService = service.NewCategoryService(a.categoryRepository)
-	a.productService = service.NewProductService(a.productRepository)
-	a.transactionService = service.NewTransactionService(a.transactionRepository, a.productRepository)
+	a.storeService = service.NewStoreService(a.storeRepository, a.bus)
+	a.transferService = service.NewStockTransferService(a.transferRepository, a.storeService, a.bus)
+	a.productService = service.NewProductService(
+		a.productRepository,
+		a.variantRepository,
+		a.modifierRepository,
+		a.barcodeRepository,
+		a.productImageRepo,
+		a.storeRepository,
+		a.bus,
+	)
+	a.stocktakeService = service.NewStocktakeService(a.stocktakeRepository, a.storeService, a.productService, a.bus)
+	a.productImportService = service.NewProductImportService(a.productRepository, a.storeRepository, a.bus)
+	a.productBatchService = service.NewProductBatchService(
+		a.productRepository,
+		a.categoryRepository,
+		a.storeRepository,
+		a.bus,
+	)
+	a.priceScheduleService = service.NewPriceScheduleService(a.priceHistoryRepo, a.productRepository, a.bus)
+	a.productImageService = service.NewProductImageService(
+		a.productImageRepo,
+		a.productRepository,
+		a.storage,
+		a.config.ImageMaxBytes,
+	)
+	a.variantService = service.NewVariantService(a.variantRepository, a.productRepository)
+	a.modifierService = service.NewModifierService(a.modifierRepository, a.productRepository)
+	a.promotionService = service.NewPromotionService(a.promotionRepository, a.couponRepository)
+	a.couponService = service.NewCouponService(a.couponRepository, a.promotionRepository)
+	a.taxRateService = service.NewTaxRateService(a.taxRateRepository, a.productRepository, a.categoryRepository)
+	a.loyaltyService = service.NewLoyaltyService(a.loyaltyRepository, a.customerRepository, a.categoryRepository)
+	a.transactionService = service.NewTransactionService(
+		a.transactionRepository,
+		a.productRepository,
+		a.variantRepository,
+		a.modifierRepository,
+		a.productService,
+		a.promotionService,
+		a.taxRateService,
+		a.storeService,
+		a.customerRepository,
+		a.loyaltyService,
+		a.bus,
+	)
+	a.orderService = service.NewOrderService(
+		a.orderRepository,
+		a.customerRepository,
+		a.storeService,
+		a.transactionService,
+	)
+	cartTTL := a.config.CartReservationTTL
+	if cartTTL <= 0 {
+		cartTTL = defaultCartReservationTTL
+	}
+	a.cartService = service.NewCartService(
+		a.cartRepository,
+		a.productRepository,
+		a.productService,
+		a.storeService,
+		a.transactionService,
+		cartTTL,
+	)
+	a.customerService = service.NewCustomerService(a.customerRepository, a.transactionRepository)
 	a.reportService = service.NewReportService(a.reportRepository)
+	a.shiftService = service.NewShiftService(a.shiftRepository, a.storeService)
+}
+
+func (a *ApiDeamon) registerWorker() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopWorkers = cancel
+
+	interval := a.config.PriceWorkerInterval
+	if interval <= 0 {
+		interval = defaultPriceWorkerInterval
+	}
+	go a.priceScheduleService.Run(ctx, interval)
+
+	loyaltyInterval := a.config.LoyaltyWorkerInterval
+	if loyaltyInterval <= 0 {
+		loyaltyInterval = defaultLoyaltyWorkerInterval
+	}
+	go a.loyaltyService.Run(ctx, loyaltyInterval)
+
+	cartInterval := a.config.CartWorkerInterval
+	if cartInterval <= 0 {
+		cartInterval = defaultCartWorkerInterval
+	}
+	go a.cartService.Run(ctx, cartInterval)
 }
 
 func (a *ApiDeamon) registerHandler() {
@@ -88,11 +331,16 @@ func (a *ApiDeamon) registerHandler() {
 		AppName:      "API",
 		ServerHeader: "Fiber",
 		ErrorHandler: customErrorHandler,
+		// Leave room for the multipart overhead around an image upload
+		BodyLimit: int(max(fiber.DefaultBodyLimit, config.ImageMaxBytes+1<<20)),
 	})
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: []string{"*"},
 		AllowHeaders: []string{"*"},
+		// Clients read the ETag to send it back in If-Match, and the number
+		// of products an export skipped
+		ExposeHeaders: []string{fiber.HeaderETag, handler.ExportSkippedHeader},
 	}))
 
 	// Health check endpoint
@@ -105,26 +353,69 @@ func (a *ApiDeamon) registerHandler() {
 	})
 
 	// API routes
-	api := app.Group("/api")
+	api := app.Group("/api", middleware.Admin(config.AdminToken))
 	v1 := api.Group("/v1")
 
 	// Products routes
 	productHandler := handler.NewProductHandler(a.productService)
 	products := v1.Group("/products")
 	products.Get("/", productHandler.GetAll)
+	products.Get("/lookup", productHandler.Lookup)
+	products.Get("/search", productHandler.Search)
+	products.Get("/autocomplete", productHandler.Autocomplete)
+
+	// Product import/export routes
+	productImportHandler := handler.NewProductImportHandler(a.productImportService)
+	products.Post("/import", productImportHandler.Import)
+	products.Get("/export", productImportHandler.Export)
+	productBatchHandler := handler.NewProductBatchHandler(a.productBatchService)
+	products.Post("/batch", productBatchHandler.Batch)
 	products.Get("/:id", productHandler.GetDetail)
 	products.Post("/", productHandler.Create)
 	products.Put("/:id", productHandler.Update)
+	products.Patch("/:id", productHandler.Patch)
 	products.Delete("/:id", productHandler.Delete)
+	products.Post("/:id/restore", middleware.RequireAdmin(), productHandler.Restore)
+	products.Get("/:id/barcodes", productHandler.GetBarcodes)
+	products.Put("/:id/barcodes", productHandler.SetBarcodes)
+
+	// Product image routes
+	productImageHandler := handler.NewProductImageHandler(a.productImageService)
+	products.Get("/:id/images", productImageHandler.GetAll)
+	products.Post("/:id/images", productImageHandler.Upload)
+	products.Delete("/:id/images/:imageId", productImageHandler.Delete)
+
+	// Product variant and modifier routes
+	variantHandler := handler.NewVariantHandler(a.variantService)
+	products.Get("/:id/variants", variantHandler.GetAll)
+	products.Post("/:id/variants", variantHandler.Create)
+	products.Put("/:id/variants/:variantId", variantHandler.Update)
+	products.Delete("/:id/variants/:variantId", variantHandler.Delete)
+	modifierHandler := handler.NewModifierHandler(a.modifierService)
+	products.Get("/:id/modifier-groups", modifierHandler.GetAll)
+	products.Post("/:id/modifier-groups", modifierHandler.Create)
+	products.Put("/:id/modifier-groups/:groupId", modifierHandler.Update)
+	products.Delete("/:id/modifier-groups/:groupId", modifierHandler.Delete)
+
+	// Price history and scheduled price change routes
+	priceScheduleHandler := handler.NewPriceScheduleHandler(a.priceScheduleService)
+	products.Get("/:id/price-history", priceScheduleHandler.GetTimeline)
+	products.Get("/:id/price-schedule", priceScheduleHandler.GetAll)
+	products.Post("/:id/price-schedule", priceScheduleHandler.Create)
+	products.Delete("/:id/price-schedule/:scheduleId", priceScheduleHandler.Cancel)
 
 	// Categories routes
 	categoryHandler := handler.NewCategoryHandler(a.categoryService)
 	categories := v1.Group("/categories")
 	categories.Get("/", categoryHandler.GetAll)
+	categories.Get("/tree", categoryHandler.GetTree)
 	categories.Get("/:id", categoryHandler.GetDetail)
 	categories.Post("/", categoryHandler.Create)
 	categories.Put("/:id", categoryHandler.Update)
+	categories.Patch("/:id", categoryHandler.Patch)
 	categories.Delete("/:id", categoryHandler.Delete)
+	categories.Put("/:id/move", categoryHandler.Move)
+	categories.Post("/:id/restore", middleware.RequireAdmin(), categoryHandler.Restore)
 
 	// Checkout/Transactions routes
 	checkoutHandler := handler.NewCheckoutHandler(a.transactionService)
@@ -132,12 +423,152 @@ func (a *ApiDeamon) registerHandler() {
 	transactions.Get("/", checkoutHandler.GetAllTransactions)
 	transactions.Post("/checkout", checkoutHandler.Checkout)
 
+	// Order routes
+	orderHandler := handler.NewOrderHandler(a.orderService)
+	orders := v1.Group("/orders")
+	orders.Get("/", orderHandler.GetAll)
+	orders.Get("/:id", orderHandler.GetDetail)
+	orders.Post("/", orderHandler.Create)
+	orders.Put("/:id", orderHandler.Update)
+	orders.Post("/:id/items", orderHandler.AddItems)
+	orders.Put("/:id/items/:itemId", orderHandler.SetItemQuantity)
+	orders.Delete("/:id/items/:itemId", orderHandler.RemoveItem)
+	orders.Post("/:id/hold", orderHandler.Hold)
+	orders.Post("/:id/resume", orderHandler.Resume)
+	orders.Post("/:id/cancel", orderHandler.Cancel)
+	orders.Post("/:id/settle", orderHandler.Settle)
+
+	// Cart routes
+	cartHandler := handler.NewCartHandler(a.cartService)
+	carts := v1.Group("/carts")
+	carts.Get("/", cartHandler.GetAll)
+	carts.Get("/:id", cartHandler.GetDetail)
+	carts.Post("/", cartHandler.Create)
+	carts.Put("/:id/items", cartHandler.SetItems)
+	carts.Post("/:id/extend", cartHandler.Extend)
+	carts.Delete("/:id", cartHandler.Release)
+
+	// Store routes
+	storeHandler := handler.NewStoreHandler(a.storeService)
+	stores := v1.Group("/stores")
+	stores.Get("/", storeHandler.GetAll)
+	stores.Get("/:id", storeHandler.GetDetail)
+	stores.Post("/", storeHandler.Create)
+	stores.Put("/:id", storeHandler.Update)
+	stores.Delete("/:id", storeHandler.Delete)
+	stores.Get("/:id/stocks", storeHandler.GetStocks)
+	stores.Put("/:id/stocks", storeHandler.SetStocks)
+
+	// Stock transfer routes
+	transferHandler := handler.NewStockTransferHandler(a.transferService)
+	transfers := v1.Group("/stock-transfers")
+	transfers.Get("/", transferHandler.GetAll)
+	transfers.Get("/:id", transferHandler.GetDetail)
+	transfers.Post("/", transferHandler.Create)
+	transfers.Post("/:id/dispatch", transferHandler.Dispatch)
+	transfers.Post("/:id/receive", transferHandler.Receive)
+	transfers.Post("/:id/cancel", transferHandler.Cancel)
+
+	// Stocktake routes
+	stocktakeHandler := handler.NewStocktakeHandler(a.stocktakeService)
+	stocktakes := v1.Group("/stocktakes")
+	stocktakes.Get("/", stocktakeHandler.GetAll)
+	stocktakes.Get("/:id", stocktakeHandler.GetDetail)
+	stocktakes.Post("/", stocktakeHandler.Create)
+	stocktakes.Post("/:id/counts", stocktakeHandler.Count)
+	stocktakes.Post("/:id/finalize", stocktakeHandler.Finalize)
+	stocktakes.Post("/:id/cancel", stocktakeHandler.Cancel)
+	stocktakes.Get("/:id/adjustments", stocktakeHandler.GetAdjustments)
+
+	// Customer routes
+	customerHandler := handler.NewCustomerHandler(a.customerService)
+	customers := v1.Group("/customers")
+	customers.Get("/", customerHandler.GetAll)
+	customers.Get("/:id", customerHandler.GetDetail)
+	customers.Get("/:id/transactions", customerHandler.GetTransactions)
+	customers.Post("/", customerHandler.Create)
+	customers.Put("/:id", customerHandler.Update)
+	customers.Delete("/:id", customerHandler.Delete)
+
+	// Loyalty routes
+	loyaltyHandler := handler.NewLoyaltyHandler(a.loyaltyService)
+	loyalty := v1.Group("/loyalty")
+	loyalty.Get("/programs", loyaltyHandler.GetPrograms)
+	loyalty.Get("/programs/:currency", loyaltyHandler.GetProgram)
+	loyalty.Put("/programs/:currency", loyaltyHandler.SaveProgram)
+	loyalty.Delete("/programs/:currency", loyaltyHandler.DeleteProgram)
+	loyalty.Get("/multipliers", loyaltyHandler.GetMultipliers)
+	loyalty.Put("/multipliers/:categoryId", loyaltyHandler.SetMultiplier)
+	loyalty.Delete("/multipliers/:categoryId", loyaltyHandler.DeleteMultiplier)
+	customers.Get("/:id/points", loyaltyHandler.GetBalance)
+	customers.Get("/:id/points/ledger", loyaltyHandler.GetEntries)
+	customers.Post("/:id/points/adjust", loyaltyHandler.Adjust)
+
+	// Shift routes
+	shiftHandler := handler.NewShiftHandler(a.shiftService)
+	shifts := v1.Group("/shifts")
+	shifts.Get("/", shiftHandler.GetAll)
+	shifts.Get("/current", shiftHandler.GetCurrent)
+	shifts.Get("/:id", shiftHandler.GetDetail)
+	shifts.Post("/", shiftHandler.Open)
+	shifts.Put("/:id", shiftHandler.Update)
+	shifts.Post("/:id/close", shiftHandler.Close)
+
+	// Promotion routes
+	promotionHandler := handler.NewPromotionHandler(a.promotionService)
+	promotions := v1.Group("/promotions")
+	promotions.Get("/", promotionHandler.GetAll)
+	promotions.Get("/:id", promotionHandler.GetDetail)
+	promotions.Post("/", promotionHandler.Create)
+	promotions.Put("/:id", promotionHandler.Update)
+	promotions.Delete("/:id", promotionHandler.Delete)
+
+	// Coupon routes
+	couponHandler := handler.NewCouponHandler(a.couponService)
+	coupons := v1.Group("/coupons")
+	coupons.Get("/", couponHandler.GetAll)
+	coupons.Get("/:id", couponHandler.GetDetail)
+	coupons.Post("/", couponHandler.Create)
+	coupons.Put("/:id", couponHandler.Update)
+	coupons.Delete("/:id", couponHandler.Delete)
+
+	// Tax rate routes
+	taxRateHandler := handler.NewTaxRateHandler(a.taxRateService)
+	taxRates := v1.Group("/tax-rates")
+	taxRates.Get("/", taxRateHandler.GetAll)
+	taxRates.Get("/:id", taxRateHandler.GetDetail)
+	taxRates.Post("/", taxRateHandler.Create)
+	taxRates.Put("/:id", taxRateHandler.Update)
+	taxRates.Delete("/:id", taxRateHandler.Delete)
+	products.Get("/:id/tax-rates", taxRateHandler.GetProductRates)
+	products.Put("/:id/tax-rates", taxRateHandler.SetProductRates)
+	categories.Get("/:id/tax-rates", taxRateHandler.GetCategoryRates)
+	categories.Put("/:id/tax-rates", taxRateHandler.SetCategoryRates)
+
 	// Report routes
 	reportHandler := handler.NewReportHandler(a.reportService)
 	report := api.Group("/report")
 	report.Get("/hari-ini", reportHandler.GetTodayReport)
 	report.Get("/", reportHandler.GetReport)
 
+	// Event stream routes, for admin clients only
+	eventHandler := handler.NewEventHandler(a.bus)
+	events := v1.Group("/events", middleware.AdminFromQuery(config.AdminToken), middleware.RequireAdmin())
+	events.Get("/", eventHandler.Stream)
+	events.Get("/ws", eventHandler.WebSocket)
+
+	// Files kept on the local filesystem are served by the API itself
+	if local, ok := a.storage.(*storage.LocalStorage); ok {
+		mountPath := defaultStorageURL
+		if u, err := url.Parse(config.StorageURL); err == nil && u.Path != "" {
+			mountPath = strings.TrimRight(u.Path, "/")
+		}
+		app.Get(mountPath+"*", static.New(local.Dir(), static.Config{
+			Browse: false,
+			MaxAge: 86400,
+		}))
+	}
+
 	fsys := template.GetFileSystem("dist")
 	app.Use(middleware.TryFilesHTML(fsys))
 	app.Use(static.New("", static.Config{
@@ -153,6 +584,9 @@ func (a *ApiDeamon) registerHandler() {
 	go func() {
 		<-c
 		log.Println("Gracefully shutting down...")
+		a.stopWorkers()
+		// End the event streams so open connections do not hold up shutdown
+		a.bus.Close()
 		_ = app.Shutdown()
 		a.db.Close()
 	}()
@@ -0,0 +1,53 @@
+// Package barcode validates and normalizes the GTIN barcodes printed on retail
+// products: EAN-8, UPC-A and EAN-13.
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidFormat     = errors.New("barcode must be an EAN-8, UPC-A or EAN-13 code")
+	ErrInvalidCheckDigit = errors.New("barcode check digit is invalid")
+)
+
+// Normalize validates code and returns it in the form it is stored and looked
+// up by. UPC-A codes are widened to EAN-13 with a leading zero, so a product
+// is found whether the scanner reports 12 or 13 digits.
+func Normalize(code string) (string, error) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidFormat
+		}
+	}
+
+	switch len(code) {
+	case 8, 13:
+	case 12:
+		code = "0" + code
+	default:
+		return "", ErrInvalidFormat
+	}
+
+	if CheckDigit(code[:len(code)-1]) != code[len(code)-1] {
+		return "", ErrInvalidCheckDigit
+	}
+	return code, nil
+}
+
+// CheckDigit computes the GTIN check digit of a code given without it: digits
+// are weighted 3 and 1 alternately from the right, and the check digit brings
+// the weighted sum up to a multiple of ten.
+func CheckDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,65 @@
+package barcode
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   byte
+	}{
+		{"400638133393", '1'},
+		{"899123456789", '1'},
+		{"9638507", '4'},
+		{"03600029145", '2'},
+		{"000000000000", '0'},
+		{"0000001", '7'},
+		{"", '0'},
+	}
+	for _, tt := range tests {
+		t.Run(tt.digits, func(t *testing.T) {
+			if got := CheckDigit(tt.digits); got != tt.want {
+				t.Errorf("CheckDigit(%q) = %c, want %c", tt.digits, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+		err  error
+	}{
+		{"EAN-13", "4006381333931", "4006381333931", nil},
+		{"EAN-8", "96385074", "96385074", nil},
+		{"UPC-A widened", "036000291452", "0036000291452", nil},
+		{"UPC-A as EAN-13", "0036000291452", "0036000291452", nil},
+		{"spaces", " 4006381 333931 ", "4006381333931", nil},
+		{"EAN-13 wrong check digit", "4006381333932", "", ErrInvalidCheckDigit},
+		{"EAN-13 check digit off by one", "4006381333930", "", ErrInvalidCheckDigit},
+		{"EAN-8 wrong check digit", "96385075", "", ErrInvalidCheckDigit},
+		{"UPC-A wrong check digit", "036000291453", "", ErrInvalidCheckDigit},
+		{"swapped digits", "4006381339331", "", ErrInvalidCheckDigit},
+		{"letters", "40063813339A1", "", ErrInvalidFormat},
+		{"negative sign", "-4006381333931", "", ErrInvalidFormat},
+		{"too short", "1234567", "", ErrInvalidFormat},
+		{"between lengths", "1234567890", "", ErrInvalidFormat},
+		{"too long", "40063813339310", "", ErrInvalidFormat},
+		{"empty", "", "", ErrInvalidFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.code)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Normalize(%q) error = %v, want %v", tt.code, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
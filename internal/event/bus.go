@@ -0,0 +1,190 @@
+// Package event is an in-process publish/subscribe bus. Services publish
+// what happened after it is committed and the streaming endpoints forward it
+// to connected clients. Events are not stored: a client only sees what is
+// published while it is connected.
+package event
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// product.updated carries the product as it is after the change; a product
+// that was deleted has its deleted_at set.
+const (
+	TransactionCreated  = "transaction.created"
+	ProductStockChanged = "product.stock_changed"
+	ProductUpdated      = "product.updated"
+)
+
+// Types lists every event type that is published.
+var Types = []string{
+	TransactionCreated,
+	ProductStockChanged,
+	ProductUpdated,
+}
+
+// Event is a published event. StoreID is set for events that happened in a
+// single store.
+type Event struct {
+	ID      uint64    `json:"id"`
+	Type    string    `json:"type"`
+	StoreID *int      `json:"store_id,omitempty"`
+	Time    time.Time `json:"time"`
+	Data    any       `json:"data"`
+}
+
+// StockChange is the data of a product.stock_changed event: the items whose
+// stock changed in a store and why.
+type StockChange struct {
+	StoreID int         `json:"store_id"`
+	Reason  string      `json:"reason"`
+	Items   []StockItem `json:"items"`
+}
+
+type StockItem struct {
+	ProductID int  `json:"product_id"`
+	VariantID *int `json:"variant_id"`
+}
+
+// Why stock changed. StockReasonCatalog is stock written on a product
+// itself, which lands in the default store; StockReasonReservation is stock
+// taken or given back by orders and carts, which changes what is available.
+const (
+	StockReasonSale        = "sale"
+	StockReasonSet         = "set"
+	StockReasonTransfer    = "transfer"
+	StockReasonStocktake   = "stocktake"
+	StockReasonCatalog     = "catalog"
+	StockReasonReservation = "reservation"
+)
+
+// Filter selects the events a subscriber receives. Empty Types matches
+// every type; a set StoreID matches events of that store and events that
+// belong to no store.
+type Filter struct {
+	Types   []string
+	StoreID *int
+}
+
+func (f Filter) matches(e *Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	return f.StoreID == nil || e.StoreID == nil || *f.StoreID == *e.StoreID
+}
+
+// Subscription receives the events matching its filter on C. C is closed
+// when the subscription is cancelled, when the bus is closed, or when the
+// subscriber falls so far behind that its buffer fills up.
+type Subscription struct {
+	C <-chan Event
+
+	bus    *Bus
+	ch     chan Event
+	filter Filter
+}
+
+// Cancel stops the subscription. It is safe to call more than once.
+func (s *Subscription) Cancel() {
+	s.bus.remove(s)
+}
+
+type Bus struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+	lastID atomic.Uint64
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe starts receiving the events matching filter, buffering up to
+// buffer of them.
+func (b *Bus) Subscribe(filter Filter, buffer int) *Subscription {
+	ch := make(chan Event, buffer)
+	sub := &Subscription{C: ch, bus: b, ch: ch, filter: filter}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish sends an event to every matching subscriber without waiting on
+// any of them. A subscriber whose buffer is full is dropped so a stalled
+// client cannot hold back the services.
+func (b *Bus) Publish(eventType string, storeID *int, data any) {
+	e := Event{
+		ID:      b.lastID.Add(1),
+		Type:    eventType,
+		StoreID: storeID,
+		Time:    time.Now(),
+		Data:    data,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		if !sub.filter.matches(&e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Close ends every subscription; later subscriptions end right away.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+func (b *Bus) remove(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// PublishStockChange publishes a product.stock_changed event for the items
+// of a store, listing each product and variant once.
+func (b *Bus) PublishStockChange(storeID int, reason string, items []StockItem) {
+	if len(items) == 0 {
+		return
+	}
+	unique := make([]StockItem, 0, len(items))
+	seen := make(map[[2]int]bool, len(items))
+	for _, item := range items {
+		key := [2]int{item.ProductID, 0}
+		if item.VariantID != nil {
+			key[1] = *item.VariantID
+		}
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, item)
+		}
+	}
+	b.Publish(ProductStockChanged, &storeID, StockChange{StoreID: storeID, Reason: reason, Items: unique})
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type CartHandler struct {
+	cartService *service.CartService
+}
+
+func NewCartHandler(cartService *service.CartService) *CartHandler {
+	return &CartHandler{
+		cartService: cartService,
+	}
+}
+
+func (h *CartHandler) Create(c fiber.Ctx) error {
+	req := &request.CartRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	data, err := h.cartService.Create(c.Context(), req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Cart opened successfully",
+		"data":    data,
+	})
+}
+
+func (h *CartHandler) GetAll(c fiber.Ctx) error {
+	storeID, err := storeQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	list, err := h.cartService.FindAll(c.Context(), storeID, c.Query("status"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *CartHandler) GetDetail(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id cart",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.cartService.FindOne(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Cart not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
+
+func (h *CartHandler) SetItems(c fiber.Ctx) error {
+	req := &request.CartItemsRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	return h.change(c, func(ctx context.Context, id int) (*model.CartModel, error) {
+		return h.cartService.SetItems(ctx, id, req.Items)
+	}, "Cart updated successfully")
+}
+
+func (h *CartHandler) Extend(c fiber.Ctx) error {
+	return h.change(c, h.cartService.Extend, "Cart extended successfully")
+}
+
+func (h *CartHandler) Release(c fiber.Ctx) error {
+	return h.change(c, h.cartService.Release, "Cart released successfully")
+}
+
+// change runs a change to a cart. A cart that is closed or ran out is a
+// conflict.
+func (h *CartHandler) change(
+	c fiber.Ctx,
+	step func(ctx context.Context, id int) (*model.CartModel, error),
+	message string,
+) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id cart",
+			"error":   nil,
+		})
+	}
+
+	data, err := step(c.Context(), id)
+	var statusErr *repository.CartStatusError
+	if errors.As(err, &statusErr) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   fiber.Map{"status": statusErr.Status},
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Cart not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data":    data,
+	})
+}
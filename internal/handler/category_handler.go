@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
 	"github.com/illusi03/golearn/internal/request"
 	"github.com/illusi03/golearn/internal/service"
 )
@@ -33,8 +35,16 @@ func (h *CategoryHandler) Create(c fiber.Ctx) error {
 	data, err := h.categoryService.Create(c.Context(), &model.CategoryModel{
 		Name:        request.Name,
 		Description: request.Description,
+		ParentID:    request.ParentID,
 	})
 	if err != nil {
+		if errors.Is(err, repository.ErrParentCategoryNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+				"error":   nil,
+			})
+		}
 		return fmt.Errorf("Error Occured : %w", err)
 	}
 
@@ -65,12 +75,22 @@ func (h *CategoryHandler) Update(c fiber.Ctx) error {
 		})
 	}
 
-	data, err := h.categoryService.Update(c.Context(), &model.CategoryModel{
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	categoryModel := &model.CategoryModel{
 		ID:          id,
 		Name:        request.Name,
 		Description: request.Description,
-	})
+		Version:     version,
+	}
+	data, err := h.categoryService.Update(c.Context(), categoryModel)
 	if err != nil {
+		if isPreconditionError(err) {
+			return preconditionError(c, err)
+		}
 		return fmt.Errorf("Error Occured : %w", err)
 	}
 
@@ -82,6 +102,81 @@ func (h *CategoryHandler) Update(c fiber.Ctx) error {
 		})
 	}
 
+	c.Set(fiber.HeaderETag, etag(categoryModel.Version))
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data updated successfully",
+		"data":    data,
+	})
+}
+
+func (h *CategoryHandler) Patch(c fiber.Ctx) error {
+	patch := &request.CategoryPatchRequest{}
+	if err := bindPatch(c, patch); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+	if patch.ParentID.Set {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "parent_id cannot be patched, move the category instead",
+			"error":   nil,
+		})
+	}
+
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id category",
+			"error":   nil,
+		})
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	data, err := h.categoryService.Patch(c.Context(), id, version, func(category *model.CategoryModel) error {
+		if patch.Name.Set {
+			if patch.Name.Null {
+				return nullFieldError("name")
+			}
+			category.Name = patch.Name.Value
+		}
+		if patch.Description.Set {
+			category.Description = patch.Description.Value
+		}
+		return nil
+	})
+	if err != nil {
+		if isPreconditionError(err) {
+			return preconditionError(c, err)
+		}
+		if errors.Is(err, errInvalidPatch) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+				"error":   nil,
+			})
+		}
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Category not found",
+			"error":   nil,
+		})
+	}
+
+	c.Set(fiber.HeaderETag, etag(data.Version))
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "Data updated successfully",
@@ -100,8 +195,49 @@ func (h *CategoryHandler) Delete(c fiber.Ctx) error {
 		})
 	}
 
-	data, err := h.categoryService.Delete(c.Context(), id)
+	var reassignTo *int
+	if raw := c.Query("reassign_to"); raw != "" {
+		target, err := strconv.Atoi(raw)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"message": "Error id reassign_to",
+				"error":   nil,
+			})
+		}
+		reassignTo = &target
+	}
+
+	version, err := ifMatchVersion(c)
 	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	data, err := h.categoryService.Delete(c.Context(), id, c.Query("strategy"), reassignTo, version)
+	if err != nil {
+		if isPreconditionError(err) {
+			return preconditionError(c, err)
+		}
+		var inUse *repository.CategoryInUseError
+		if errors.As(err, &inUse) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+				"error": fiber.Map{
+					"product_count": inUse.ProductCount,
+				},
+			})
+		}
+		if errors.Is(err, repository.ErrReassignCategoryNotFound) ||
+			errors.Is(err, service.ErrUnknownDeleteStrategy) ||
+			errors.Is(err, service.ErrReassignTargetRequired) ||
+			errors.Is(err, service.ErrReassignToSelf) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+				"error":   nil,
+			})
+		}
 		return fmt.Errorf("Error Occured : %w", err)
 	}
 
@@ -120,8 +256,48 @@ func (h *CategoryHandler) Delete(c fiber.Ctx) error {
 	})
 }
 
+func (h *CategoryHandler) Restore(c fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id category",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.categoryService.Restore(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Deleted category not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data restored successfully",
+		"data":    data,
+	})
+}
+
 func (h *CategoryHandler) GetAll(c fiber.Ctx) error {
-	list, err := h.categoryService.FindAll(c)
+	include, err := includeDeleted(c)
+	if err != nil {
+		return c.Status(filterErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	list, err := h.categoryService.FindAll(c.Context(), include)
 	if err != nil {
 		return fmt.Errorf("Error Occured : %w", err)
 	}
@@ -157,9 +333,77 @@ func (h *CategoryHandler) GetDetail(c fiber.Ctx) error {
 		})
 	}
 
+	c.Set(fiber.HeaderETag, etag(data.Version))
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "Data fetched successfully",
 		"data":    data,
 	})
 }
+
+func (h *CategoryHandler) GetTree(c fiber.Ctx) error {
+	tree, err := h.categoryService.FindTree(c.Context())
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    tree,
+	})
+}
+
+func (h *CategoryHandler) Move(c fiber.Ctx) error {
+	request := &request.MoveCategoryRequest{}
+	if err := c.Bind().Body(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id category",
+			"error":   nil,
+		})
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	data, err := h.categoryService.Move(c.Context(), id, request.ParentID, version)
+	if err != nil {
+		if isPreconditionError(err) {
+			return preconditionError(c, err)
+		}
+		if errors.Is(err, repository.ErrCategoryCycle) || errors.Is(err, repository.ErrParentCategoryNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+				"error":   nil,
+			})
+		}
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Category not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Category moved successfully",
+		"data":    data,
+	})
+}
@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"strconv"
+
 	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/repository"
 	"github.com/illusi03/golearn/internal/request"
 	"github.com/illusi03/golearn/internal/service"
 )
@@ -43,7 +46,29 @@ func (h *CheckoutHandler) Checkout(c fiber.Ctx) error {
 }
 
 func (h *CheckoutHandler) GetAllTransactions(c fiber.Ctx) error {
-	data, err := h.transactionService.FindAll(c.Context())
+	storeID, err := storeQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	filter := repository.TransactionFilter{StoreID: storeID}
+	if value := c.Query("customer_id"); value != "" {
+		customerID, err := strconv.Atoi(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": "invalid customer_id",
+				"error":   nil,
+			})
+		}
+		filter.CustomerID = &customerID
+	}
+
+	data, err := h.transactionService.FindAll(c.Context(), filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type CouponHandler struct {
+	couponService *service.CouponService
+}
+
+func NewCouponHandler(couponService *service.CouponService) *CouponHandler {
+	return &CouponHandler{
+		couponService: couponService,
+	}
+}
+
+func couponFromRequest(req *request.CouponRequest) *model.CouponModel {
+	coupon := &model.CouponModel{
+		Code:        req.Code,
+		PromotionID: req.PromotionID,
+		UsageLimit:  req.UsageLimit,
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+		Active:      true,
+	}
+	if req.Active != nil {
+		coupon.Active = *req.Active
+	}
+	return coupon
+}
+
+func (h *CouponHandler) Create(c fiber.Ctx) error {
+	req := &request.CouponRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	data, err := h.couponService.Create(c.Context(), couponFromRequest(req))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data created successfully",
+		"data":    data,
+	})
+}
+
+func (h *CouponHandler) Update(c fiber.Ctx) error {
+	req := &request.CouponRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id coupon",
+			"error":   nil,
+		})
+	}
+
+	coupon := couponFromRequest(req)
+	coupon.ID = id
+	data, err := h.couponService.Update(c.Context(), coupon)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Coupon not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data updated successfully",
+		"data":    data,
+	})
+}
+
+func (h *CouponHandler) Delete(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id coupon",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.couponService.Delete(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Coupon not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data deleted successfully",
+		"data":    data,
+	})
+}
+
+func (h *CouponHandler) GetAll(c fiber.Ctx) error {
+	list, err := h.couponService.FindAll(c.Context())
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *CouponHandler) GetDetail(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id coupon",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.couponService.FindOne(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Coupon not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type CustomerHandler struct {
+	customerService *service.CustomerService
+}
+
+func NewCustomerHandler(customerService *service.CustomerService) *CustomerHandler {
+	return &CustomerHandler{
+		customerService: customerService,
+	}
+}
+
+func customerFromRequest(req *request.CustomerRequest) *model.CustomerModel {
+	return &model.CustomerModel{
+		Name:  req.Name,
+		Phone: req.Phone,
+		Email: req.Email,
+	}
+}
+
+func (h *CustomerHandler) Create(c fiber.Ctx) error {
+	req := &request.CustomerRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	data, err := h.customerService.Create(c.Context(), customerFromRequest(req))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data created successfully",
+		"data":    data,
+	})
+}
+
+func (h *CustomerHandler) Update(c fiber.Ctx) error {
+	req := &request.CustomerRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id customer",
+			"error":   nil,
+		})
+	}
+
+	customer := customerFromRequest(req)
+	customer.ID = id
+	data, err := h.customerService.Update(c.Context(), customer)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Customer not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data updated successfully",
+		"data":    data,
+	})
+}
+
+func (h *CustomerHandler) Delete(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id customer",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.customerService.Delete(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Customer not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data deleted successfully",
+		"data":    data,
+	})
+}
+
+func (h *CustomerHandler) GetAll(c fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	list, err := h.customerService.FindAll(c.Context(), c.Query("q"), limit)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *CustomerHandler) GetDetail(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id customer",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.customerService.FindOne(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Customer not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
+
+func (h *CustomerHandler) GetTransactions(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id customer",
+			"error":   nil,
+		})
+	}
+
+	list, err := h.customerService.FindTransactions(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if list == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Customer not found",
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/event"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// eventBuffer is how many events a client may fall behind before its
+	// stream is closed
+	eventBuffer = 256
+
+	// eventHeartbeat keeps idle streams alive through proxies and finds
+	// clients that went away
+	eventHeartbeat = 15 * time.Second
+
+	eventWriteTimeout = 10 * time.Second
+)
+
+var eventUpgrader = websocket.FastHTTPUpgrader{
+	// The API is open to every origin; access is checked by the admin token
+	CheckOrigin: func(*fasthttp.RequestCtx) bool { return true },
+}
+
+type EventHandler struct {
+	bus *event.Bus
+}
+
+func NewEventHandler(bus *event.Bus) *EventHandler {
+	return &EventHandler{
+		bus: bus,
+	}
+}
+
+// Stream sends events as Server-Sent Events until the client disconnects.
+// Events are not replayed, so a reconnecting client should reload what it
+// shows.
+func (h *EventHandler) Stream(c fiber.Ctx) error {
+	filter, err := eventFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	sub := h.bus.Subscribe(filter, eventBuffer)
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Keep reverse proxies from buffering the stream
+	c.Set("X-Accel-Buffering", "no")
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer sub.Cancel()
+		ticker := time.NewTicker(eventHeartbeat)
+		defer ticker.Stop()
+
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+		for {
+			select {
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(e)
+				if err != nil {
+					log.Printf("Failed to encode %s event: %v", e.Type, err)
+					continue
+				}
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+			case <-ticker.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// WebSocket sends events as JSON text messages over a WebSocket until
+// either side closes it. Messages from the client are ignored.
+func (h *EventHandler) WebSocket(c fiber.Ctx) error {
+	if !websocket.FastHTTPIsWebSocketUpgrade(c.RequestCtx()) {
+		return fiber.ErrUpgradeRequired
+	}
+	filter, err := eventFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	err = eventUpgrader.Upgrade(c.RequestCtx(), func(conn *websocket.Conn) {
+		defer conn.Close()
+		sub := h.bus.Subscribe(filter, eventBuffer)
+		defer sub.Cancel()
+
+		// Read until the client goes away so close and pong frames are
+		// handled
+		gone := make(chan struct{})
+		go func() {
+			defer close(gone)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ticker := time.NewTicker(eventHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case e, ok := <-sub.C:
+				if !ok {
+					_ = conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseGoingAway, "event stream closed"),
+						time.Now().Add(eventWriteTimeout))
+					return
+				}
+				_ = conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+				if err := conn.WriteJSON(e); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventWriteTimeout)); err != nil {
+					return
+				}
+			case <-gone:
+				return
+			}
+		}
+	})
+	if err != nil {
+		return fiber.ErrUpgradeRequired
+	}
+	return nil
+}
+
+// eventFilter reads the comma separated types and the store_id to stream
+// events for from the query.
+func eventFilter(c fiber.Ctx) (event.Filter, error) {
+	var filter event.Filter
+	storeID, err := storeQuery(c)
+	if err != nil {
+		return filter, err
+	}
+	filter.StoreID = storeID
+
+	if value := c.Query("types"); value != "" {
+		for _, t := range strings.Split(value, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(event.Types, t) {
+				return filter, fmt.Errorf("unknown event type %q", t)
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type LoyaltyHandler struct {
+	loyaltyService *service.LoyaltyService
+}
+
+func NewLoyaltyHandler(loyaltyService *service.LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{
+		loyaltyService: loyaltyService,
+	}
+}
+
+func (h *LoyaltyHandler) GetPrograms(c fiber.Ctx) error {
+	list, err := h.loyaltyService.FindPrograms(c.Context())
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *LoyaltyHandler) GetProgram(c fiber.Ctx) error {
+	data, err := h.loyaltyService.FindProgram(c.Context(), c.Params("currency"))
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Loyalty program not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
+
+// SaveProgram creates or replaces the program of the currency in the URL.
+func (h *LoyaltyHandler) SaveProgram(c fiber.Ctx) error {
+	req := &request.LoyaltyProgramRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	program := &model.LoyaltyProgramModel{
+		Currency:   c.Params("currency"),
+		EarnAmount: req.EarnAmount,
+		EarnPoints: req.EarnPoints,
+		PointValue: req.PointValue,
+		ExpiryDays: req.ExpiryDays,
+		Active:     true,
+	}
+	if req.Active != nil {
+		program.Active = *req.Active
+	}
+
+	data, err := h.loyaltyService.SaveProgram(c.Context(), program)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data saved successfully",
+		"data":    data,
+	})
+}
+
+func (h *LoyaltyHandler) DeleteProgram(c fiber.Ctx) error {
+	data, err := h.loyaltyService.DeleteProgram(c.Context(), c.Params("currency"))
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Loyalty program not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data deleted successfully",
+		"data":    data,
+	})
+}
+
+func (h *LoyaltyHandler) GetMultipliers(c fiber.Ctx) error {
+	list, err := h.loyaltyService.FindMultipliers(c.Context())
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *LoyaltyHandler) SetMultiplier(c fiber.Ctx) error {
+	req := &request.LoyaltyMultiplierRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	categoryID, err := strconv.Atoi(c.Params("categoryId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id category",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.loyaltyService.SetMultiplier(c.Context(), categoryID, req.MultiplierBps)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Category not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data updated successfully",
+		"data":    data,
+	})
+}
+
+func (h *LoyaltyHandler) DeleteMultiplier(c fiber.Ctx) error {
+	categoryID, err := strconv.Atoi(c.Params("categoryId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id category",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.loyaltyService.DeleteMultiplier(c.Context(), categoryID)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Multiplier not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data deleted successfully",
+		"data":    data,
+	})
+}
+
+func (h *LoyaltyHandler) GetBalance(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id customer",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.loyaltyService.FindBalance(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Customer not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
+
+func (h *LoyaltyHandler) GetEntries(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id customer",
+			"error":   nil,
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	list, err := h.loyaltyService.FindEntries(c.Context(), id, limit)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if list == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Customer not found",
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *LoyaltyHandler) Adjust(c fiber.Ctx) error {
+	req := &request.LoyaltyAdjustRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id customer",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.loyaltyService.Adjust(c.Context(), id, req.Points, req.Note, req.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, repository.ErrCustomerNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": "Customer not found",
+				"error":   nil,
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data created successfully",
+		"data":    data,
+	})
+}
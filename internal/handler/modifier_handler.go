@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type ModifierHandler struct {
+	modifierService *service.ModifierService
+}
+
+func NewModifierHandler(modifierService *service.ModifierService) *ModifierHandler {
+	return &ModifierHandler{
+		modifierService: modifierService,
+	}
+}
+
+func modifierGroupFromRequest(req *request.ModifierGroupRequest) *model.ModifierGroupModel {
+	group := &model.ModifierGroupModel{
+		Name:      req.Name,
+		MinSelect: req.MinSelect,
+		MaxSelect: req.MaxSelect,
+		Modifiers: make([]model.ModifierModel, len(req.Modifiers)),
+	}
+	for i, m := range req.Modifiers {
+		group.Modifiers[i] = model.ModifierModel{
+			ID:         m.ID,
+			Name:       m.Name,
+			PriceDelta: m.PriceDelta,
+			Stock:      m.Stock,
+		}
+	}
+	return group
+}
+
+func (h *ModifierHandler) GetAll(c fiber.Ctx) error {
+	productID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	list, err := h.modifierService.FindGroupsByProduct(c.Context(), productID)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if list == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Product not found",
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *ModifierHandler) Create(c fiber.Ctx) error {
+	req := &request.ModifierGroupRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	productID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	group := modifierGroupFromRequest(req)
+	group.ProductID = productID
+	data, err := h.modifierService.CreateGroup(c.Context(), group)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data created successfully",
+		"data":    data,
+	})
+}
+
+func (h *ModifierHandler) Update(c fiber.Ctx) error {
+	req := &request.ModifierGroupRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	productID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("groupId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id modifier group",
+			"error":   nil,
+		})
+	}
+
+	group := modifierGroupFromRequest(req)
+	group.ID = id
+	group.ProductID = productID
+	data, err := h.modifierService.UpdateGroup(c.Context(), group)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Modifier group not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data updated successfully",
+		"data":    data,
+	})
+}
+
+func (h *ModifierHandler) Delete(c fiber.Ctx) error {
+	productID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("groupId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id modifier group",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.modifierService.DeleteGroup(c.Context(), productID, id)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Modifier group not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data deleted successfully",
+		"data":    data,
+	})
+}
@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type OrderHandler struct {
+	orderService *service.OrderService
+}
+
+func NewOrderHandler(orderService *service.OrderService) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+	}
+}
+
+func (h *OrderHandler) Create(c fiber.Ctx) error {
+	req := &request.OrderRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	data, err := h.orderService.Create(c.Context(), req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Order opened successfully",
+		"data":    data,
+	})
+}
+
+func (h *OrderHandler) GetAll(c fiber.Ctx) error {
+	storeID, err := storeQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	list, err := h.orderService.FindAll(c.Context(), storeID, c.Query("status"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *OrderHandler) GetDetail(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id order",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.orderService.FindOne(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Order not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
+
+func (h *OrderHandler) Update(c fiber.Ctx) error {
+	req := &request.OrderUpdateRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	return h.change(c, func(ctx context.Context, id int) (*model.OrderModel, error) {
+		return h.orderService.Update(ctx, id, req)
+	}, "Data updated successfully")
+}
+
+func (h *OrderHandler) AddItems(c fiber.Ctx) error {
+	req := &request.OrderItemsRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	return h.change(c, func(ctx context.Context, id int) (*model.OrderModel, error) {
+		return h.orderService.AddItems(ctx, id, req.Items)
+	}, "Items added successfully")
+}
+
+func (h *OrderHandler) SetItemQuantity(c fiber.Ctx) error {
+	req := &request.OrderItemQuantityRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	itemID, err := strconv.Atoi(c.Params("itemId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id order item",
+			"error":   nil,
+		})
+	}
+
+	return h.change(c, func(ctx context.Context, id int) (*model.OrderModel, error) {
+		return h.orderService.SetItemQuantity(ctx, id, itemID, req.Quantity)
+	}, "Data updated successfully")
+}
+
+func (h *OrderHandler) RemoveItem(c fiber.Ctx) error {
+	itemID, err := strconv.Atoi(c.Params("itemId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id order item",
+			"error":   nil,
+		})
+	}
+
+	return h.change(c, func(ctx context.Context, id int) (*model.OrderModel, error) {
+		return h.orderService.RemoveItem(ctx, id, itemID)
+	}, "Item removed successfully")
+}
+
+func (h *OrderHandler) Hold(c fiber.Ctx) error {
+	return h.change(c, h.orderService.Hold, "Order held successfully")
+}
+
+func (h *OrderHandler) Resume(c fiber.Ctx) error {
+	return h.change(c, h.orderService.Resume, "Order resumed successfully")
+}
+
+func (h *OrderHandler) Cancel(c fiber.Ctx) error {
+	return h.change(c, h.orderService.Cancel, "Order cancelled successfully")
+}
+
+// change runs a change to an order. An order whose status does not allow
+// the change is a conflict.
+func (h *OrderHandler) change(
+	c fiber.Ctx,
+	step func(ctx context.Context, id int) (*model.OrderModel, error),
+	message string,
+) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id order",
+			"error":   nil,
+		})
+	}
+
+	data, err := step(c.Context(), id)
+	var statusErr *repository.OrderStatusError
+	if errors.As(err, &statusErr) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   fiber.Map{"status": statusErr.Status},
+		})
+	}
+	if errors.Is(err, repository.ErrOrderItemNotFound) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Order item not found",
+			"error":   nil,
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Order not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data":    data,
+	})
+}
+
+// Settle pays for an order and returns the transaction it became.
+func (h *OrderHandler) Settle(c fiber.Ctx) error {
+	req := &request.SettleOrderRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id order",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.orderService.Settle(c.Context(), id, req)
+	var statusErr *repository.OrderStatusError
+	if errors.As(err, &statusErr) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   fiber.Map{"status": statusErr.Status},
+		})
+	}
+	if errors.Is(err, repository.ErrOrderChanged) || errors.Is(err, repository.ErrOrderReservationLost) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Order not found",
+			"error":   nil,
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Order settled successfully",
+		"data":    data,
+	})
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const mimeMergePatchJSON = "application/merge-patch+json"
+
+var errInvalidPatch = errors.New("invalid patch")
+
+// bindPatch decodes a JSON Merge Patch body, sent either as
+// application/merge-patch+json or as plain JSON.
+func bindPatch(c fiber.Ctx, out any) error {
+	if contentType := c.Get(fiber.HeaderContentType); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != mimeMergePatchJSON && mediaType != fiber.MIMEApplicationJSON) {
+			return fmt.Errorf("%w: content type must be %s", errInvalidPatch, mimeMergePatchJSON)
+		}
+	}
+	if err := json.Unmarshal(c.Body(), out); err != nil {
+		return fmt.Errorf("%w: %s", errInvalidPatch, err.Error())
+	}
+	return nil
+}
+
+func nullFieldError(field string) error {
+	return fmt.Errorf("%w: %s cannot be null", errInvalidPatch, field)
+}
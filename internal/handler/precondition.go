@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+var (
+	errPreconditionRequired = errors.New("If-Match header with the ETag of the record is required")
+	errWildcardPrecondition = errors.New("If-Match must carry the ETag of the record, * is not accepted")
+	errPreconditionFailed   = errors.New("If-Match does not match the current version of the record")
+)
+
+// etag is the entity tag of a record at the given version.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion reads the version a write is based on from If-Match. "*"
+// is refused: it would match any version and skip the optimistic locking
+// the guarded writes rely on.
+func ifMatchVersion(c fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		return 0, errPreconditionRequired
+	}
+	if header == "*" {
+		return 0, errWildcardPrecondition
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, errPreconditionFailed
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version <= 0 {
+		return 0, errPreconditionFailed
+	}
+	return version, nil
+}
+
+func isPreconditionError(err error) bool {
+	return errors.Is(err, errPreconditionRequired) ||
+		errors.Is(err, errWildcardPrecondition) ||
+		errors.Is(err, errPreconditionFailed) ||
+		errors.Is(err, repository.ErrVersionMismatch)
+}
+
+// preconditionError answers 428 when If-Match is missing or carries no
+// concrete version and 412 when it does not match the record.
+func preconditionError(c fiber.Ctx, err error) error {
+	status := fiber.StatusPreconditionFailed
+	if errors.Is(err, errPreconditionRequired) || errors.Is(err, errWildcardPrecondition) {
+		status = fiber.StatusPreconditionRequired
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+		"error":   nil,
+	})
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type PriceScheduleHandler struct {
+	priceScheduleService *service.PriceScheduleService
+}
+
+func NewPriceScheduleHandler(priceScheduleService *service.PriceScheduleService) *PriceScheduleHandler {
+	return &PriceScheduleHandler{
+		priceScheduleService: priceScheduleService,
+	}
+}
+
+func (h *PriceScheduleHandler) GetTimeline(c fiber.Ctx) error {
+	productID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.priceScheduleService.Timeline(c.Context(), productID)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Product not found",
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
+
+func (h *PriceScheduleHandler) GetAll(c fiber.Ctx) error {
+	productID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	list, err := h.priceScheduleService.FindScheduled(c.Context(), productID)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if list == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Product not found",
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *PriceScheduleHandler) Create(c fiber.Ctx) error {
+	request := &request.PriceScheduleRequest{}
+	if err := c.Bind().Body(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	productID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.priceScheduleService.Schedule(c.Context(), &model.ScheduledPriceChangeModel{
+		ProductID:   productID,
+		VariantID:   request.VariantID,
+		Price:       request.Price,
+		EffectiveAt: request.EffectiveAt,
+		Note:        request.Note,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Product or variant not found",
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Price change scheduled successfully",
+		"data":    data,
+	})
+}
+
+func (h *PriceScheduleHandler) Cancel(c fiber.Ctx) error {
+	productID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("scheduleId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id price schedule",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.priceScheduleService.Cancel(c.Context(), productID, id)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Pending price change not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Price change cancelled successfully",
+		"data":    data,
+	})
+}
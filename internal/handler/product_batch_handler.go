@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type ProductBatchHandler struct {
+	productBatchService *service.ProductBatchService
+}
+
+func NewProductBatchHandler(productBatchService *service.ProductBatchService) *ProductBatchHandler {
+	return &ProductBatchHandler{
+		productBatchService: productBatchService,
+	}
+}
+
+func (h *ProductBatchHandler) Batch(c fiber.Ctx) error {
+	request := &request.ProductBatchRequest{}
+	if err := c.Bind().Body(&request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	result, err := h.productBatchService.Batch(c.Context(), request.Operations)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if !result.Committed {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": fmt.Sprintf("Batch has %d failed operations, nothing was saved", result.Failed),
+			"data":    result,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Batch applied successfully",
+		"data":    result,
+	})
+}
@@ -1,13 +1,17 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
 	"github.com/illusi03/golearn/internal/request"
 	"github.com/illusi03/golearn/internal/service"
+	"github.com/illusi03/golearn/utils/middleware"
 )
 
 type ProductHandler struct {
@@ -32,6 +36,7 @@ func (h *ProductHandler) Create(c fiber.Ctx) error {
 
 	productModel := &model.ProductModel{
 		Name:        request.Name,
+		SKU:         request.SKU,
 		Description: request.Description,
 		Price:       request.Price,
 		Stock:       request.Stock,
@@ -42,7 +47,11 @@ func (h *ProductHandler) Create(c fiber.Ctx) error {
 
 	data, err := h.productService.Create(c.Context(), productModel)
 	if err != nil {
-		return fmt.Errorf("Error Occured : %w", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -53,8 +62,15 @@ func (h *ProductHandler) Create(c fiber.Ctx) error {
 }
 
 func (h *ProductHandler) Update(c fiber.Ctx) error {
-	request := &request.ProductRequest{}
-	if err := c.Bind().Body(&request); err != nil {
+	request := &request.ProductReplaceRequest{}
+	if err := c.Bind().Body(request); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+	if err := validateProductReplace(request); err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"success": false,
 			"message": "Error validation json",
@@ -72,20 +88,35 @@ func (h *ProductHandler) Update(c fiber.Ctx) error {
 		})
 	}
 
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
 	productModel := &model.ProductModel{
 		ID:          id,
-		Name:        request.Name,
+		Version:     version,
+		Name:        *request.Name,
+		SKU:         request.SKU,
 		Description: request.Description,
-		Price:       request.Price,
-		Stock:       request.Stock,
-	}
-	if request.CategoryId > 0 {
-		productModel.CategoryID = &request.CategoryId
+		Price:       *request.Price,
+		Stock:       *request.Stock,
+		CategoryID:  request.CategoryID,
 	}
 
 	data, err := h.productService.Update(c.Context(), productModel)
 	if err != nil {
-		return fmt.Errorf("Error Occured : %w", err)
+		if isPreconditionError(err) {
+			return preconditionError(c, err)
+		}
+		if handled, err := stockReserved(c, err); handled {
+			return err
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
 	}
 
 	if !data {
@@ -96,6 +127,89 @@ func (h *ProductHandler) Update(c fiber.Ctx) error {
 		})
 	}
 
+	c.Set(fiber.HeaderETag, etag(productModel.Version))
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data updated successfully",
+		"data":    data,
+	})
+}
+
+// validateProductReplace checks that a PUT body carries every required
+// field. A category_id of 0 is refused rather than read as "no category";
+// null clears it.
+func validateProductReplace(req *request.ProductReplaceRequest) error {
+	var missing []string
+	if req.Name == nil {
+		missing = append(missing, "name")
+	}
+	if req.Price == nil {
+		missing = append(missing, "price")
+	}
+	if req.Stock == nil {
+		missing = append(missing, "stock")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s required, PUT replaces the whole product (use PATCH for a partial update)",
+			strings.Join(missing, ", "))
+	}
+	if req.CategoryID != nil && *req.CategoryID <= 0 {
+		return errors.New("category_id must be a category id, or null for no category")
+	}
+	return nil
+}
+
+func (h *ProductHandler) Patch(c fiber.Ctx) error {
+	patch := &request.ProductPatchRequest{}
+	if err := bindPatch(c, patch); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	data, err := h.productService.Patch(c.Context(), id, version, func(p *model.ProductModel) error {
+		return applyProductPatch(patch, p)
+	})
+	if err != nil {
+		if isPreconditionError(err) {
+			return preconditionError(c, err)
+		}
+		if handled, err := stockReserved(c, err); handled {
+			return err
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Product not found",
+			"error":   nil,
+		})
+	}
+
+	c.Set(fiber.HeaderETag, etag(data.Version))
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "Data updated successfully",
@@ -103,6 +217,43 @@ func (h *ProductHandler) Update(c fiber.Ctx) error {
 	})
 }
 
+func applyProductPatch(patch *request.ProductPatchRequest, p *model.ProductModel) error {
+	if patch.Name.Set {
+		if patch.Name.Null {
+			return nullFieldError("name")
+		}
+		p.Name = patch.Name.Value
+	}
+	if patch.SKU.Set {
+		p.SKU = patch.SKU.Ptr()
+	}
+	if patch.Description.Set {
+		p.Description = patch.Description.Value
+	}
+	if patch.Stock.Set {
+		if patch.Stock.Null {
+			return nullFieldError("stock")
+		}
+		p.Stock = patch.Stock.Value
+	}
+	if patch.CategoryID.Set {
+		p.CategoryID = patch.CategoryID.Ptr()
+	}
+	if patch.Price.Set {
+		price := patch.Price.Value
+		if patch.Price.Null || price.Amount.Null || price.Currency.Null {
+			return nullFieldError("price")
+		}
+		if price.Amount.Set {
+			p.Price.Amount = price.Amount.Value
+		}
+		if price.Currency.Set {
+			p.Price.Currency = strings.ToUpper(price.Currency.Value)
+		}
+	}
+	return nil
+}
+
 func (h *ProductHandler) Delete(c fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := strconv.Atoi(idStr)
@@ -114,8 +265,16 @@ func (h *ProductHandler) Delete(c fiber.Ctx) error {
 		})
 	}
 
-	data, err := h.productService.Delete(c.Context(), id)
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	data, err := h.productService.Delete(c.Context(), id, version)
 	if err != nil {
+		if isPreconditionError(err) {
+			return preconditionError(c, err)
+		}
 		return fmt.Errorf("Error Occured : %w", err)
 	}
 
@@ -134,9 +293,56 @@ func (h *ProductHandler) Delete(c fiber.Ctx) error {
 	})
 }
 
+func (h *ProductHandler) Restore(c fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.productService.Restore(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrProductSKUTaken) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+				"error":   nil,
+			})
+		}
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Deleted product not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data restored successfully",
+		"data":    data,
+	})
+}
+
 func (h *ProductHandler) GetAll(c fiber.Ctx) error {
+	filter, err := productFilter(c)
+	if err != nil {
+		return c.Status(filterErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
 	name := c.Query("name")
-	list, err := h.productService.FindAll(c.Context(), name)
+	list, err := h.productService.FindAll(c.Context(), name, filter)
 	if err != nil {
 		return fmt.Errorf("Error Occured : %w", err)
 	}
@@ -172,9 +378,208 @@ func (h *ProductHandler) GetDetail(c fiber.Ctx) error {
 		})
 	}
 
+	c.Set(fiber.HeaderETag, etag(data.Version))
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "Data fetched successfully",
 		"data":    data,
 	})
 }
+
+func (h *ProductHandler) Lookup(c fiber.Ctx) error {
+	data, err := h.productService.Lookup(c.Context(), c.Query("barcode"), c.Query("sku"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Product not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
+
+func (h *ProductHandler) GetBarcodes(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	list, err := h.productService.FindBarcodes(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if list == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Product not found",
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *ProductHandler) SetBarcodes(c fiber.Ctx) error {
+	req := &request.ProductBarcodeRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	barcodes := make([]model.ProductBarcodeModel, len(req.Barcodes))
+	for i, b := range req.Barcodes {
+		barcodes[i] = model.ProductBarcodeModel{
+			Barcode:   b.Barcode,
+			VariantID: b.VariantID,
+		}
+	}
+
+	data, err := h.productService.SetBarcodes(c.Context(), id, barcodes)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Product not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data updated successfully",
+		"data":    data,
+	})
+}
+
+func (h *ProductHandler) Search(c fiber.Ctx) error {
+	filter, err := productFilter(c)
+	if err != nil {
+		return c.Status(filterErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	list, err := h.productService.Search(c.Context(), c.Query("q"), limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *ProductHandler) Autocomplete(c fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	list, err := h.productService.Autocomplete(c.Context(), c.Query("q"), limit)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+// productFilter reads the category_id query. Products of subcategories are
+// included unless include_descendants=false.
+func productFilter(c fiber.Ctx) (repository.ProductFilter, error) {
+	filter := repository.ProductFilter{IncludeDescendants: true}
+	if raw := c.Query("category_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			return filter, errors.New("invalid category_id")
+		}
+		filter.CategoryID = &id
+	}
+	if raw := c.Query("include_descendants"); raw != "" {
+		include, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, errors.New("invalid include_descendants")
+		}
+		filter.IncludeDescendants = include
+	}
+	include, err := includeDeleted(c)
+	if err != nil {
+		return filter, err
+	}
+	filter.IncludeDeleted = include
+	return filter, nil
+}
+
+var errAdminOnlyFilter = errors.New("include_deleted requires admin access")
+
+// includeDeleted reads the include_deleted query, which only admins may set.
+func includeDeleted(c fiber.Ctx) (bool, error) {
+	raw := c.Query("include_deleted")
+	if raw == "" {
+		return false, nil
+	}
+	include, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, errors.New("invalid include_deleted")
+	}
+	if include && !middleware.IsAdmin(c) {
+		return false, errAdminOnlyFilter
+	}
+	return include, nil
+}
+
+func filterErrorStatus(err error) int {
+	if errors.Is(err, errAdminOnlyFilter) {
+		return fiber.StatusForbidden
+	}
+	return fiber.StatusBadRequest
+}
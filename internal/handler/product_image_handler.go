@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type ProductImageHandler struct {
+	productImageService *service.ProductImageService
+}
+
+func NewProductImageHandler(productImageService *service.ProductImageService) *ProductImageHandler {
+	return &ProductImageHandler{
+		productImageService: productImageService,
+	}
+}
+
+func (h *ProductImageHandler) GetAll(c fiber.Ctx) error {
+	productID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	list, err := h.productImageService.FindByProduct(c.Context(), productID)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if list == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Product not found",
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+// Upload expects a multipart form with the file in the "image" field.
+func (h *ProductImageHandler) Upload(c fiber.Ctx) error {
+	productID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error image file",
+			"error":   err.Error(),
+		})
+	}
+
+	maxBytes := h.productImageService.MaxBytes()
+	if fileHeader.Size > maxBytes {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"success": false,
+			"message": fmt.Sprintf("%s: the limit is %d bytes", service.ErrImageTooLarge, maxBytes),
+			"error":   nil,
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	result, err := h.productImageService.Upload(c.Context(), productID, data)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, service.ErrImageTooLarge) {
+			status = fiber.StatusRequestEntityTooLarge
+		} else if errors.Is(err, service.ErrUnsupportedImageType) {
+			status = fiber.StatusUnsupportedMediaType
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data created successfully",
+		"data":    result,
+	})
+}
+
+func (h *ProductImageHandler) Delete(c fiber.Ctx) error {
+	productID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id product",
+			"error":   nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("imageId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id image",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.productImageService.Delete(c.Context(), productID, id)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Image not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data deleted successfully",
+		"data":    data,
+	})
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type ProductImportHandler struct {
+	productImportService *service.ProductImportService
+}
+
+func NewProductImportHandler(productImportService *service.ProductImportService) *ProductImportHandler {
+	return &ProductImportHandler{
+		productImportService: productImportService,
+	}
+}
+
+// Import accepts the CSV either as a multipart upload in the "file" field or
+// as the raw request body. Pass ?dry_run=true to only validate it.
+func (h *ProductImportHandler) Import(c fiber.Ctx) error {
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	var body io.Reader
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"message": "Error csv file",
+				"error":   err.Error(),
+			})
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return fmt.Errorf("Error Occured : %w", err)
+		}
+		defer file.Close()
+		body = file
+	} else {
+		body = bytes.NewReader(c.Body())
+	}
+
+	result, err := h.productImportService.Import(c.Context(), body, dryRun)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if result.Failed > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": fmt.Sprintf("Import has %d invalid rows, nothing was saved", result.Failed),
+			"data":    result,
+		})
+	}
+
+	message := "Data imported successfully"
+	if dryRun {
+		message = "Data validated successfully, nothing was saved"
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data":    result,
+	})
+}
+
+// ExportSkippedHeader reports how many products the export left out because
+// they have no SKU to import them back by.
+const ExportSkippedHeader = "X-Skipped-Count"
+
+func (h *ProductImportHandler) Export(c fiber.Ctx) error {
+	var buf bytes.Buffer
+	skipped, err := h.productImportService.Export(c.Context(), &buf)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	c.Set(ExportSkippedHeader, strconv.Itoa(skipped))
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="products.csv"`)
+	return c.Send(buf.Bytes())
+}
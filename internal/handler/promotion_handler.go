@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type PromotionHandler struct {
+	promotionService *service.PromotionService
+}
+
+func NewPromotionHandler(promotionService *service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{
+		promotionService: promotionService,
+	}
+}
+
+func promotionFromRequest(req *request.PromotionRequest) *model.PromotionModel {
+	promotion := &model.PromotionModel{
+		Name:           req.Name,
+		Description:    req.Description,
+		Type:           req.Type,
+		Scope:          req.Scope,
+		Value:          req.Value,
+		BuyQuantity:    req.BuyQuantity,
+		GetQuantity:    req.GetQuantity,
+		ProductID:      req.ProductID,
+		CategoryID:     req.CategoryID,
+		MinSubtotal:    req.MinSubtotal,
+		RequiresCoupon: req.RequiresCoupon,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		DailyStartTime: req.DailyStartTime,
+		DailyEndTime:   req.DailyEndTime,
+		Active:         true,
+	}
+	if req.Active != nil {
+		promotion.Active = *req.Active
+	}
+	return promotion
+}
+
+func (h *PromotionHandler) Create(c fiber.Ctx) error {
+	req := &request.PromotionRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	data, err := h.promotionService.Create(c.Context(), promotionFromRequest(req))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data created successfully",
+		"data":    data,
+	})
+}
+
+func (h *PromotionHandler) Update(c fiber.Ctx) error {
+	req := &request.PromotionRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id promotion",
+			"error":   nil,
+		})
+	}
+
+	promotion := promotionFromRequest(req)
+	promotion.ID = id
+	data, err := h.promotionService.Update(c.Context(), promotion)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Promotion not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data updated successfully",
+		"data":    data,
+	})
+}
+
+func (h *PromotionHandler) Delete(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id promotion",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.promotionService.Delete(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Promotion not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data deleted successfully",
+		"data":    data,
+	})
+}
+
+func (h *PromotionHandler) GetAll(c fiber.Ctx) error {
+	list, err := h.promotionService.FindAll(c.Context())
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *PromotionHandler) GetDetail(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id promotion",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.promotionService.FindOne(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Promotion not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/money"
 	"github.com/illusi03/golearn/internal/service"
 )
 
@@ -20,7 +22,23 @@ func (h *ReportHandler) GetTodayReport(c fiber.Ctx) error {
 	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	endDate := startDate.AddDate(0, 0, 1)
 
-	data, err := h.reportService.GetReport(c.Context(), startDate, endDate)
+	currency, ok := reportCurrency(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "unsupported currency",
+		})
+	}
+
+	storeID, err := storeQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data, err := h.reportService.GetReport(c.Context(), startDate, endDate, currency, storeID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -60,7 +78,23 @@ func (h *ReportHandler) GetReport(c fiber.Ctx) error {
 
 	endDate = endDate.AddDate(0, 0, 1)
 
-	data, err := h.reportService.GetReport(c.Context(), startDate, endDate)
+	currency, ok := reportCurrency(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "unsupported currency",
+		})
+	}
+
+	storeID, err := storeQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	data, err := h.reportService.GetReport(c.Context(), startDate, endDate, currency, storeID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -70,3 +104,10 @@ func (h *ReportHandler) GetReport(c fiber.Ctx) error {
 
 	return c.JSON(data)
 }
+
+// reportCurrency reads the optional currency query parameter. Reports never
+// add up amounts across currencies, so one currency is reported at a time.
+func reportCurrency(c fiber.Ctx) (string, bool) {
+	currency := strings.ToUpper(c.Query("currency", money.DefaultCurrency))
+	return currency, money.IsSupported(currency)
+}
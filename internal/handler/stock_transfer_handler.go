@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type StockTransferHandler struct {
+	transferService *service.StockTransferService
+}
+
+func NewStockTransferHandler(transferService *service.StockTransferService) *StockTransferHandler {
+	return &StockTransferHandler{
+		transferService: transferService,
+	}
+}
+
+func (h *StockTransferHandler) Create(c fiber.Ctx) error {
+	req := &request.StockTransferRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	transfer := &model.StockTransferModel{
+		SourceStoreID:      req.SourceStoreID,
+		DestinationStoreID: req.DestinationStoreID,
+		Note:               req.Note,
+		Items:              make([]model.StockTransferItemModel, len(req.Items)),
+	}
+	for i, item := range req.Items {
+		transfer.Items[i] = model.StockTransferItemModel{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		}
+	}
+
+	data, err := h.transferService.Create(c.Context(), transfer)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Transfer requested successfully",
+		"data":    data,
+	})
+}
+
+func (h *StockTransferHandler) GetAll(c fiber.Ctx) error {
+	storeID, err := storeQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	list, err := h.transferService.FindAll(c.Context(), storeID, c.Query("status"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *StockTransferHandler) GetDetail(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id transfer",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.transferService.FindOne(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Transfer not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
+
+func (h *StockTransferHandler) Dispatch(c fiber.Ctx) error {
+	return h.transition(c, h.transferService.Dispatch, "Transfer dispatched successfully")
+}
+
+func (h *StockTransferHandler) Receive(c fiber.Ctx) error {
+	return h.transition(c, h.transferService.Receive, "Transfer received successfully")
+}
+
+func (h *StockTransferHandler) Cancel(c fiber.Ctx) error {
+	return h.transition(c, h.transferService.Cancel, "Transfer cancelled successfully")
+}
+
+// transition runs one step of a transfer. A transfer that is not in the
+// status the step starts from is a conflict.
+func (h *StockTransferHandler) transition(
+	c fiber.Ctx,
+	step func(ctx context.Context, id int) (*model.StockTransferModel, error),
+	message string,
+) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id transfer",
+			"error":   nil,
+		})
+	}
+
+	data, err := step(c.Context(), id)
+	var statusErr *repository.TransferStatusError
+	if errors.As(err, &statusErr) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   fiber.Map{"status": statusErr.Status},
+		})
+	}
+	if errors.Is(err, repository.ErrTransferInsufficientStock) || errors.Is(err, repository.ErrTransferStockMismatch) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Transfer not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data":    data,
+	})
+}
@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/repository"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type StocktakeHandler struct {
+	stocktakeService *service.StocktakeService
+}
+
+func NewStocktakeHandler(stocktakeService *service.StocktakeService) *StocktakeHandler {
+	return &StocktakeHandler{
+		stocktakeService: stocktakeService,
+	}
+}
+
+// stocktakeClosed answers with 409 when err says the stocktake is no longer
+// open.
+func stocktakeClosed(c fiber.Ctx, err error) (bool, error) {
+	var statusErr *repository.StocktakeStatusError
+	if !errors.As(err, &statusErr) {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+		"error":   fiber.Map{"status": statusErr.Status},
+	})
+}
+
+func (h *StocktakeHandler) Create(c fiber.Ctx) error {
+	req := &request.StocktakeRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	data, err := h.stocktakeService.Create(c.Context(), req.StoreID, req.Note)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Stocktake opened successfully",
+		"data":    data,
+	})
+}
+
+func (h *StocktakeHandler) GetAll(c fiber.Ctx) error {
+	storeID, err := storeQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	list, err := h.stocktakeService.FindAll(c.Context(), storeID)
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *StocktakeHandler) GetDetail(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id stocktake",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.stocktakeService.FindOne(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Stocktake not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
+
+func (h *StocktakeHandler) Count(c fiber.Ctx) error {
+	req := &request.StocktakeCountRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id stocktake",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.stocktakeService.Count(c.Context(), id, req)
+	if handled, err := stocktakeClosed(c, err); handled {
+		return err
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Stocktake not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Counts recorded successfully",
+		"data":    data,
+	})
+}
+
+func (h *StocktakeHandler) Finalize(c fiber.Ctx) error {
+	req := &request.FinalizeStocktakeRequest{}
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(req); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"message": "Error validation json",
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id stocktake",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.stocktakeService.Finalize(c.Context(), id, req.ZeroUncounted)
+	if handled, err := stocktakeClosed(c, err); handled {
+		return err
+	}
+	if handled, err := stockReserved(c, err); handled {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Stocktake not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Stocktake finalized successfully",
+		"data":    data,
+	})
+}
+
+func (h *StocktakeHandler) Cancel(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id stocktake",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.stocktakeService.Cancel(c.Context(), id)
+	if handled, err := stocktakeClosed(c, err); handled {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Stocktake not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Stocktake cancelled successfully",
+		"data":    data,
+	})
+}
+
+func (h *StocktakeHandler) GetAdjustments(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id stocktake",
+			"error":   nil,
+		})
+	}
+
+	list, err := h.stocktakeService.FindAdjustments(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if list == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Stocktake not found",
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
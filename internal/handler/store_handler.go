@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+	"github.com/illusi03/golearn/internal/request"
+	"github.com/illusi03/golearn/internal/service"
+)
+
+type StoreHandler struct {
+	storeService *service.StoreService
+}
+
+func NewStoreHandler(storeService *service.StoreService) *StoreHandler {
+	return &StoreHandler{
+		storeService: storeService,
+	}
+}
+
+var errInvalidStoreID = errors.New("invalid store_id")
+
+// storeQuery reads the optional store_id query parameter. Leaving it out
+// covers every store.
+func storeQuery(c fiber.Ctx) (*int, error) {
+	value := c.Query("store_id")
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, errInvalidStoreID
+	}
+	return &id, nil
+}
+
+func storeFromRequest(req *request.StoreRequest) *model.StoreModel {
+	return &model.StoreModel{
+		Name:      req.Name,
+		Code:      req.Code,
+		Address:   req.Address,
+		IsDefault: req.IsDefault,
+	}
+}
+
+func (h *StoreHandler) Create(c fiber.Ctx) error {
+	req := &request.StoreRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	data, err := h.storeService.Create(c.Context(), storeFromRequest(req))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data created successfully",
+		"data":    data,
+	})
+}
+
+func (h *StoreHandler) Update(c fiber.Ctx) error {
+	req := &request.StoreRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id store",
+			"error":   nil,
+		})
+	}
+
+	store := storeFromRequest(req)
+	store.ID = id
+	data, err := h.storeService.Update(c.Context(), store)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Store not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data updated successfully",
+		"data":    data,
+	})
+}
+
+func (h *StoreHandler) Delete(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id store",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.storeService.Delete(c.Context(), id)
+	if errors.Is(err, repository.ErrDefaultStore) ||
+		errors.Is(err, repository.ErrStoreHasStock) ||
+		errors.Is(err, repository.ErrStoreInUse) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Store not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data deleted successfully",
+		"data":    data,
+	})
+}
+
+func (h *StoreHandler) GetAll(c fiber.Ctx) error {
+	list, err := h.storeService.FindAll(c.Context())
+	if err != nil {
+		return fmt.Errorf("Error Occured : %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *StoreHandler) GetDetail(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id store",
+			"error":   nil,
+		})
+	}
+
+	data, err := h.storeService.FindOne(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Store not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    data,
+	})
+}
+
+func (h *StoreHandler) GetStocks(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id store",
+			"error":   nil,
+		})
+	}
+
+	list, err := h.storeService.FindStocks(c.Context(), id)
+	if err != nil {
+		return fmt.Errorf("Error Occured: %w", err)
+	}
+
+	if list == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Store not found",
+			"error":   nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data fetched successfully",
+		"data":    list,
+	})
+}
+
+func (h *StoreHandler) SetStocks(c fiber.Ctx) error {
+	req := &request.SetStoreStockRequest{}
+	if err := c.Bind().Body(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error validation json",
+			"error":   err.Error(),
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"message": "Error id store",
+			"error":   nil,
+		})
+	}
+
+	items := make([]model.StoreStockModel, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = model.StoreStockModel{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Stock:     item.Stock,
+		}
+	}
+
+	data, err := h.storeService.SetStocks(c.Context(), id, items)
+	if handled, err := stockReserved(c, err); handled {
+		return err
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+			"error":   nil,
+		})
+	}
+
+	if !data {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Store not found",
+			"error":   data,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Data updated successfully",
+		"data":    data,
+	})
+}
+
+// stockReserved answers with 409 when err says a write would leave a store
+// with less stock than it has reserved for orders and carts.
+func stockReserved(c fiber.Ctx, err error) (bool, error) {
+	if !errors.Is(err, repository.ErrStockReserved) {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+		"error":   nil,
+	})
+}
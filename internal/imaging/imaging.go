@@ -0,0 +1,66 @@
+// Package imaging scales images down for thumbnails using only the standard
+// library.
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Thumbnail scales src down so that neither side exceeds maxSize, keeping the
+// aspect ratio. Each destination pixel is the average of the source pixels it
+// covers (a box filter), which gives clean results when shrinking and needs
+// no external dependency. Images that already fit are only converted.
+func Thumbnail(src image.Image, maxSize int) *image.RGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	dstW, dstH := srcW, srcH
+	if srcW > maxSize || srcH > maxSize {
+		if srcW >= srcH {
+			dstW = maxSize
+			dstH = max(1, srcH*maxSize/srcW)
+		} else {
+			dstH = maxSize
+			dstW = max(1, srcW*maxSize/srcH)
+		}
+	}
+
+	// Work on premultiplied RGBA so transparent pixels do not bleed their
+	// colour into the edges
+	rgba := image.NewRGBA(image.Rect(0, 0, srcW, srcH))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	if dstW == srcW && dstH == srcH {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0 := y * srcH / dstH
+		y1 := max(y0+1, (y+1)*srcH/dstH)
+		for x := 0; x < dstW; x++ {
+			x0 := x * srcW / dstW
+			x1 := max(x0+1, (x+1)*srcW/dstW)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8((r + n/2) / n)
+			dst.Pix[i+1] = uint8((g + n/2) / n)
+			dst.Pix[i+2] = uint8((b + n/2) / n)
+			dst.Pix[i+3] = uint8((a + n/2) / n)
+		}
+	}
+	return dst
+}
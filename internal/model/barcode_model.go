@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type ProductBarcodeModel struct {
+	ID        int       `json:"id"`
+	ProductID int       `json:"product_id"`
+	VariantID *int      `json:"variant_id"`
+	Barcode   string    `json:"barcode"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProductLookupModel is the product a scanned barcode or SKU resolves to,
+// with the variant when the code belongs to one.
+type ProductLookupModel struct {
+	Product *ProductModel        `json:"product"`
+	Variant *ProductVariantModel `json:"variant"`
+}
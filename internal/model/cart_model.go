@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+const (
+	CartStatusActive     = "active"
+	CartStatusCheckedOut = "checked_out"
+	CartStatusReleased   = "released"
+	CartStatusExpired    = "expired"
+)
+
+// CartModel holds stock back for a sale that is still being rung up. Its
+// items stay reserved until the cart expires, is released or is checked out.
+type CartModel struct {
+	ID            int             `json:"id"`
+	StoreID       int             `json:"store_id"`
+	StoreName     string          `json:"store_name"`
+	Status        string          `json:"status"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	TransactionID *int            `json:"transaction_id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	ClosedAt      *time.Time      `json:"closed_at"`
+	Items         []CartItemModel `json:"items"`
+}
+
+type CartItemModel struct {
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	VariantID   *int    `json:"variant_id"`
+	VariantName *string `json:"variant_name"`
+	Quantity    int     `json:"quantity"`
+}
@@ -1,9 +1,30 @@
 package model
 
+import "time"
+
 type CategoryModel struct {
 	ID          int    `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	ParentID    *int   `json:"parent_id"`
+	// ProductCount is the number of products directly in the category.
+	ProductCount int        `json:"product_count"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	Version      int        `json:"version"`
+}
+
+// What happens to the products of a category when it is deleted.
+const (
+	CategoryDeleteReject   = "reject"
+	CategoryDeleteReassign = "reassign"
+	CategoryDeleteNullify  = "nullify"
+)
+
+// CategoryTreeModel is a category with its subcategories nested below it.
+type CategoryTreeModel struct {
+	CategoryModel
+	Depth    int                 `json:"depth"`
+	Children []CategoryTreeModel `json:"children"`
 }
 
 var LastCategoryId int = 0
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
+type CustomerModel struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Phone     *string   `json:"phone"`
+	Email     *string   `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+
+	LifetimeValue []CustomerValueModel `json:"lifetime_value,omitempty"`
+}
+
+// CustomerValueModel sums what a customer spent in one currency; amounts in
+// different currencies are never added together.
+type CustomerValueModel struct {
+	Currency         string      `json:"currency"`
+	TotalSpent       money.Money `json:"total_spent"`
+	TotalTransaction int         `json:"total_transaction"`
+	AverageOrder     money.Money `json:"average_order"`
+	FirstPurchaseAt  time.Time   `json:"first_purchase_at"`
+	LastPurchaseAt   time.Time   `json:"last_purchase_at"`
+}
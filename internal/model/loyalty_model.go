@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
+const (
+	LoyaltyEntryEarn   = "earn"
+	LoyaltyEntryRedeem = "redeem"
+	LoyaltyEntryExpire = "expire"
+	LoyaltyEntryAdjust = "adjust"
+)
+
+// LoyaltyProgramModel sets how customers earn and redeem points for sales in
+// one currency.
+type LoyaltyProgramModel struct {
+	Currency   string      `json:"currency"`
+	EarnAmount money.Money `json:"earn_amount"`
+	EarnPoints int         `json:"earn_points"`
+	PointValue money.Money `json:"point_value"`
+	ExpiryDays *int        `json:"expiry_days"`
+	Active     bool        `json:"active"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type LoyaltyMultiplierModel struct {
+	CategoryID    int    `json:"category_id"`
+	CategoryName  string `json:"category_name"`
+	MultiplierBps int    `json:"multiplier_bps"`
+}
+
+// LoyaltyEntryModel is one change to the points of a customer. Remaining is
+// what is left of a credit after later debits and expiry.
+type LoyaltyEntryModel struct {
+	ID            int        `json:"id"`
+	CustomerID    int        `json:"customer_id"`
+	TransactionID *int       `json:"transaction_id"`
+	Kind          string     `json:"kind"`
+	Points        int        `json:"points"`
+	Remaining     int        `json:"remaining"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	Note          string     `json:"note"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// LoyaltyBalanceModel is the spendable points of a customer with the points
+// that are due to expire, soonest first.
+type LoyaltyBalanceModel struct {
+	CustomerID int                    `json:"customer_id"`
+	Balance    int                    `json:"balance"`
+	Expiring   []LoyaltyExpiringModel `json:"expiring"`
+}
+
+type LoyaltyExpiringModel struct {
+	Points    int       `json:"points"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
+type ModifierGroupModel struct {
+	ID        int             `json:"id"`
+	ProductID int             `json:"product_id"`
+	Name      string          `json:"name"`
+	MinSelect int             `json:"min_select"`
+	MaxSelect *int            `json:"max_select"`
+	CreatedAt time.Time       `json:"created_at"`
+	Modifiers []ModifierModel `json:"modifiers"`
+}
+
+type ModifierModel struct {
+	ID         int         `json:"id"`
+	GroupID    int         `json:"group_id"`
+	Name       string      `json:"name"`
+	PriceDelta money.Money `json:"price_delta"`
+	Stock      *int        `json:"stock"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+const (
+	OrderStatusOpen      = "open"
+	OrderStatusHeld      = "held"
+	OrderStatusSettled   = "settled"
+	OrderStatusCancelled = "cancelled"
+)
+
+// OrderModel is a tab that collects items before it is settled into a
+// transaction. When ReserveStock is set the items are held back from other
+// sales of the store.
+type OrderModel struct {
+	ID            int              `json:"id"`
+	StoreID       int              `json:"store_id"`
+	StoreName     string           `json:"store_name"`
+	CustomerID    *int             `json:"customer_id"`
+	Label         string           `json:"label"`
+	Note          string           `json:"note"`
+	Status        string           `json:"status"`
+	ReserveStock  bool             `json:"reserve_stock"`
+	Version       int              `json:"version"`
+	TransactionID *int             `json:"transaction_id"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	SettledAt     *time.Time       `json:"settled_at"`
+	CancelledAt   *time.Time       `json:"cancelled_at"`
+	Items         []OrderItemModel `json:"items"`
+}
+
+type OrderItemModel struct {
+	ID          int       `json:"id"`
+	OrderID     int       `json:"order_id"`
+	ProductID   int       `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	VariantID   *int      `json:"variant_id"`
+	VariantName *string   `json:"variant_name"`
+	ModifierIDs []int     `json:"modifier_ids"`
+	Quantity    int       `json:"quantity"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "github.com/illusi03/golearn/internal/money"
+
+const (
+	PaymentMethodCash    = "cash"
+	PaymentMethodCard    = "card"
+	PaymentMethodQRIS    = "qris"
+	PaymentMethodEWallet = "e_wallet"
+	PaymentMethodPoints  = "points"
+)
+
+type PaymentModel struct {
+	ID             int         `json:"id"`
+	TransactionID  int         `json:"transaction_id"`
+	Method         string      `json:"method"`
+	Amount         money.Money `json:"amount"`
+	TenderedAmount money.Money `json:"tendered_amount"`
+	ChangeAmount   money.Money `json:"change_amount"`
+	Reference      string      `json:"reference"`
+}
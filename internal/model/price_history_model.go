@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
+// Where a price change came from, as recorded in the price history.
+const (
+	PriceSourceManual    = "manual"
+	PriceSourceScheduled = "scheduled"
+	PriceSourceImport    = "import"
+	PriceSourceBatch     = "batch"
+)
+
+const (
+	PriceChangePending   = "pending"
+	PriceChangeApplied   = "applied"
+	PriceChangeCancelled = "cancelled"
+	PriceChangeFailed    = "failed"
+)
+
+// PriceHistoryModel is one price change of a product, or of one of its
+// variants when VariantID is set. OldPrice is nil for the first price.
+type PriceHistoryModel struct {
+	ID        int          `json:"id"`
+	ProductID int          `json:"product_id"`
+	VariantID *int         `json:"variant_id"`
+	OldPrice  *money.Money `json:"old_price"`
+	NewPrice  money.Money  `json:"new_price"`
+	Source    string       `json:"source"`
+	ChangedAt time.Time    `json:"changed_at"`
+}
+
+// ScheduledPriceChangeModel is a price that takes effect at EffectiveAt, for
+// the product or for one of its variants when VariantID is set. It fails
+// instead of being applied when the product no longer has the currency of
+// the price.
+type ScheduledPriceChangeModel struct {
+	ID            int         `json:"id"`
+	ProductID     int         `json:"product_id"`
+	VariantID     *int        `json:"variant_id"`
+	Price         money.Money `json:"price"`
+	EffectiveAt   time.Time   `json:"effective_at"`
+	Status        string      `json:"status"`
+	Note          string      `json:"note"`
+	FailureReason *string     `json:"failure_reason"`
+	CreatedAt     time.Time   `json:"created_at"`
+	AppliedAt     *time.Time  `json:"applied_at"`
+}
+
+// PriceTimelineModel lists the past prices of a product and its variants,
+// oldest first, and the changes still waiting to take effect.
+type PriceTimelineModel struct {
+	ProductID    int                         `json:"product_id"`
+	CurrentPrice money.Money                 `json:"current_price"`
+	History      []PriceHistoryModel         `json:"history"`
+	Upcoming     []ScheduledPriceChangeModel `json:"upcoming"`
+}
@@ -0,0 +1,37 @@
+package model
+
+const (
+	BatchOpCreate          = "create"
+	BatchOpUpdate          = "update"
+	BatchOpDelete          = "delete"
+	BatchOpRepriceCategory = "reprice_category"
+)
+
+const (
+	BatchStatusOK      = "ok"
+	BatchStatusError   = "error"
+	BatchStatusSkipped = "skipped"
+)
+
+// ProductBatchResultModel reports a batch operation by operation. The batch
+// runs in one transaction, so nothing is committed unless every operation
+// succeeded; the operations that did not get to run are reported as skipped.
+type ProductBatchResultModel struct {
+	Committed bool                    `json:"committed"`
+	Total     int                     `json:"total"`
+	Succeeded int                     `json:"succeeded"`
+	Failed    int                     `json:"failed"`
+	Results   []ProductBatchItemModel `json:"results"`
+}
+
+// ProductBatchItemModel is the outcome of one operation. Affected counts the
+// products repriced by a reprice_category operation.
+type ProductBatchItemModel struct {
+	Index     int    `json:"index"`
+	Op        string `json:"op"`
+	Status    string `json:"status"`
+	ProductID *int   `json:"product_id,omitempty"`
+	Version   *int   `json:"version,omitempty"`
+	Affected  int    `json:"affected"`
+	Error     string `json:"error,omitempty"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type ProductImageModel struct {
+	ID           int       `json:"id"`
+	ProductID    int       `json:"product_id"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Position     int       `json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package model
+
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionError  = "error"
+)
+
+// ProductImportResultModel reports what an import did, or would do in a dry
+// run, row by row. Nothing is committed unless every row is valid.
+type ProductImportResultModel struct {
+	DryRun            bool                    `json:"dry_run"`
+	Committed         bool                    `json:"committed"`
+	TotalRows         int                     `json:"total_rows"`
+	Created           int                     `json:"created"`
+	Updated           int                     `json:"updated"`
+	Failed            int                     `json:"failed"`
+	CategoriesCreated []string                `json:"categories_created"`
+	Rows              []ProductImportRowModel `json:"rows"`
+}
+
+// ProductImportRowModel is the outcome of one CSV row. Row is the line number
+// in the file, the header being line 1.
+type ProductImportRowModel struct {
+	Row       int      `json:"row"`
+	SKU       string   `json:"sku"`
+	Name      string   `json:"name"`
+	Action    string   `json:"action"`
+	ProductID *int     `json:"product_id"`
+	Errors    []string `json:"errors"`
+}
@@ -1,11 +1,64 @@
 package model
 
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
+// ProductModel carries the stock on hand over all stores in Stock. Reserved
+// is the part held for orders and carts; Available is what can still be sold.
 type ProductModel struct {
-	ID           int     `json:"id"`
-	Name         string  `json:"name"`
-	Description  string  `json:"description"`
-	Price        int     `json:"price"`
-	Stock        int     `json:"stock"`
-	CategoryID   *int    `json:"category_id"`
-	CategoryName *string `json:"category_name"`
+	ID           int         `json:"id"`
+	Name         string      `json:"name"`
+	SKU          *string     `json:"sku"`
+	Description  string      `json:"description"`
+	Price        money.Money `json:"price"`
+	Stock        int         `json:"stock"`
+	Reserved     int         `json:"reserved"`
+	Available    int         `json:"available"`
+	CategoryID   *int        `json:"category_id"`
+	CategoryName *string     `json:"category_name"`
+	ImageURL     *string     `json:"image_url"`
+	ThumbnailURL *string     `json:"thumbnail_url"`
+	DeletedAt    *time.Time  `json:"deleted_at,omitempty"`
+	Version      int         `json:"version"`
+
+	Variants       []ProductVariantModel `json:"variants,omitempty"`
+	ModifierGroups []ModifierGroupModel  `json:"modifier_groups,omitempty"`
+	Barcodes       []ProductBarcodeModel `json:"barcodes,omitempty"`
+	Images         []ProductImageModel   `json:"images,omitempty"`
+	Stocks         []StoreStockModel     `json:"stocks,omitempty"`
+}
+
+const (
+	SearchMatchFullText = "fulltext"
+	SearchMatchFuzzy    = "fuzzy"
+)
+
+// ProductSearchModel is a product matched by a search. Match is fulltext when
+// the search document matched and fuzzy when only the trigram fallback did.
+type ProductSearchModel struct {
+	ProductModel
+	Rank      float64               `json:"rank"`
+	Match     string                `json:"match"`
+	Highlight ProductHighlightModel `json:"highlight"`
+}
+
+// ProductHighlightModel holds the matched fields, HTML escaped, with the
+// matching terms wrapped in <mark> tags.
+type ProductHighlightModel struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ProductSuggestionModel is a lightweight autocomplete entry for the till.
+type ProductSuggestionModel struct {
+	ID           int         `json:"id"`
+	Name         string      `json:"name"`
+	SKU          *string     `json:"sku"`
+	Price        money.Money `json:"price"`
+	Stock        int         `json:"stock"`
+	ThumbnailURL *string     `json:"thumbnail_url"`
+	Highlight    string      `json:"highlight"`
 }
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
+const (
+	PromotionTypePercentage = "percentage"
+	PromotionTypeFixed      = "fixed"
+	PromotionTypeBuyXGetY   = "buy_x_get_y"
+
+	PromotionScopeLine = "line"
+	PromotionScopeCart = "cart"
+)
+
+type PromotionModel struct {
+	ID             int         `json:"id"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	Type           string      `json:"type"`
+	Scope          string      `json:"scope"`
+	Value          int         `json:"value"`
+	Amount         money.Money `json:"amount"`
+	BuyQuantity    int         `json:"buy_quantity"`
+	GetQuantity    int         `json:"get_quantity"`
+	ProductID      *int        `json:"product_id"`
+	CategoryID     *int        `json:"category_id"`
+	MinSubtotal    money.Money `json:"min_subtotal"`
+	RequiresCoupon bool        `json:"requires_coupon"`
+	StartsAt       *time.Time  `json:"starts_at"`
+	EndsAt         *time.Time  `json:"ends_at"`
+	DailyStartTime *string     `json:"daily_start_time"`
+	DailyEndTime   *string     `json:"daily_end_time"`
+	Active         bool        `json:"active"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+type CouponModel struct {
+	ID            int        `json:"id"`
+	Code          string     `json:"code"`
+	PromotionID   int        `json:"promotion_id"`
+	PromotionName *string    `json:"promotion_name"`
+	UsageLimit    *int       `json:"usage_limit"`
+	UsedCount     int        `json:"used_count"`
+	Active        bool       `json:"active"`
+	StartsAt      *time.Time `json:"starts_at"`
+	EndsAt        *time.Time `json:"ends_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -1,12 +1,55 @@
 package model
 
+import "github.com/illusi03/golearn/internal/money"
+
+// ReportModel covers a single store when StoreID is set, otherwise every
+// store together, with SalesByStore splitting the totals per store.
 type ReportModel struct {
-	TotalRevenue     int              `json:"total_revenue"`
-	TotalTransaction int              `json:"total_transaksi"`
-	BestSeller       *BestSellerModel `json:"produk_terlaris"`
+	Currency         string                      `json:"mata_uang"`
+	StoreID          *int                        `json:"store_id"`
+	TotalRevenue     money.Money                 `json:"total_revenue"`
+	TotalTransaction int                         `json:"total_transaksi"`
+	BestSeller       *BestSellerModel            `json:"produk_terlaris"`
+	RevenueByMethod  []PaymentMethodRevenueModel `json:"pendapatan_per_metode"`
+	TotalTax         money.Money                 `json:"total_pajak"`
+	TaxBreakdown     []TaxSummaryModel           `json:"rincian_pajak"`
+	SalesByCategory  []CategorySalesModel        `json:"penjualan_per_kategori"`
+	SalesByStore     []StoreSalesModel           `json:"penjualan_per_toko"`
 }
 
 type BestSellerModel struct {
 	Name    string `json:"nama"`
 	QtySold int    `json:"qty_terjual"`
 }
+
+type PaymentMethodRevenueModel struct {
+	Method           string      `json:"metode"`
+	Total            money.Money `json:"total"`
+	TotalTransaction int         `json:"total_transaksi"`
+}
+
+type TaxSummaryModel struct {
+	TaxRateID     *int        `json:"tax_rate_id"`
+	Name          string      `json:"nama"`
+	RateBps       int         `json:"rate_bps"`
+	Inclusive     bool        `json:"inclusive"`
+	TaxableAmount money.Money `json:"dasar_pengenaan_pajak"`
+	TaxAmount     money.Money `json:"total_pajak"`
+}
+
+// CategorySalesModel totals the lines sold in a category and in all of its
+// subcategories, so a parent category rolls up its whole subtree.
+type CategorySalesModel struct {
+	CategoryID int         `json:"category_id"`
+	ParentID   *int        `json:"parent_id"`
+	Name       string      `json:"nama"`
+	QtySold    int         `json:"qty_terjual"`
+	Total      money.Money `json:"total"`
+}
+
+type StoreSalesModel struct {
+	StoreID          int         `json:"store_id"`
+	Name             string      `json:"nama"`
+	TotalRevenue     money.Money `json:"total_revenue"`
+	TotalTransaction int         `json:"total_transaksi"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
+const (
+	ShiftStatusOpen   = "open"
+	ShiftStatusClosed = "closed"
+)
+
+type ShiftModel struct {
+	ID               int          `json:"id"`
+	StoreID          int          `json:"store_id"`
+	Status           string       `json:"status"`
+	Currency         string       `json:"currency"`
+	OpeningFloat     money.Money  `json:"opening_float"`
+	TotalSales       money.Money  `json:"total_sales"`
+	CashSales        money.Money  `json:"cash_sales"`
+	TotalTransaction int          `json:"total_transaction"`
+	ExpectedCash     money.Money  `json:"expected_cash"`
+	CountedCash      *money.Money `json:"counted_cash"`
+	Variance         *money.Money `json:"variance"`
+	OpeningNote      string       `json:"opening_note"`
+	ClosingNote      string       `json:"closing_note"`
+	OpenedAt         time.Time    `json:"opened_at"`
+	ClosedAt         *time.Time   `json:"closed_at"`
+}
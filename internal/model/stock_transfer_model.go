@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	TransferStatusRequested = "requested"
+	TransferStatusInTransit = "in_transit"
+	TransferStatusReceived  = "received"
+	TransferStatusCancelled = "cancelled"
+)
+
+type StockTransferModel struct {
+	ID                   int                      `json:"id"`
+	SourceStoreID        int                      `json:"source_store_id"`
+	SourceStoreName      string                   `json:"source_store_name"`
+	DestinationStoreID   int                      `json:"destination_store_id"`
+	DestinationStoreName string                   `json:"destination_store_name"`
+	Status               string                   `json:"status"`
+	Note                 string                   `json:"note"`
+	RequestedAt          time.Time                `json:"requested_at"`
+	DispatchedAt         *time.Time               `json:"dispatched_at"`
+	ReceivedAt           *time.Time               `json:"received_at"`
+	CancelledAt          *time.Time               `json:"cancelled_at"`
+	Items                []StockTransferItemModel `json:"items"`
+}
+
+type StockTransferItemModel struct {
+	ID          int     `json:"id"`
+	TransferID  int     `json:"transfer_id"`
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	VariantID   *int    `json:"variant_id"`
+	VariantName *string `json:"variant_name"`
+	Quantity    int     `json:"quantity"`
+}
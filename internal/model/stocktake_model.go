@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+const (
+	StocktakeStatusOpen      = "open"
+	StocktakeStatusFinalized = "finalized"
+	StocktakeStatusCancelled = "cancelled"
+)
+
+const (
+	StocktakeCountAdd = "add"
+	StocktakeCountSet = "set"
+)
+
+const AdjustmentReasonStocktake = "stocktake"
+
+// StocktakeModel is a physical count of one store. The totals cover the
+// items counted so far.
+type StocktakeModel struct {
+	ID            int                  `json:"id"`
+	StoreID       int                  `json:"store_id"`
+	StoreName     string               `json:"store_name"`
+	Status        string               `json:"status"`
+	Note          string               `json:"note"`
+	TotalItems    int                  `json:"total_items"`
+	CountedItems  int                  `json:"counted_items"`
+	TotalVariance int                  `json:"total_variance"`
+	CreatedAt     time.Time            `json:"created_at"`
+	FinalizedAt   *time.Time           `json:"finalized_at"`
+	CancelledAt   *time.Time           `json:"cancelled_at"`
+	Items         []StocktakeItemModel `json:"items,omitempty"`
+}
+
+// StocktakeItemModel compares the stock of an item when the stocktake was
+// opened with its count. Counted and Variance stay nil until it is counted.
+type StocktakeItemModel struct {
+	ProductID   int        `json:"product_id"`
+	ProductName string     `json:"product_name"`
+	VariantID   *int       `json:"variant_id"`
+	VariantName *string    `json:"variant_name"`
+	Expected    int        `json:"expected"`
+	Counted     *int       `json:"counted"`
+	Variance    *int       `json:"variance"`
+	CountedAt   *time.Time `json:"counted_at"`
+}
+
+type StockAdjustmentModel struct {
+	ID          int       `json:"id"`
+	StoreID     int       `json:"store_id"`
+	ProductID   int       `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	VariantID   *int      `json:"variant_id"`
+	VariantName *string   `json:"variant_name"`
+	Quantity    int       `json:"quantity"`
+	Reason      string    `json:"reason"`
+	StocktakeID *int      `json:"stocktake_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+type StoreModel struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Code      string    `json:"code"`
+	Address   string    `json:"address"`
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StoreStockModel is the stock one store holds of a product, or of one of
+// its variants when VariantID is set. Incoming is stock dispatched to the
+// store by a transfer that has not been received yet. Reserved is the part
+// of Stock held for orders and carts.
+type StoreStockModel struct {
+	StoreID     int     `json:"store_id"`
+	StoreName   string  `json:"store_name"`
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	VariantID   *int    `json:"variant_id"`
+	VariantName *string `json:"variant_name"`
+	Stock       int     `json:"stock"`
+	Incoming    int     `json:"incoming"`
+	Reserved    int     `json:"reserved"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type TaxRateModel struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Code      string    `json:"code"`
+	RateBps   int       `json:"rate_bps"`
+	Inclusive bool      `json:"inclusive"`
+	Compound  bool      `json:"compound"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
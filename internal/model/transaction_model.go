@@ -1,19 +1,69 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
 
 type TransactionModel struct {
-	ID          int                      `json:"id"`
-	TotalAmount int                      `json:"total_amount"`
-	CreatedAt   time.Time                `json:"created_at"`
-	Details     []TransactionDetailModel `json:"details"`
+	ID             int                      `json:"id"`
+	Currency       string                   `json:"currency"`
+	SubtotalAmount money.Money              `json:"subtotal_amount"`
+	DiscountAmount money.Money              `json:"discount_amount"`
+	TaxAmount      money.Money              `json:"tax_amount"`
+	TotalAmount    money.Money              `json:"total_amount"`
+	PromotionID    *int                     `json:"promotion_id"`
+	CouponID       *int                     `json:"coupon_id"`
+	ShiftID        *int                     `json:"shift_id"`
+	StoreID        int                      `json:"store_id"`
+	CustomerID     *int                     `json:"customer_id"`
+	PointsEarned   int                      `json:"points_earned"`
+	PointsRedeemed int                      `json:"points_redeemed"`
+	OrderID        *int                     `json:"order_id"`
+	CartID         *int                     `json:"cart_id"`
+	CreatedAt      time.Time                `json:"created_at"`
+	Details        []TransactionDetailModel `json:"details"`
+	Payments       []PaymentModel           `json:"payments"`
+
+	// OrderVersion is the version of the order being settled
+	OrderVersion int `json:"-"`
 }
 
 type TransactionDetailModel struct {
-	ID            int     `json:"id"`
-	TransactionID int     `json:"transaction_id"`
-	ProductID     int     `json:"product_id"`
-	ProductName   *string `json:"product_name"`
-	Quantity      int     `json:"quantity"`
-	Subtotal      int     `json:"subtotal"`
+	ID             int         `json:"id"`
+	TransactionID  int         `json:"transaction_id"`
+	ProductID      int         `json:"product_id"`
+	ProductName    *string     `json:"product_name"`
+	VariantID      *int        `json:"variant_id"`
+	VariantName    *string     `json:"variant_name"`
+	Quantity       int         `json:"quantity"`
+	UnitPrice      money.Money `json:"unit_price"`
+	Subtotal       money.Money `json:"subtotal"`
+	DiscountAmount money.Money `json:"discount_amount"`
+	PromotionID    *int        `json:"promotion_id"`
+	TaxAmount      money.Money `json:"tax_amount"`
+
+	Modifiers []TransactionDetailModifierModel `json:"modifiers"`
+	Taxes     []TransactionDetailTaxModel      `json:"taxes"`
+}
+
+type TransactionDetailModifierModel struct {
+	ID                  int         `json:"id"`
+	TransactionDetailID int         `json:"transaction_detail_id"`
+	ModifierID          *int        `json:"modifier_id"`
+	GroupName           string      `json:"group_name"`
+	Name                string      `json:"name"`
+	PriceDelta          money.Money `json:"price_delta"`
+}
+
+type TransactionDetailTaxModel struct {
+	ID                  int         `json:"id"`
+	TransactionDetailID int         `json:"transaction_detail_id"`
+	TaxRateID           *int        `json:"tax_rate_id"`
+	Name                string      `json:"name"`
+	RateBps             int         `json:"rate_bps"`
+	Inclusive           bool        `json:"inclusive"`
+	TaxableAmount       money.Money `json:"taxable_amount"`
+	TaxAmount           money.Money `json:"tax_amount"`
 }
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
+type ProductVariantModel struct {
+	ID        int         `json:"id"`
+	ProductID int         `json:"product_id"`
+	Name      string      `json:"name"`
+	SKU       *string     `json:"sku"`
+	Price     money.Money `json:"price"`
+	Stock     int         `json:"stock"`
+	Reserved  int         `json:"reserved"`
+	Available int         `json:"available"`
+	CreatedAt time.Time   `json:"created_at"`
+}
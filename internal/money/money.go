@@ -0,0 +1,265 @@
+// Package money implements an integer money type. Amounts are kept in the
+// minor unit of their ISO 4217 currency so they can be stored in BIGINT
+// columns and never go through floating point.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+	ErrOverflow         = errors.New("money: amount overflow")
+	ErrInvalidCurrency  = errors.New("money: invalid currency")
+)
+
+// DefaultCurrency is used for amounts that are sent without a currency.
+var DefaultCurrency = "IDR"
+
+// minorUnits lists the number of decimals of the minor unit per currency.
+// IDR is listed with 0 decimals because sen are not used in practice.
+var minorUnits = map[string]int{
+	"IDR": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"AUD": 2,
+	"SGD": 2,
+	"MYR": 2,
+	"THB": 2,
+	"PHP": 2,
+	"CNY": 2,
+	"HKD": 2,
+}
+
+// IsSupported reports whether the currency code is known.
+func IsSupported(currency string) bool {
+	_, ok := minorUnits[currency]
+	return ok
+}
+
+// MinorUnits returns the number of decimals of the currency's minor unit.
+func MinorUnits(currency string) int {
+	return minorUnits[currency]
+}
+
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) sameCurrency(o Money) error {
+	if m.Currency != o.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return nil
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	if (o.Amount > 0 && m.Amount > math.MaxInt64-o.Amount) ||
+		(o.Amount < 0 && m.Amount < math.MinInt64-o.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return New(m.Amount+o.Amount, m.Currency), nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(New(-o.Amount, o.Currency))
+}
+
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Amount == 0 || n == 0 {
+		return Zero(m.Currency), nil
+	}
+	result := m.Amount * n
+	if result/n != m.Amount || (m.Amount == -1 && n == math.MinInt64) || (n == -1 && m.Amount == math.MinInt64) {
+		return Money{}, ErrOverflow
+	}
+	return New(result, m.Currency), nil
+}
+
+// MulDiv returns m * num / den rounded half away from zero. The intermediate
+// product is computed without overflow, so it is safe for percentages and
+// basis points on large amounts.
+func (m Money) MulDiv(num, den int64) (Money, error) {
+	if den == 0 {
+		return Money{}, errors.New("money: division by zero")
+	}
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(num))
+	d := big.NewInt(den)
+	quotient, remainder := new(big.Int).QuoRem(product, d, new(big.Int))
+
+	// Round half away from zero: compare twice the remainder with the divisor.
+	remainder.Abs(remainder).Lsh(remainder, 1)
+	if remainder.Cmp(new(big.Int).Abs(d)) >= 0 {
+		if product.Sign()*d.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	if !quotient.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return New(quotient.Int64(), m.Currency), nil
+}
+
+// Cmp compares two amounts of the same currency and returns -1, 0 or +1.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// String formats the amount in major units, e.g. "USD 12.50" or "IDR 15000".
+func (m Money) String() string {
+	return m.Currency + " " + m.Decimal()
+}
+
+// Decimal formats the amount in major units without the currency, e.g.
+// "12.50". It is the format Parse reads back.
+func (m Money) Decimal() string {
+	decimals := MinorUnits(m.Currency)
+	if decimals == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	amount := new(big.Int).SetInt64(m.Amount)
+	if amount.Sign() < 0 {
+		sign = "-"
+		amount.Abs(amount)
+	}
+	digits := amount.String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	split := len(digits) - decimals
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// Parse reads an amount written in major units, e.g. "12.5" USD is 1250
+// cents. More decimals than the currency's minor unit allows are rejected
+// rather than rounded.
+func Parse(text string, currency string) (Money, error) {
+	if !IsSupported(currency) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidCurrency, currency)
+	}
+
+	text = strings.TrimSpace(text)
+	negative := strings.HasPrefix(text, "-")
+	whole, fraction, hasFraction := strings.Cut(strings.TrimPrefix(text, "-"), ".")
+	decimals := MinorUnits(currency)
+	if whole == "" || (hasFraction && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("money: invalid amount %q", text)
+	}
+	if len(fraction) > decimals {
+		return Money{}, fmt.Errorf("money: %s allows at most %d decimals", currency, decimals)
+	}
+
+	// Parse with the sign so the most negative amount is not an overflow
+	digits := whole + fraction + strings.Repeat("0", decimals-len(fraction))
+	if negative {
+		digits = "-" + digits
+	}
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrOverflow
+	}
+	return New(amount, currency), nil
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON always writes the amount in minor units next to its currency:
+// {"amount": 1250, "currency": "USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return json.Marshal(moneyJSON{Amount: m.Amount, Currency: currency})
+}
+
+// UnmarshalJSON accepts the object form written by MarshalJSON as well as a
+// bare integer of minor units, which is read in DefaultCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "null" {
+		return nil
+	}
+
+	if strings.HasPrefix(trimmed, "{") {
+		var v moneyJSON
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		if v.Currency == "" {
+			v.Currency = DefaultCurrency
+		}
+		v.Currency = strings.ToUpper(v.Currency)
+		if !IsSupported(v.Currency) {
+			return fmt.Errorf("%w: %q", ErrInvalidCurrency, v.Currency)
+		}
+		*m = New(v.Amount, v.Currency)
+		return nil
+	}
+
+	amount, err := strconv.ParseInt(trimmed, 10, 64)
+	if err != nil {
+		return fmt.Errorf("money: amount must be an integer of minor units: %w", err)
+	}
+	*m = New(amount, DefaultCurrency)
+	return nil
+}
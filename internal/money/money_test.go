@@ -0,0 +1,300 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Money
+		want Money
+		err  error
+	}{
+		{"positive", New(1250, "USD"), New(750, "USD"), New(2000, "USD"), nil},
+		{"negative", New(-1250, "USD"), New(-750, "USD"), New(-2000, "USD"), nil},
+		{"mixed signs", New(100, "IDR"), New(-250, "IDR"), New(-150, "IDR"), nil},
+		{"up to max", New(math.MaxInt64-1, "IDR"), New(1, "IDR"), New(math.MaxInt64, "IDR"), nil},
+		{"down to min", New(math.MinInt64+1, "IDR"), New(-1, "IDR"), New(math.MinInt64, "IDR"), nil},
+		{"overflow", New(math.MaxInt64, "IDR"), New(1, "IDR"), Money{}, ErrOverflow},
+		{"underflow", New(math.MinInt64, "IDR"), New(-1, "IDR"), Money{}, ErrOverflow},
+		{"currency mismatch", New(100, "USD"), New(100, "EUR"), Money{}, ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Add(tt.b)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Add() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Add() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSub(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Money
+		want Money
+		err  error
+	}{
+		{"positive", New(2000, "USD"), New(750, "USD"), New(1250, "USD"), nil},
+		{"below zero", New(100, "IDR"), New(250, "IDR"), New(-150, "IDR"), nil},
+		{"minus negative", New(100, "IDR"), New(-250, "IDR"), New(350, "IDR"), nil},
+		{"min operand", New(0, "IDR"), New(math.MinInt64, "IDR"), Money{}, ErrOverflow},
+		{"underflow", New(math.MinInt64, "IDR"), New(1, "IDR"), Money{}, ErrOverflow},
+		{"overflow", New(math.MaxInt64, "IDR"), New(-1, "IDR"), Money{}, ErrOverflow},
+		{"currency mismatch", New(100, "USD"), New(100, "EUR"), Money{}, ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Sub(tt.b)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Sub() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Sub() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Money
+		n    int64
+		want Money
+		err  error
+	}{
+		{"positive", New(1250, "USD"), 3, New(3750, "USD"), nil},
+		{"negative factor", New(1250, "USD"), -2, New(-2500, "USD"), nil},
+		{"negative amount", New(-1250, "USD"), -2, New(2500, "USD"), nil},
+		{"zero", New(math.MaxInt64, "IDR"), 0, Zero("IDR"), nil},
+		{"overflow", New(math.MaxInt64/2+1, "IDR"), 2, Money{}, ErrOverflow},
+		{"negative overflow", New(math.MinInt64/2-1, "IDR"), 2, Money{}, ErrOverflow},
+		{"min times minus one", New(math.MinInt64, "IDR"), -1, Money{}, ErrOverflow},
+		{"minus one times min", New(-1, "IDR"), math.MinInt64, Money{}, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.Mul(tt.n)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Mul() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Mul() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   int64
+		num, den int64
+		want     int64
+		err      error
+	}{
+		{"exact", 10000, 1100, 10000, 1100, nil},
+		{"round down", 1234, 1, 10, 123, nil},
+		{"round up", 1236, 1, 10, 124, nil},
+		{"tie rounds up", 1235, 1, 10, 124, nil},
+		{"negative tie rounds down", -1235, 1, 10, -124, nil},
+		{"negative below tie", -1234, 1, 10, -123, nil},
+		{"negative divisor tie", 1235, 1, -10, -124, nil},
+		{"both negative tie", -1235, 1, -10, 124, nil},
+		{"odd divisor", 10, 1, 3, 3, nil},
+		{"odd divisor up", 20, 1, 3, 7, nil},
+		{"zero", 0, 1100, 10000, 0, nil},
+		{"large product", math.MaxInt64, 5000, 10000, math.MaxInt64/2 + 1, nil},
+		{"overflow", math.MaxInt64, 3, 2, 0, ErrOverflow},
+		{"negative overflow", math.MinInt64, 3, 2, 0, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.amount, "IDR").MulDiv(tt.num, tt.den)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("MulDiv() error = %v, want %v", err, tt.err)
+			}
+			if err == nil && got != New(tt.want, "IDR") {
+				t.Errorf("MulDiv() = %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulDivByZero(t *testing.T) {
+	if _, err := New(100, "IDR").MulDiv(1, 0); err == nil {
+		t.Fatal("MulDiv() by zero returned no error")
+	}
+}
+
+func TestCmp(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Money
+		want int
+		err  error
+	}{
+		{"less", New(-5, "USD"), New(5, "USD"), -1, nil},
+		{"equal", New(5, "USD"), New(5, "USD"), 0, nil},
+		{"greater", New(5, "USD"), New(-5, "USD"), 1, nil},
+		{"currency mismatch", New(5, "USD"), New(5, "IDR"), 0, ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Cmp(tt.b)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Cmp() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Cmp() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{New(1250, "USD"), "USD 12.50"},
+		{New(5, "USD"), "USD 0.05"},
+		{New(50, "USD"), "USD 0.50"},
+		{New(0, "USD"), "USD 0.00"},
+		{New(-5, "USD"), "USD -0.05"},
+		{New(-1250, "EUR"), "EUR -12.50"},
+		{New(15000, "IDR"), "IDR 15000"},
+		{New(-15000, "IDR"), "IDR -15000"},
+		{New(math.MinInt64, "USD"), "USD -92233720368547758.08"},
+		{New(math.MaxInt64, "USD"), "USD 92233720368547758.07"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.m.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text     string
+		currency string
+		want     int64
+		wantErr  bool
+		err      error
+	}{
+		{"12.5", "USD", 1250, false, nil},
+		{"12.50", "USD", 1250, false, nil},
+		{"12", "USD", 1200, false, nil},
+		{"0.05", "USD", 5, false, nil},
+		{" 7.1 ", "USD", 710, false, nil},
+		{"-12.5", "USD", -1250, false, nil},
+		{"-0.05", "USD", -5, false, nil},
+		{"15000", "IDR", 15000, false, nil},
+		{"-15000", "IDR", -15000, false, nil},
+		{"9223372036854775807", "IDR", math.MaxInt64, false, nil},
+		{"-9223372036854775808", "IDR", math.MinInt64, false, nil},
+		{"-92233720368547758.08", "USD", math.MinInt64, false, nil},
+		{"9223372036854775808", "IDR", 0, true, ErrOverflow},
+		{"92233720368547758.08", "USD", 0, true, ErrOverflow},
+		{"12.345", "USD", 0, true, nil},
+		{"15000.5", "IDR", 0, true, nil},
+		{"12.", "USD", 0, true, nil},
+		{".5", "USD", 0, true, nil},
+		{"", "USD", 0, true, nil},
+		{"-", "USD", 0, true, nil},
+		{"--5", "USD", 0, true, nil},
+		{"+5", "USD", 0, true, nil},
+		{"1,000", "IDR", 0, true, nil},
+		{"1e3", "IDR", 0, true, nil},
+		{"12", "XYZ", 0, true, ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency+" "+tt.text, func(t *testing.T) {
+			got, err := Parse(tt.text, tt.currency)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse() = %v, want an error", got)
+				}
+				if tt.err != nil && !errors.Is(err, tt.err) {
+					t.Fatalf("Parse() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got != New(tt.want, tt.currency) {
+				t.Errorf("Parse() = %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReadsDecimal(t *testing.T) {
+	for _, m := range []Money{New(1250, "USD"), New(-5, "USD"), New(15000, "IDR"), New(math.MinInt64, "EUR")} {
+		got, err := Parse(m.Decimal(), m.Currency)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", m.Decimal(), err)
+		}
+		if got != m {
+			t.Errorf("Parse(%q) = %v, want %v", m.Decimal(), got, m)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Money
+		wantErr bool
+	}{
+		{"object", `{"amount": 1250, "currency": "USD"}`, New(1250, "USD"), false},
+		{"lower case currency", `{"amount": -1250, "currency": "usd"}`, New(-1250, "USD"), false},
+		{"object without currency", `{"amount": 15000}`, New(15000, DefaultCurrency), false},
+		{"bare integer", `15000`, New(15000, DefaultCurrency), false},
+		{"negative integer", `-15000`, New(-15000, DefaultCurrency), false},
+		{"unknown currency", `{"amount": 1, "currency": "XYZ"}`, Money{}, true},
+		{"fraction", `12.5`, Money{}, true},
+		{"string", `"12"`, Money{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	data, err := json.Marshal(New(-1250, "USD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"amount":-1250,"currency":"USD"}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+	data, err = json.Marshal(Money{Amount: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"amount":100,"currency":"`+DefaultCurrency+`"}` {
+		t.Errorf("Marshal() without currency = %s", data)
+	}
+}
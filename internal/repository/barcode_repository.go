@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrBarcodeTaken = errors.New("barcode is already assigned to another product")
+
+const barcodeSelectQuery = `
+	SELECT b.id, b.product_id, b.variant_id, b.barcode, b.created_at
+	FROM product_barcodes b
+`
+
+type BarcodeRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewBarcodeRepository(dbPool *pgxpool.Pool) *BarcodeRepository {
+	return &BarcodeRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanBarcode(row pgx.Row, b *model.ProductBarcodeModel) error {
+	return row.Scan(&b.ID, &b.ProductID, &b.VariantID, &b.Barcode, &b.CreatedAt)
+}
+
+func (r *BarcodeRepository) FindByProduct(ctx context.Context, productID int) ([]model.ProductBarcodeModel, error) {
+	rows, err := r.dbPool.Query(ctx, barcodeSelectQuery+" WHERE b.product_id = $1 ORDER BY b.id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.ProductBarcodeModel, 0)
+	for rows.Next() {
+		var b model.ProductBarcodeModel
+		if err := scanBarcode(rows, &b); err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FindByBarcode expects the barcode in its normalized form.
+func (r *BarcodeRepository) FindByBarcode(
+	ctx context.Context,
+	barcode string,
+) (*model.ProductBarcodeModel, error) {
+	var b model.ProductBarcodeModel
+	err := scanBarcode(r.dbPool.QueryRow(ctx, barcodeSelectQuery+" WHERE b.barcode = $1", barcode), &b)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+// SetProductBarcodes replaces all barcodes of a product.
+func (r *BarcodeRepository) SetProductBarcodes(
+	ctx context.Context,
+	productID int,
+	barcodes []model.ProductBarcodeModel,
+) error {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "DELETE FROM product_barcodes WHERE product_id = $1", productID); err != nil {
+		return err
+	}
+
+	const query = `
+		INSERT INTO product_barcodes (product_id, variant_id, barcode)
+		VALUES ($1, $2, $3)
+	`
+	for _, b := range barcodes {
+		if _, err = tx.Exec(ctx, query, productID, b.VariantID, b.Barcode); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return ErrBarcodeTaken
+			}
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrCartNotFound = errors.New("cart not found")
+
+// CartStatusError is returned when a cart is no longer active, including
+// an active cart whose reservation ran out before the sweeper got to it.
+type CartStatusError struct {
+	Status string
+}
+
+func (e *CartStatusError) Error() string {
+	return fmt.Sprintf("cart is %s", e.Status)
+}
+
+const cartSelectQuery = `
+	SELECT
+		c.id, c.store_id, s.name, c.status, c.expires_at, t.id, c.created_at, c.updated_at, c.closed_at
+	FROM carts c
+	JOIN stores s ON s.id = c.store_id
+	LEFT JOIN transactions t ON t.cart_id = c.id
+`
+
+type CartRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewCartRepository(dbPool *pgxpool.Pool) *CartRepository {
+	return &CartRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanCart(row pgx.Row, c *model.CartModel) error {
+	return row.Scan(
+		&c.ID, &c.StoreID, &c.StoreName, &c.Status, &c.ExpiresAt, &c.TransactionID, &c.CreatedAt,
+		&c.UpdatedAt, &c.ClosedAt,
+	)
+}
+
+// FindAll lists the carts of a store, or of every store when storeID is nil,
+// optionally limited to one status.
+func (r *CartRepository) FindAll(ctx context.Context, storeID *int, status string) ([]model.CartModel, error) {
+	const query = cartSelectQuery + `
+		WHERE ($1::int IS NULL OR c.store_id = $1) AND ($2 = '' OR c.status = $2)
+		ORDER BY c.created_at DESC, c.id DESC
+	`
+	rows, err := r.dbPool.Query(ctx, query, storeID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.CartModel, 0)
+	for rows.Next() {
+		var c model.CartModel
+		if err := scanCart(rows, &c); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachItems(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *CartRepository) FindOne(ctx context.Context, id int) (*model.CartModel, error) {
+	var c model.CartModel
+	if err := scanCart(r.dbPool.QueryRow(ctx, cartSelectQuery+" WHERE c.id = $1", id), &c); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	list := []model.CartModel{c}
+	if err := r.attachItems(ctx, list); err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+// attachItems fills in the stock the carts hold. Carts that are no longer
+// active hold none.
+func (r *CartRepository) attachItems(ctx context.Context, carts []model.CartModel) error {
+	if len(carts) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(carts))
+	byID := make(map[int]*model.CartModel, len(carts))
+	for i := range carts {
+		carts[i].Items = []model.CartItemModel{}
+		ids[i] = carts[i].ID
+		byID[carts[i].ID] = &carts[i]
+	}
+
+	const query = `
+		SELECT sr.cart_id, sr.product_id, p.name, sr.variant_id, v.name, sr.quantity
+		FROM stock_reservations sr
+		JOIN products p ON p.id = sr.product_id
+		LEFT JOIN product_variants v ON v.id = sr.variant_id
+		WHERE sr.cart_id = ANY($1)
+		ORDER BY sr.id
+	`
+	rows, err := r.dbPool.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cartID int
+		var item model.CartItemModel
+		err := rows.Scan(&cartID, &item.ProductID, &item.ProductName, &item.VariantID, &item.VariantName,
+			&item.Quantity)
+		if err != nil {
+			return err
+		}
+		cart := byID[cartID]
+		cart.Items = append(cart.Items, item)
+	}
+	return rows.Err()
+}
+
+// Create opens a cart that reserves its items for ttl.
+func (r *CartRepository) Create(
+	ctx context.Context,
+	storeID int,
+	items []model.CartItemModel,
+	ttl time.Duration,
+) (*model.CartModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const query = `
+		INSERT INTO carts (store_id, expires_at)
+		VALUES ($1, NOW() + $2::interval)
+		RETURNING id
+	`
+	var id int
+	if err = tx.QueryRow(ctx, query, storeID, ttl).Scan(&id); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.ConstraintName == "carts_store_id_fkey" {
+			return nil, ErrStoreNotFound
+		}
+		return nil, err
+	}
+	if err = replaceReservations(ctx, tx, cartOwner(id), storeID, cartReservations(items)); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, id)
+}
+
+// SetItems replaces the items of an active cart and renews its reservation
+// for ttl. It returns nil when the cart does not exist.
+func (r *CartRepository) SetItems(
+	ctx context.Context,
+	id int,
+	items []model.CartItemModel,
+	ttl time.Duration,
+) (*model.CartModel, error) {
+	return r.edit(ctx, id, func(tx pgx.Tx, storeID int) error {
+		if err := replaceReservations(ctx, tx, cartOwner(id), storeID, cartReservations(items)); err != nil {
+			return err
+		}
+		return renewCart(ctx, tx, id, ttl)
+	})
+}
+
+// Extend renews the reservation of an active cart for ttl from now. It
+// returns nil when the cart does not exist.
+func (r *CartRepository) Extend(ctx context.Context, id int, ttl time.Duration) (*model.CartModel, error) {
+	return r.edit(ctx, id, func(tx pgx.Tx, storeID int) error {
+		return renewCart(ctx, tx, id, ttl)
+	})
+}
+
+// Release closes an active cart and gives its stock back. It returns nil
+// when the cart does not exist.
+func (r *CartRepository) Release(ctx context.Context, id int) (*model.CartModel, error) {
+	return r.edit(ctx, id, func(tx pgx.Tx, storeID int) error {
+		return closeCart(ctx, tx, id, model.CartStatusReleased)
+	})
+}
+
+// ExpireDue closes up to limit active carts whose reservation ran out,
+// releasing their stock, and returns how many were closed and the stock they
+// gave back in Reserved. Carts locked by a checkout are left for the next
+// run.
+func (r *CartRepository) ExpireDue(ctx context.Context, limit int) (int, []model.StoreStockModel, error) {
+	const query = `
+		WITH due AS (
+			SELECT id
+			FROM carts
+			WHERE status = 'active' AND expires_at <= NOW()
+			ORDER BY expires_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), released AS (
+			DELETE FROM stock_reservations sr
+			USING due
+			WHERE sr.cart_id = due.id
+			RETURNING sr.store_id, sr.product_id, sr.variant_id, sr.quantity
+		), expired AS (
+			UPDATE carts c
+			SET status = 'expired', closed_at = NOW(), updated_at = NOW()
+			FROM due
+			WHERE c.id = due.id
+			RETURNING c.id
+		)
+		SELECT (SELECT COUNT(*) FROM expired), r.store_id, r.product_id, r.variant_id, r.quantity
+		FROM (SELECT 1) one
+		LEFT JOIN released r ON TRUE
+	`
+	rows, err := r.dbPool.Query(ctx, query, limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	var expired int
+	released := make([]model.StoreStockModel, 0)
+	for rows.Next() {
+		var storeID, productID, quantity *int
+		var s model.StoreStockModel
+		if err := rows.Scan(&expired, &storeID, &productID, &s.VariantID, &quantity); err != nil {
+			return 0, nil, err
+		}
+		// When no stock was released the single row only carries the count
+		if storeID == nil {
+			continue
+		}
+		s.StoreID, s.ProductID, s.Reserved = *storeID, *productID, *quantity
+		released = append(released, s)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+	return expired, released, nil
+}
+
+// edit runs a change to an active cart. It returns nil when the cart does
+// not exist.
+func (r *CartRepository) edit(
+	ctx context.Context,
+	id int,
+	change func(tx pgx.Tx, storeID int) error,
+) (*model.CartModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	storeID, err := lockCart(ctx, tx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err = change(tx, storeID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, id)
+}
+
+// lockCart locks an active cart and returns its store, returning
+// pgx.ErrNoRows when it does not exist and a CartStatusError when it is
+// closed or its reservation ran out.
+func lockCart(ctx context.Context, tx pgx.Tx, id int) (int, error) {
+	var storeID int
+	var status string
+	var expired bool
+	err := tx.QueryRow(ctx, `SELECT store_id, status, expires_at <= NOW() FROM carts WHERE id = $1 FOR UPDATE`, id).
+		Scan(&storeID, &status, &expired)
+	if err != nil {
+		return 0, err
+	}
+	if status != model.CartStatusActive {
+		return 0, &CartStatusError{Status: status}
+	}
+	if expired {
+		return 0, &CartStatusError{Status: model.CartStatusExpired}
+	}
+	return storeID, nil
+}
+
+func renewCart(ctx context.Context, tx pgx.Tx, id int, ttl time.Duration) error {
+	_, err := tx.Exec(ctx, `UPDATE carts SET expires_at = NOW() + $1::interval, updated_at = NOW() WHERE id = $2`,
+		ttl, id)
+	return err
+}
+
+// closeCart moves a cart to a closed status and releases its stock.
+func closeCart(ctx context.Context, tx pgx.Tx, id int, status string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM stock_reservations WHERE cart_id = $1`, id); err != nil {
+		return err
+	}
+	const query = `UPDATE carts SET status = $1, closed_at = NOW(), updated_at = NOW() WHERE id = $2`
+	_, err := tx.Exec(ctx, query, status, id)
+	return err
+}
+
+// consumeCart checks out an active cart of the store being sold from,
+// releasing its reserved stock so the sale deducts it instead.
+func consumeCart(ctx context.Context, tx pgx.Tx, id int, storeID int) error {
+	cartStoreID, err := lockCart(ctx, tx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCartNotFound
+		}
+		return err
+	}
+	if cartStoreID != storeID {
+		return ErrCartNotFound
+	}
+	return closeCart(ctx, tx, id, model.CartStatusCheckedOut)
+}
+
+// cartReservations sums the items of a cart per product and variant.
+func cartReservations(items []model.CartItemModel) map[reservationKey]int {
+	desired := make(map[reservationKey]int, len(items))
+	for _, item := range items {
+		key := reservationKey{productID: item.ProductID}
+		if item.VariantID != nil {
+			key.variantID = *item.VariantID
+		}
+		desired[key] += item.Quantity
+	}
+	return desired
+}
@@ -3,12 +3,41 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/illusi03/golearn/internal/model"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	ErrParentCategoryNotFound   = errors.New("parent category not found")
+	ErrCategoryCycle            = errors.New("a category cannot be moved below itself or one of its subcategories")
+	ErrReassignCategoryNotFound = errors.New("category to reassign products to not found")
+)
+
+// CategoryInUseError is returned when deleting a category that still has
+// products and the products were not reassigned or nullified.
+type CategoryInUseError struct {
+	ProductCount int
+}
+
+func (e *CategoryInUseError) Error() string {
+	return fmt.Sprintf("category is used by %d product(s)", e.ProductCount)
+}
+
+const categorySelectQuery = `
+	SELECT c.id, c.name, c.description, c.parent_id,
+		(SELECT COUNT(*) FROM products p WHERE p.category_id = c.id AND p.deleted_at IS NULL),
+		c.deleted_at, c.version
+	FROM categories c
+`
+
+// categoryMoveLockKey serializes category moves so two concurrent moves can
+// never close a cycle that neither of them could see on its own.
+const categoryMoveLockKey = 0x63617465
+
 type CategoryRepository struct {
 	dbPool *pgxpool.Pool
 }
@@ -19,13 +48,16 @@ func NewCategoryRepository(dbPool *pgxpool.Pool) *CategoryRepository {
 	}
 }
 
-func (a *CategoryRepository) FindAll(ctx context.Context) ([]model.CategoryModel, error) {
-	const query = `
-		SELECT id, name, description
-		FROM categories
-		ORDER BY id
-	`
-	rows, err := a.dbPool.Query(ctx, query)
+func scanCategory(row pgx.Row, c *model.CategoryModel) error {
+	return row.Scan(&c.ID, &c.Name, &c.Description, &c.ParentID, &c.ProductCount, &c.DeletedAt, &c.Version)
+}
+
+func (a *CategoryRepository) FindAll(ctx context.Context, includeDeleted bool) ([]model.CategoryModel, error) {
+	query := categorySelectQuery
+	if !includeDeleted {
+		query += " WHERE c.deleted_at IS NULL"
+	}
+	rows, err := a.dbPool.Query(ctx, query+" ORDER BY c.id")
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +66,7 @@ func (a *CategoryRepository) FindAll(ctx context.Context) ([]model.CategoryModel
 	list := make([]model.CategoryModel, 0)
 	for rows.Next() {
 		var c model.CategoryModel
-		if err := rows.Scan(&c.ID, &c.Name, &c.Description); err != nil {
+		if err := scanCategory(rows, &c); err != nil {
 			return nil, err
 		}
 		list = append(list, c)
@@ -49,15 +81,8 @@ func (a *CategoryRepository) FindOne(
 	ctx context.Context,
 	id int,
 ) (*model.CategoryModel, error) {
-	const query = `
-		SELECT id, name, description
-		FROM categories
-		WHERE id = $1
-	`
 	var c model.CategoryModel
-	err := a.dbPool.
-		QueryRow(ctx, query, id).
-		Scan(&c.ID, &c.Name, &c.Description)
+	err := scanCategory(a.dbPool.QueryRow(ctx, categorySelectQuery+" WHERE c.id = $1 AND c.deleted_at IS NULL", id), &c)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -67,57 +92,135 @@ func (a *CategoryRepository) FindOne(
 	return &c, nil
 }
 
+// Delete soft deletes a category. Its products are handled by strategy: with
+// CategoryDeleteReject the delete fails with a CategoryInUseError while the
+// category has products, CategoryDeleteReassign moves them to reassignTo and
+// CategoryDeleteNullify leaves them without a category. Subcategories are
+// moved up to the parent of the deleted category. A version other than 0
+// must match the current one.
 func (a *CategoryRepository) Delete(
 	ctx context.Context,
 	id int,
+	strategy string,
+	reassignTo *int,
+	version int,
 ) (bool, error) {
-	const query = `
-		DELETE FROM categories
-		WHERE id = $1
-	`
-	cmdTag, err := a.dbPool.Exec(ctx, query, id)
+	tx, err := a.dbPool.Begin(ctx)
 	if err != nil {
 		return false, err
 	}
-	if cmdTag.RowsAffected() == 0 {
-		return false, nil
+	defer tx.Rollback(ctx)
+
+	var parentID *int
+	var current int
+	err = tx.QueryRow(ctx, "SELECT parent_id, version FROM categories WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).
+		Scan(&parentID, &current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	if version != 0 && version != current {
+		return false, ErrVersionMismatch
+	}
+
+	switch strategy {
+	case model.CategoryDeleteReassign:
+		var exists bool
+		err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1 AND deleted_at IS NULL FOR SHARE)", *reassignTo).
+			Scan(&exists)
+		if err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, ErrReassignCategoryNotFound
+		}
+		if _, err = tx.Exec(ctx, "UPDATE products SET category_id = $1 WHERE category_id = $2 AND deleted_at IS NULL", *reassignTo, id); err != nil {
+			return false, err
+		}
+	case model.CategoryDeleteNullify:
+		if _, err = tx.Exec(ctx, "UPDATE products SET category_id = NULL WHERE category_id = $1 AND deleted_at IS NULL", id); err != nil {
+			return false, err
+		}
+	default:
+		var count int
+		if err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM products WHERE category_id = $1 AND deleted_at IS NULL", id).Scan(&count); err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return false, &CategoryInUseError{ProductCount: count}
+		}
+	}
+
+	if _, err = tx.Exec(ctx, "UPDATE categories SET parent_id = $1 WHERE parent_id = $2", parentID, id); err != nil {
+		return false, err
+	}
+	if _, err = tx.Exec(ctx, "UPDATE categories SET deleted_at = NOW(), version = version + 1 WHERE id = $1", id); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
+// Update saves the category when c.Version is 0 or matches the current
+// version, and stores the new version in c.Version.
 func (a *CategoryRepository) Update(
 	ctx context.Context,
 	c *model.CategoryModel,
 ) (bool, error) {
 	const query = `
 		UPDATE categories
-		SET name = $1, description = $2
-		WHERE id = $3
+		SET name = $1, description = $2, version = version + 1
+		WHERE id = $3 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)
+		RETURNING version
 	`
-	cmdTag, err := a.dbPool.Exec(
+	err := a.dbPool.QueryRow(
 		ctx,
 		query,
 		c.Name,
 		c.Description,
 		c.ID,
-	)
+		c.Version,
+	).Scan(&c.Version)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, a.versionMismatch(ctx, a.dbPool, c.ID)
+		}
 		return false, err
 	}
-	if cmdTag.RowsAffected() == 0 {
-		return false, nil
-	}
 	return true, nil
 }
 
+// versionMismatch reports ErrVersionMismatch when the category still exists,
+// and nil when it is gone so the caller answers not found.
+func (a *CategoryRepository) versionMismatch(ctx context.Context, q interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}, id int) error {
+	var exists bool
+	err := q.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1 AND deleted_at IS NULL)", id).
+		Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
 func (a *CategoryRepository) Create(
 	ctx context.Context,
 	c *model.CategoryModel,
 ) (*model.CategoryModel, error) {
 	const query = `
-		INSERT INTO categories (name, description)
-		VALUES ($1, $2)
-		RETURNING id, name, description
+		INSERT INTO categories (name, description, parent_id)
+		SELECT $1, $2, $3
+		WHERE $3::INT IS NULL OR EXISTS (SELECT 1 FROM categories WHERE id = $3 AND deleted_at IS NULL)
+		RETURNING id, name, description, parent_id, version
 	`
 	var out model.CategoryModel
 	err := a.dbPool.QueryRow(
@@ -125,9 +228,114 @@ func (a *CategoryRepository) Create(
 		query,
 		c.Name,
 		c.Description,
-	).Scan(&out.ID, &out.Name, &out.Description)
+		c.ParentID,
+	).Scan(&out.ID, &out.Name, &out.Description, &out.ParentID, &out.Version)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrParentCategoryNotFound
+		}
+		return nil, categoryWriteError(err)
 	}
 	return &out, nil
 }
+
+// Move changes the parent of a category. Moving a category below itself or
+// below one of its own descendants is rejected with ErrCategoryCycle. A
+// version other than 0 must match the current one.
+func (a *CategoryRepository) Move(
+	ctx context.Context,
+	id int,
+	parentID *int,
+	version int,
+) (bool, error) {
+	tx, err := a.dbPool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", categoryMoveLockKey); err != nil {
+		return false, err
+	}
+
+	if parentID != nil {
+		var exists bool
+		err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1 AND deleted_at IS NULL)", *parentID).
+			Scan(&exists)
+		if err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, ErrParentCategoryNotFound
+		}
+
+		cycleQuery := "SELECT $2 IN (" + categorySubtreeQuery("$1") + ")"
+		var cycle bool
+		if err = tx.QueryRow(ctx, cycleQuery, id, *parentID).Scan(&cycle); err != nil {
+			return false, err
+		}
+		if cycle {
+			return false, ErrCategoryCycle
+		}
+	}
+
+	const moveQuery = `
+		UPDATE categories
+		SET parent_id = $1, version = version + 1
+		WHERE id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)
+	`
+	cmdTag, err := tx.Exec(ctx, moveQuery, parentID, id, version)
+	if err != nil {
+		return false, categoryWriteError(err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, a.versionMismatch(ctx, tx, id)
+	}
+	return true, tx.Commit(ctx)
+}
+
+// Restore brings back a deleted category. Subcategories and products moved
+// away when it was deleted stay where they are, and a category whose parent
+// was deleted in the meantime comes back at the top level.
+func (a *CategoryRepository) Restore(
+	ctx context.Context,
+	id int,
+) (bool, error) {
+	const query = `
+		UPDATE categories c
+		SET deleted_at = NULL, version = c.version + 1,
+			parent_id = (SELECT p.id FROM categories p WHERE p.id = c.parent_id AND p.deleted_at IS NULL)
+		WHERE c.id = $1 AND c.deleted_at IS NOT NULL
+	`
+	cmdTag, err := a.dbPool.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// categorySubtreeQuery selects the id of the category bound to param and the
+// ids of all of its descendants.
+func categorySubtreeQuery(param string) string {
+	return `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = ` + param + `
+			UNION ALL
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT id FROM subtree`
+}
+
+func categoryWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.Code == "23503" || pgErr.Code == "23514") {
+		if pgErr.Code == "23514" {
+			return ErrCategoryCycle
+		}
+		return ErrParentCategoryNotFound
+	}
+	return err
+}
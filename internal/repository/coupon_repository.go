@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrCouponCodeTaken    = errors.New("coupon code is already in use")
+	ErrCouponLimitReached = errors.New("coupon usage limit reached")
+)
+
+const couponSelectQuery = `
+	SELECT
+		cp.id, cp.code, cp.promotion_id, p.name, cp.usage_limit, cp.used_count,
+		cp.active, cp.starts_at, cp.ends_at, cp.created_at
+	FROM coupons cp
+	LEFT JOIN promotions p ON p.id = cp.promotion_id
+`
+
+type CouponRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewCouponRepository(dbPool *pgxpool.Pool) *CouponRepository {
+	return &CouponRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanCoupon(row pgx.Row, c *model.CouponModel) error {
+	return row.Scan(
+		&c.ID, &c.Code, &c.PromotionID, &c.PromotionName, &c.UsageLimit, &c.UsedCount,
+		&c.Active, &c.StartsAt, &c.EndsAt, &c.CreatedAt,
+	)
+}
+
+func (r *CouponRepository) FindAll(ctx context.Context) ([]model.CouponModel, error) {
+	rows, err := r.dbPool.Query(ctx, couponSelectQuery+" ORDER BY cp.id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.CouponModel, 0)
+	for rows.Next() {
+		var c model.CouponModel
+		if err := scanCoupon(rows, &c); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *CouponRepository) FindOne(
+	ctx context.Context,
+	id int,
+) (*model.CouponModel, error) {
+	var c model.CouponModel
+	err := scanCoupon(r.dbPool.QueryRow(ctx, couponSelectQuery+" WHERE cp.id = $1", id), &c)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CouponRepository) FindByCode(
+	ctx context.Context,
+	code string,
+) (*model.CouponModel, error) {
+	var c model.CouponModel
+	err := scanCoupon(r.dbPool.QueryRow(ctx, couponSelectQuery+" WHERE UPPER(cp.code) = UPPER($1)", code), &c)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CouponRepository) Delete(
+	ctx context.Context,
+	id int,
+) (bool, error) {
+	const query = `
+		DELETE FROM coupons
+		WHERE id = $1
+	`
+	cmdTag, err := r.dbPool.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *CouponRepository) Update(
+	ctx context.Context,
+	c *model.CouponModel,
+) (bool, error) {
+	const query = `
+		UPDATE coupons
+		SET code = $1, promotion_id = $2, usage_limit = $3, active = $4, starts_at = $5, ends_at = $6
+		WHERE id = $7
+	`
+	cmdTag, err := r.dbPool.Exec(
+		ctx,
+		query,
+		c.Code, c.PromotionID, c.UsageLimit, c.Active, c.StartsAt, c.EndsAt,
+		c.ID,
+	)
+	if err != nil {
+		return false, couponWriteError(err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *CouponRepository) Create(
+	ctx context.Context,
+	c *model.CouponModel,
+) (*model.CouponModel, error) {
+	const query = `
+		INSERT INTO coupons (code, promotion_id, usage_limit, active, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	var id int
+	err := r.dbPool.QueryRow(
+		ctx,
+		query,
+		c.Code, c.PromotionID, c.UsageLimit, c.Active, c.StartsAt, c.EndsAt,
+	).Scan(&id)
+	if err != nil {
+		return nil, couponWriteError(err)
+	}
+	return r.FindOne(ctx, id)
+}
+
+func couponWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return ErrCouponCodeTaken
+		case "23514":
+			return errors.New("usage limit cannot be lower than the times the coupon was used")
+		}
+	}
+	return err
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+var (
+	ErrCustomerNotFound   = errors.New("customer not found")
+	ErrCustomerPhoneTaken = errors.New("phone number is already used by another customer")
+	ErrCustomerEmailTaken = errors.New("email is already used by another customer")
+)
+
+const customerSelectQuery = `
+	SELECT c.id, c.name, c.phone, c.email, c.created_at
+	FROM customers c
+`
+
+type CustomerRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewCustomerRepository(dbPool *pgxpool.Pool) *CustomerRepository {
+	return &CustomerRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanCustomer(row pgx.Row, c *model.CustomerModel) error {
+	return row.Scan(&c.ID, &c.Name, &c.Phone, &c.Email, &c.CreatedAt)
+}
+
+// FindAll lists customers by name. A non-empty text keeps the customers whose
+// name, phone or email contains it; phone matches ignore anything but digits.
+func (r *CustomerRepository) FindAll(
+	ctx context.Context,
+	text string,
+	digits string,
+	limit int,
+) ([]model.CustomerModel, error) {
+	const query = customerSelectQuery + `
+		WHERE $1 = ''
+			OR c.name ILIKE '%' || $1 || '%'
+			OR c.email ILIKE '%' || $1 || '%'
+			OR ($2 <> '' AND c.phone LIKE '%' || $2 || '%')
+		ORDER BY c.name, c.id
+		LIMIT $3
+	`
+	rows, err := r.dbPool.Query(ctx, query, likeEscaper.Replace(text), digits, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.CustomerModel, 0)
+	for rows.Next() {
+		var c model.CustomerModel
+		if err := scanCustomer(rows, &c); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *CustomerRepository) FindOne(
+	ctx context.Context,
+	id int,
+) (*model.CustomerModel, error) {
+	var c model.CustomerModel
+	err := scanCustomer(r.dbPool.QueryRow(ctx, customerSelectQuery+" WHERE c.id = $1", id), &c)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// FindLifetimeValue totals the transactions of a customer per currency.
+func (r *CustomerRepository) FindLifetimeValue(ctx context.Context, id int) ([]model.CustomerValueModel, error) {
+	const query = `
+		SELECT
+			currency,
+			SUM(total_amount),
+			COUNT(*),
+			ROUND(AVG(total_amount))::BIGINT,
+			MIN(created_at),
+			MAX(created_at)
+		FROM transactions
+		WHERE customer_id = $1
+		GROUP BY currency
+		ORDER BY currency
+	`
+	rows, err := r.dbPool.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.CustomerValueModel, 0)
+	for rows.Next() {
+		var v model.CustomerValueModel
+		var total, average int64
+		err := rows.Scan(&v.Currency, &total, &v.TotalTransaction, &average, &v.FirstPurchaseAt, &v.LastPurchaseAt)
+		if err != nil {
+			return nil, err
+		}
+		v.TotalSpent = money.New(total, v.Currency)
+		v.AverageOrder = money.New(average, v.Currency)
+		list = append(list, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *CustomerRepository) Create(
+	ctx context.Context,
+	c *model.CustomerModel,
+) (*model.CustomerModel, error) {
+	const query = `
+		INSERT INTO customers (name, phone, email)
+		VALUES ($1, $2, $3)
+		RETURNING id, name, phone, email, created_at
+	`
+	var out model.CustomerModel
+	if err := scanCustomer(r.dbPool.QueryRow(ctx, query, c.Name, c.Phone, c.Email), &out); err != nil {
+		return nil, customerWriteError(err)
+	}
+	return &out, nil
+}
+
+func (r *CustomerRepository) Update(
+	ctx context.Context,
+	c *model.CustomerModel,
+) (bool, error) {
+	const query = `
+		UPDATE customers
+		SET name = $1, phone = $2, email = $3
+		WHERE id = $4
+	`
+	cmdTag, err := r.dbPool.Exec(ctx, query, c.Name, c.Phone, c.Email, c.ID)
+	if err != nil {
+		return false, customerWriteError(err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// Delete removes a customer. Their transactions are kept without a customer.
+func (r *CustomerRepository) Delete(
+	ctx context.Context,
+	id int,
+) (bool, error) {
+	cmdTag, err := r.dbPool.Exec(ctx, `DELETE FROM customers WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func customerWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		switch pgErr.ConstraintName {
+		case "customers_phone_key":
+			return ErrCustomerPhoneTaken
+		case "customers_email_key":
+			return ErrCustomerEmailTaken
+		}
+	}
+	return err
+}
@@ -0,0 +1,439 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrInsufficientPoints = errors.New("customer does not have enough points")
+
+const loyaltyProgramSelectQuery = `
+	SELECT lp.currency, lp.earn_amount, lp.earn_points, lp.point_value, lp.expiry_days, lp.active, lp.created_at
+	FROM loyalty_programs lp
+`
+
+const loyaltyEntryColumns = `id, customer_id, transaction_id, kind, points, remaining, expires_at, note, created_at`
+
+type LoyaltyRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewLoyaltyRepository(dbPool *pgxpool.Pool) *LoyaltyRepository {
+	return &LoyaltyRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanLoyaltyProgram(row pgx.Row, p *model.LoyaltyProgramModel) error {
+	var earnAmount, pointValue int64
+	err := row.Scan(&p.Currency, &earnAmount, &p.EarnPoints, &pointValue, &p.ExpiryDays, &p.Active, &p.CreatedAt)
+	if err != nil {
+		return err
+	}
+	p.EarnAmount = money.New(earnAmount, p.Currency)
+	p.PointValue = money.New(pointValue, p.Currency)
+	return nil
+}
+
+func scanLoyaltyEntry(row pgx.Row, e *model.LoyaltyEntryModel) error {
+	return row.Scan(&e.ID, &e.CustomerID, &e.TransactionID, &e.Kind, &e.Points, &e.Remaining,
+		&e.ExpiresAt, &e.Note, &e.CreatedAt)
+}
+
+func (r *LoyaltyRepository) FindPrograms(ctx context.Context) ([]model.LoyaltyProgramModel, error) {
+	rows, err := r.dbPool.Query(ctx, loyaltyProgramSelectQuery+" ORDER BY lp.currency")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.LoyaltyProgramModel, 0)
+	for rows.Next() {
+		var p model.LoyaltyProgramModel
+		if err := scanLoyaltyProgram(rows, &p); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *LoyaltyRepository) FindProgram(ctx context.Context, currency string) (*model.LoyaltyProgramModel, error) {
+	var p model.LoyaltyProgramModel
+	err := scanLoyaltyProgram(r.dbPool.QueryRow(ctx, loyaltyProgramSelectQuery+" WHERE lp.currency = $1", currency), &p)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SaveProgram creates the program of a currency or replaces its settings.
+// Points already earned keep the expiry they were given.
+func (r *LoyaltyRepository) SaveProgram(
+	ctx context.Context,
+	p *model.LoyaltyProgramModel,
+) (*model.LoyaltyProgramModel, error) {
+	const query = `
+		INSERT INTO loyalty_programs (currency, earn_amount, earn_points, point_value, expiry_days, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (currency) DO UPDATE
+		SET earn_amount = EXCLUDED.earn_amount,
+			earn_points = EXCLUDED.earn_points,
+			point_value = EXCLUDED.point_value,
+			expiry_days = EXCLUDED.expiry_days,
+			active = EXCLUDED.active
+		RETURNING currency, earn_amount, earn_points, point_value, expiry_days, active, created_at
+	`
+	var out model.LoyaltyProgramModel
+	row := r.dbPool.QueryRow(ctx, query, p.Currency, p.EarnAmount.Amount, p.EarnPoints, p.PointValue.Amount,
+		p.ExpiryDays, p.Active)
+	if err := scanLoyaltyProgram(row, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *LoyaltyRepository) DeleteProgram(ctx context.Context, currency string) (bool, error) {
+	cmdTag, err := r.dbPool.Exec(ctx, `DELETE FROM loyalty_programs WHERE currency = $1`, currency)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+func (r *LoyaltyRepository) FindMultipliers(ctx context.Context) ([]model.LoyaltyMultiplierModel, error) {
+	const query = `
+		SELECT m.category_id, c.name, m.multiplier_bps
+		FROM loyalty_category_multipliers m
+		JOIN categories c ON c.id = m.category_id
+		ORDER BY c.name, m.category_id
+	`
+	rows, err := r.dbPool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.LoyaltyMultiplierModel, 0)
+	for rows.Next() {
+		var m model.LoyaltyMultiplierModel
+		if err := rows.Scan(&m.CategoryID, &m.CategoryName, &m.MultiplierBps); err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FindMultiplierRates returns the multiplier of every given category that
+// has one, keyed by category id.
+func (r *LoyaltyRepository) FindMultiplierRates(ctx context.Context, categoryIDs []int) (map[int]int, error) {
+	rates := make(map[int]int)
+	if len(categoryIDs) == 0 {
+		return rates, nil
+	}
+
+	rows, err := r.dbPool.Query(ctx,
+		`SELECT category_id, multiplier_bps FROM loyalty_category_multipliers WHERE category_id = ANY($1)`,
+		categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var categoryID, bps int
+		if err := rows.Scan(&categoryID, &bps); err != nil {
+			return nil, err
+		}
+		rates[categoryID] = bps
+	}
+	return rates, rows.Err()
+}
+
+func (r *LoyaltyRepository) SetMultiplier(ctx context.Context, categoryID int, multiplierBps int) error {
+	const query = `
+		INSERT INTO loyalty_category_multipliers (category_id, multiplier_bps)
+		VALUES ($1, $2)
+		ON CONFLICT (category_id) DO UPDATE SET multiplier_bps = EXCLUDED.multiplier_bps
+	`
+	if _, err := r.dbPool.Exec(ctx, query, categoryID, multiplierBps); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *LoyaltyRepository) DeleteMultiplier(ctx context.Context, categoryID int) (bool, error) {
+	cmdTag, err := r.dbPool.Exec(ctx, `DELETE FROM loyalty_category_multipliers WHERE category_id = $1`, categoryID)
+	if err != nil {
+		return false, err
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// FindBalance sums the unexpired points of a customer and lists when the
+// next points expire.
+func (r *LoyaltyRepository) FindBalance(ctx context.Context, customerID int) (*model.LoyaltyBalanceModel, error) {
+	balance := &model.LoyaltyBalanceModel{
+		CustomerID: customerID,
+		Expiring:   make([]model.LoyaltyExpiringModel, 0),
+	}
+
+	const balanceQuery = `
+		SELECT COALESCE(SUM(remaining), 0)
+		FROM loyalty_ledger
+		WHERE customer_id = $1 AND remaining > 0 AND (expires_at IS NULL OR expires_at > NOW())
+	`
+	if err := r.dbPool.QueryRow(ctx, balanceQuery, customerID).Scan(&balance.Balance); err != nil {
+		return nil, err
+	}
+
+	const expiringQuery = `
+		SELECT SUM(remaining), expires_at
+		FROM loyalty_ledger
+		WHERE customer_id = $1 AND remaining > 0 AND expires_at > NOW()
+		GROUP BY expires_at
+		ORDER BY expires_at
+		LIMIT 10
+	`
+	rows, err := r.dbPool.Query(ctx, expiringQuery, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e model.LoyaltyExpiringModel
+		if err := rows.Scan(&e.Points, &e.ExpiresAt); err != nil {
+			return nil, err
+		}
+		balance.Expiring = append(balance.Expiring, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+// FindEntries lists the ledger of a customer, newest first.
+func (r *LoyaltyRepository) FindEntries(ctx context.Context, customerID int, limit int) ([]model.LoyaltyEntryModel, error) {
+	query := `SELECT ` + loyaltyEntryColumns + `
+		FROM loyalty_ledger
+		WHERE customer_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := r.dbPool.Query(ctx, query, customerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.LoyaltyEntryModel, 0)
+	for rows.Next() {
+		var e model.LoyaltyEntryModel
+		if err := scanLoyaltyEntry(rows, &e); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Adjust credits points to a customer by hand, or debits them when points is
+// negative.
+func (r *LoyaltyRepository) Adjust(
+	ctx context.Context,
+	customerID int,
+	points int,
+	note string,
+	expiresAt *time.Time,
+) (*model.LoyaltyEntryModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var entry *model.LoyaltyEntryModel
+	if points > 0 {
+		entry, err = creditPoints(ctx, tx, customerID, nil, model.LoyaltyEntryAdjust, points, expiresAt, note)
+	} else {
+		entry, err = debitPoints(ctx, tx, customerID, nil, model.LoyaltyEntryAdjust, -points, note)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entry, tx.Commit(ctx)
+}
+
+// ExpireDue writes off up to limit credits whose expiry has passed, oldest
+// first, and returns how many were handled. Credits locked by a checkout or
+// another worker are left for the next run.
+func (r *LoyaltyRepository) ExpireDue(ctx context.Context, limit int) (int, error) {
+	const query = `
+		WITH due AS (
+			SELECT id, customer_id, remaining
+			FROM loyalty_ledger
+			WHERE remaining > 0 AND expires_at <= NOW()
+			ORDER BY expires_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), cleared AS (
+			UPDATE loyalty_ledger l
+			SET remaining = 0
+			FROM due
+			WHERE l.id = due.id
+		)
+		INSERT INTO loyalty_ledger (customer_id, kind, points, note)
+		SELECT customer_id, 'expire', -remaining, 'expired points of entry ' || id
+		FROM due
+		ORDER BY id
+	`
+	cmdTag, err := r.dbPool.Exec(ctx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+	return int(cmdTag.RowsAffected()), nil
+}
+
+// creditPoints adds points to the ledger of a customer.
+func creditPoints(
+	ctx context.Context,
+	tx pgx.Tx,
+	customerID int,
+	transactionID *int,
+	kind string,
+	points int,
+	expiresAt *time.Time,
+	note string,
+) (*model.LoyaltyEntryModel, error) {
+	query := `
+		INSERT INTO loyalty_ledger (customer_id, transaction_id, kind, points, remaining, expires_at, note)
+		VALUES ($1, $2, $3, $4, $4, $5, $6)
+		RETURNING ` + loyaltyEntryColumns
+	var entry model.LoyaltyEntryModel
+	err := scanLoyaltyEntry(tx.QueryRow(ctx, query, customerID, transactionID, kind, points, expiresAt, note), &entry)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.ConstraintName == "loyalty_ledger_customer_id_fkey" {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// debitPoints takes points from the unexpired credits of a customer, the
+// ones expiring soonest first, and records the debit. The customer row is
+// locked so concurrent debits cannot spend the same points twice.
+func debitPoints(
+	ctx context.Context,
+	tx pgx.Tx,
+	customerID int,
+	transactionID *int,
+	kind string,
+	points int,
+	note string,
+) (*model.LoyaltyEntryModel, error) {
+	var id int
+	err := tx.QueryRow(ctx, `SELECT id FROM customers WHERE id = $1 FOR UPDATE`, customerID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, err
+	}
+
+	const creditQuery = `
+		SELECT id, remaining
+		FROM loyalty_ledger
+		WHERE customer_id = $1 AND remaining > 0 AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY expires_at NULLS LAST, id
+		FOR UPDATE
+	`
+	rows, err := tx.Query(ctx, creditQuery, customerID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0)
+	used := make([]int, 0)
+	left := points
+	for rows.Next() && left > 0 {
+		var creditID, remaining int
+		if err := rows.Scan(&creditID, &remaining); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		take := min(remaining, left)
+		ids = append(ids, creditID)
+		used = append(used, take)
+		left -= take
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if left > 0 {
+		return nil, ErrInsufficientPoints
+	}
+
+	const consumeQuery = `
+		UPDATE loyalty_ledger l
+		SET remaining = l.remaining - u.used
+		FROM unnest($1::int[], $2::int[]) AS u(id, used)
+		WHERE l.id = u.id
+	`
+	if _, err := tx.Exec(ctx, consumeQuery, ids, used); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO loyalty_ledger (customer_id, transaction_id, kind, points, note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + loyaltyEntryColumns
+	var entry model.LoyaltyEntryModel
+	err = scanLoyaltyEntry(tx.QueryRow(ctx, query, customerID, transactionID, kind, -points, note), &entry)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// pointsExpiry returns when points earned now in a currency expire, or nil
+// when they never do.
+func pointsExpiry(ctx context.Context, tx pgx.Tx, currency string) (*time.Time, error) {
+	const query = `
+		SELECT NOW() + make_interval(days => expiry_days)
+		FROM loyalty_programs
+		WHERE currency = $1
+	`
+	var expiresAt *time.Time
+	if err := tx.QueryRow(ctx, query, currency).Scan(&expiresAt); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return expiresAt, nil
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrModifierNotFound = errors.New("modifier not found in this group")
+
+const modifierGroupSelectQuery = `
+	SELECT g.id, g.product_id, g.name, g.min_select, g.max_select, g.created_at
+	FROM modifier_groups g
+`
+
+type ModifierRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewModifierRepository(dbPool *pgxpool.Pool) *ModifierRepository {
+	return &ModifierRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanModifierGroup(row pgx.Row, g *model.ModifierGroupModel) error {
+	return row.Scan(&g.ID, &g.ProductID, &g.Name, &g.MinSelect, &g.MaxSelect, &g.CreatedAt)
+}
+
+func (r *ModifierRepository) FindGroupsByProduct(ctx context.Context, productID int) ([]model.ModifierGroupModel, error) {
+	rows, err := r.dbPool.Query(ctx, modifierGroupSelectQuery+" WHERE g.product_id = $1 ORDER BY g.id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.ModifierGroupModel, 0)
+	for rows.Next() {
+		g := model.ModifierGroupModel{Modifiers: []model.ModifierModel{}}
+		if err := scanModifierGroup(rows, &g); err != nil {
+			return nil, err
+		}
+		list = append(list, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachModifiers(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *ModifierRepository) attachModifiers(ctx context.Context, groups []model.ModifierGroupModel) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	groupIDs := make([]int, len(groups))
+	for i := range groups {
+		groupIDs[i] = groups[i].ID
+	}
+
+	const query = `
+		SELECT m.id, m.group_id, m.name, m.price_delta, p.currency, m.stock
+		FROM modifiers m
+		JOIN modifier_groups g ON g.id = m.group_id
+		JOIN products p ON p.id = g.product_id
+		WHERE m.group_id = ANY($1)
+		ORDER BY m.id
+	`
+	rows, err := r.dbPool.Query(ctx, query, groupIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m model.ModifierModel
+		if err := rows.Scan(&m.ID, &m.GroupID, &m.Name, &m.PriceDelta.Amount, &m.PriceDelta.Currency, &m.Stock); err != nil {
+			return err
+		}
+		for i := range groups {
+			if groups[i].ID == m.GroupID {
+				groups[i].Modifiers = append(groups[i].Modifiers, m)
+				break
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (r *ModifierRepository) CreateGroup(
+	ctx context.Context,
+	g *model.ModifierGroupModel,
+) (*model.ModifierGroupModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const query = `
+		INSERT INTO modifier_groups (product_id, name, min_select, max_select)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	out := *g
+	err = tx.QueryRow(ctx, query, g.ProductID, g.Name, g.MinSelect, g.MaxSelect).Scan(&out.ID, &out.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	out.Modifiers = make([]model.ModifierModel, len(g.Modifiers))
+	for i, m := range g.Modifiers {
+		if err := insertModifier(ctx, tx, out.ID, &m); err != nil {
+			return nil, err
+		}
+		out.Modifiers[i] = m
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateGroup saves the group and syncs its modifiers: modifiers with an id
+// are updated, new ones are inserted and the ones left out are deleted.
+func (r *ModifierRepository) UpdateGroup(
+	ctx context.Context,
+	g *model.ModifierGroupModel,
+) (bool, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	const query = `
+		UPDATE modifier_groups
+		SET name = $1, min_select = $2, max_select = $3
+		WHERE product_id = $4 AND id = $5
+	`
+	cmdTag, err := tx.Exec(ctx, query, g.Name, g.MinSelect, g.MaxSelect, g.ProductID, g.ID)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	keep := make([]int, 0, len(g.Modifiers))
+	for _, m := range g.Modifiers {
+		if m.ID != 0 {
+			keep = append(keep, m.ID)
+		}
+	}
+	if _, err = tx.Exec(ctx, "DELETE FROM modifiers WHERE group_id = $1 AND NOT (id = ANY($2))", g.ID, keep); err != nil {
+		return false, err
+	}
+
+	const updateModifierQuery = `
+		UPDATE modifiers
+		SET name = $1, price_delta = $2, stock = $3
+		WHERE group_id = $4 AND id = $5
+	`
+	for i := range g.Modifiers {
+		m := &g.Modifiers[i]
+		if m.ID == 0 {
+			if err := insertModifier(ctx, tx, g.ID, m); err != nil {
+				return false, err
+			}
+			continue
+		}
+		cmdTag, err := tx.Exec(ctx, updateModifierQuery, m.Name, m.PriceDelta.Amount, m.Stock, g.ID, m.ID)
+		if err != nil {
+			return false, err
+		}
+		if cmdTag.RowsAffected() == 0 {
+			return false, ErrModifierNotFound
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func insertModifier(ctx context.Context, tx pgx.Tx, groupID int, m *model.ModifierModel) error {
+	const query = `
+		INSERT INTO modifiers (group_id, name, price_delta, stock)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	m.GroupID = groupID
+	return tx.QueryRow(ctx, query, groupID, m.Name, m.PriceDelta.Amount, m.Stock).Scan(&m.ID)
+}
+
+func (r *ModifierRepository) DeleteGroup(
+	ctx context.Context,
+	productID int,
+	id int,
+) (bool, error) {
+	const query = `
+		DELETE FROM modifier_groups
+		WHERE product_id = $1 AND id = $2
+	`
+	cmdTag, err := r.dbPool.Exec(ctx, query, productID, id)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,546 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrOrderNotFound            = errors.New("order not found")
+	ErrOrderChanged             = errors.New("order changed since it was loaded, reload it and try again")
+	ErrOrderItemNotFound        = errors.New("order item not found")
+	ErrReserveInsufficientStock = errors.New("not enough stock available to reserve")
+	ErrOrderReservationLost     = errors.New("stock reserved for the order is no longer on hand")
+)
+
+// OrderStatusError is returned when an order is not in a status that allows
+// the requested change.
+type OrderStatusError struct {
+	Status string
+}
+
+func (e *OrderStatusError) Error() string {
+	return fmt.Sprintf("order is %s", e.Status)
+}
+
+const orderSelectQuery = `
+	SELECT
+		o.id, o.store_id, s.name, o.customer_id, o.label, o.note, o.status, o.reserve_stock, o.version,
+		t.id, o.created_at, o.updated_at, o.settled_at, o.cancelled_at
+	FROM orders o
+	JOIN stores s ON s.id = o.store_id
+	LEFT JOIN transactions t ON t.order_id = o.id
+`
+
+type OrderRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewOrderRepository(dbPool *pgxpool.Pool) *OrderRepository {
+	return &OrderRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanOrder(row pgx.Row, o *model.OrderModel) error {
+	return row.Scan(
+		&o.ID, &o.StoreID, &o.StoreName, &o.CustomerID, &o.Label, &o.Note, &o.Status, &o.ReserveStock,
+		&o.Version, &o.TransactionID, &o.CreatedAt, &o.UpdatedAt, &o.SettledAt, &o.CancelledAt,
+	)
+}
+
+// FindAll lists the orders of a store, or of every store when storeID is
+// nil, optionally limited to one status.
+func (r *OrderRepository) FindAll(
+	ctx context.Context,
+	storeID *int,
+	status string,
+) ([]model.OrderModel, error) {
+	const query = orderSelectQuery + `
+		WHERE ($1::int IS NULL OR o.store_id = $1) AND ($2 = '' OR o.status = $2)
+		ORDER BY o.created_at DESC, o.id DESC
+	`
+	rows, err := r.dbPool.Query(ctx, query, storeID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.OrderModel, 0)
+	for rows.Next() {
+		var o model.OrderModel
+		if err := scanOrder(rows, &o); err != nil {
+			return nil, err
+		}
+		list = append(list, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachItems(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *OrderRepository) FindOne(
+	ctx context.Context,
+	id int,
+) (*model.OrderModel, error) {
+	var o model.OrderModel
+	if err := scanOrder(r.dbPool.QueryRow(ctx, orderSelectQuery+" WHERE o.id = $1", id), &o); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	list := []model.OrderModel{o}
+	if err := r.attachItems(ctx, list); err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+func (r *OrderRepository) attachItems(ctx context.Context, orders []model.OrderModel) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(orders))
+	byID := make(map[int]*model.OrderModel, len(orders))
+	for i := range orders {
+		orders[i].Items = []model.OrderItemModel{}
+		ids[i] = orders[i].ID
+		byID[orders[i].ID] = &orders[i]
+	}
+
+	const query = `
+		SELECT i.id, i.order_id, i.product_id, p.name, i.variant_id, v.name, i.modifier_ids, i.quantity,
+			i.created_at
+		FROM order_items i
+		JOIN products p ON p.id = i.product_id
+		LEFT JOIN product_variants v ON v.id = i.variant_id
+		WHERE i.order_id = ANY($1)
+		ORDER BY i.id
+	`
+	rows, err := r.dbPool.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item model.OrderItemModel
+		err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.ProductName, &item.VariantID,
+			&item.VariantName, &item.ModifierIDs, &item.Quantity, &item.CreatedAt)
+		if err != nil {
+			return err
+		}
+		order := byID[item.OrderID]
+		order.Items = append(order.Items, item)
+	}
+	return rows.Err()
+}
+
+// Create opens an order with its first items, reserving their stock when
+// the order asks for it.
+func (r *OrderRepository) Create(
+	ctx context.Context,
+	o *model.OrderModel,
+) (*model.OrderModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const query = `
+		INSERT INTO orders (store_id, customer_id, label, note, reserve_stock)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	var id int
+	err = tx.QueryRow(ctx, query, o.StoreID, o.CustomerID, o.Label, o.Note, o.ReserveStock).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			switch pgErr.ConstraintName {
+			case "orders_store_id_fkey":
+				return nil, ErrStoreNotFound
+			case "orders_customer_id_fkey":
+				return nil, ErrCustomerNotFound
+			}
+		}
+		return nil, err
+	}
+
+	if err = insertOrderItems(ctx, tx, id, o.Items); err != nil {
+		return nil, err
+	}
+	if err = syncReservations(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, id)
+}
+
+// Update edits the details of an open or held order. Switching stock
+// reservation on reserves the items already on the order.
+func (r *OrderRepository) Update(ctx context.Context, o *model.OrderModel) (*model.OrderModel, error) {
+	return r.edit(ctx, o.ID, []string{model.OrderStatusOpen, model.OrderStatusHeld}, func(tx pgx.Tx) error {
+		const query = `
+			UPDATE orders
+			SET customer_id = $1, label = $2, note = $3, reserve_stock = $4
+			WHERE id = $5
+		`
+		_, err := tx.Exec(ctx, query, o.CustomerID, o.Label, o.Note, o.ReserveStock, o.ID)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.ConstraintName == "orders_customer_id_fkey" {
+			return ErrCustomerNotFound
+		}
+		return err
+	})
+}
+
+// AddItems adds items to an open order.
+func (r *OrderRepository) AddItems(ctx context.Context, id int, items []model.OrderItemModel) (*model.OrderModel, error) {
+	return r.edit(ctx, id, []string{model.OrderStatusOpen}, func(tx pgx.Tx) error {
+		return insertOrderItems(ctx, tx, id, items)
+	})
+}
+
+// SetItemQuantity changes the quantity of a line of an open order.
+func (r *OrderRepository) SetItemQuantity(ctx context.Context, id, itemID, quantity int) (*model.OrderModel, error) {
+	return r.edit(ctx, id, []string{model.OrderStatusOpen}, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx, `UPDATE order_items SET quantity = $1 WHERE id = $2 AND order_id = $3`,
+			quantity, itemID, id)
+		if err != nil {
+			return err
+		}
+		if cmdTag.RowsAffected() == 0 {
+			return ErrOrderItemNotFound
+		}
+		return nil
+	})
+}
+
+// RemoveItem takes a line off an open order.
+func (r *OrderRepository) RemoveItem(ctx context.Context, id, itemID int) (*model.OrderModel, error) {
+	return r.edit(ctx, id, []string{model.OrderStatusOpen}, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx, `DELETE FROM order_items WHERE id = $1 AND order_id = $2`, itemID, id)
+		if err != nil {
+			return err
+		}
+		if cmdTag.RowsAffected() == 0 {
+			return ErrOrderItemNotFound
+		}
+		return nil
+	})
+}
+
+// Hold parks an open order. Its items stay reserved.
+func (r *OrderRepository) Hold(ctx context.Context, id int) (*model.OrderModel, error) {
+	return r.transition(ctx, id, []string{model.OrderStatusOpen}, model.OrderStatusHeld, "")
+}
+
+// Resume reopens a held order for changes.
+func (r *OrderRepository) Resume(ctx context.Context, id int) (*model.OrderModel, error) {
+	return r.transition(ctx, id, []string{model.OrderStatusHeld}, model.OrderStatusOpen, "")
+}
+
+// Cancel drops an open or held order and releases its reserved stock.
+func (r *OrderRepository) Cancel(ctx context.Context, id int) (*model.OrderModel, error) {
+	return r.transition(ctx, id, []string{model.OrderStatusOpen, model.OrderStatusHeld},
+		model.OrderStatusCancelled, "cancelled_at")
+}
+
+// edit runs a change to an order in the given statuses, moves the order to
+// a new version and brings its reservations in line with its items. It
+// returns nil when the order does not exist.
+func (r *OrderRepository) edit(
+	ctx context.Context,
+	id int,
+	statuses []string,
+	change func(tx pgx.Tx) error,
+) (*model.OrderModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockOrder(ctx, tx, id, statuses); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err = change(tx); err != nil {
+		return nil, err
+	}
+	if err = touchOrder(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if err = syncReservations(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, id)
+}
+
+// transition moves an order to another status, stamping timestampColumn
+// when it is set. Reservations follow: an order that is no longer open or
+// held holds no stock.
+func (r *OrderRepository) transition(
+	ctx context.Context,
+	id int,
+	from []string,
+	to string,
+	timestampColumn string,
+) (*model.OrderModel, error) {
+	return r.edit(ctx, id, from, func(tx pgx.Tx) error {
+		query := `UPDATE orders SET status = $1 WHERE id = $2`
+		if timestampColumn != "" {
+			query = fmt.Sprintf(`UPDATE orders SET status = $1, %s = NOW() WHERE id = $2`, timestampColumn)
+		}
+		_, err := tx.Exec(ctx, query, to, id)
+		return err
+	})
+}
+
+// lockOrder locks an order, returning pgx.ErrNoRows when it does not exist
+// and an OrderStatusError when it is not in one of the given statuses.
+func lockOrder(ctx context.Context, tx pgx.Tx, id int, statuses []string) error {
+	var status string
+	if err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+		return err
+	}
+	if !slices.Contains(statuses, status) {
+		return &OrderStatusError{Status: status}
+	}
+	return nil
+}
+
+func touchOrder(ctx context.Context, tx pgx.Tx, id int) error {
+	_, err := tx.Exec(ctx, `UPDATE orders SET version = version + 1, updated_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// insertOrderItems adds items to an order; an item matching an existing line
+// adds to its quantity. Modifier ids must be sorted.
+func insertOrderItems(ctx context.Context, tx pgx.Tx, orderID int, items []model.OrderItemModel) error {
+	const query = `
+		INSERT INTO order_items (order_id, product_id, variant_id, modifier_ids, quantity)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (order_id, product_id, COALESCE(variant_id, 0), modifier_ids)
+		DO UPDATE SET quantity = order_items.quantity + EXCLUDED.quantity
+	`
+	for _, item := range items {
+		modifierIDs := item.ModifierIDs
+		if modifierIDs == nil {
+			modifierIDs = []int{}
+		}
+		_, err := tx.Exec(ctx, query, orderID, item.ProductID, item.VariantID, modifierIDs, item.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type reservationKey struct {
+	productID int
+	variantID int
+}
+
+// reservationOwner is the order or the cart stock reservations belong to.
+type reservationOwner struct {
+	column string
+	id     int
+}
+
+func orderOwner(id int) reservationOwner {
+	return reservationOwner{column: "order_id", id: id}
+}
+
+func cartOwner(id int) reservationOwner {
+	return reservationOwner{column: "cart_id", id: id}
+}
+
+// syncReservations makes the stock reserved for an order match its items:
+// everything for an open or held order that reserves stock, nothing
+// otherwise.
+func syncReservations(ctx context.Context, tx pgx.Tx, orderID int) error {
+	var storeID int
+	var status string
+	var reserve bool
+	err := tx.QueryRow(ctx, `SELECT store_id, status, reserve_stock FROM orders WHERE id = $1`, orderID).
+		Scan(&storeID, &status, &reserve)
+	if err != nil {
+		return err
+	}
+
+	desired := make(map[reservationKey]int)
+	if reserve && (status == model.OrderStatusOpen || status == model.OrderStatusHeld) {
+		const itemQuery = `
+			SELECT product_id, COALESCE(variant_id, 0), SUM(quantity)
+			FROM order_items
+			WHERE order_id = $1
+			GROUP BY product_id, COALESCE(variant_id, 0)
+		`
+		rows, err := tx.Query(ctx, itemQuery, orderID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var key reservationKey
+			var quantity int
+			if err := rows.Scan(&key.productID, &key.variantID, &quantity); err != nil {
+				rows.Close()
+				return err
+			}
+			desired[key] = quantity
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+	return replaceReservations(ctx, tx, orderOwner(orderID), storeID, desired)
+}
+
+// replaceReservations makes the stock an owner reserves in a store match
+// desired. Only the quantities added on top of what the owner already held
+// need to be available.
+func replaceReservations(
+	ctx context.Context,
+	tx pgx.Tx,
+	owner reservationOwner,
+	storeID int,
+	desired map[reservationKey]int,
+) error {
+	current := make(map[reservationKey]int)
+	rows, err := tx.Query(ctx,
+		`SELECT product_id, COALESCE(variant_id, 0), quantity FROM stock_reservations WHERE `+owner.column+` = $1`,
+		owner.id)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var key reservationKey
+		var quantity int
+		if err := rows.Scan(&key.productID, &key.variantID, &quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		current[key] = quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Check the extra quantities in a stable order so concurrent owners lock
+	// stock rows the same way
+	keys := make([]reservationKey, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b reservationKey) int {
+		if a.productID != b.productID {
+			return a.productID - b.productID
+		}
+		return a.variantID - b.variantID
+	})
+
+	const availableQuery = `
+		SELECT stock - reserved
+		FROM store_stocks
+		WHERE store_id = $1 AND product_id = $2 AND COALESCE(variant_id, 0) = $3
+		FOR UPDATE
+	`
+	for _, key := range keys {
+		extra := desired[key] - current[key]
+		if extra <= 0 {
+			continue
+		}
+		var available int
+		err := tx.QueryRow(ctx, availableQuery, storeID, key.productID, key.variantID).Scan(&available)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if available < extra {
+			return fmt.Errorf("%w (product %d, available: %d, requested: %d)",
+				ErrReserveInsufficientStock, key.productID, max(available, 0), extra)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM stock_reservations WHERE `+owner.column+` = $1`, owner.id); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	productIDs := make([]int, len(keys))
+	variantIDs := make([]*int, len(keys))
+	quantities := make([]int, len(keys))
+	for i, key := range keys {
+		productIDs[i] = key.productID
+		if key.variantID != 0 {
+			variantIDs[i] = &key.variantID
+		}
+		quantities[i] = desired[key]
+	}
+	insertQuery := `
+		INSERT INTO stock_reservations (` + owner.column + `, store_id, product_id, variant_id, quantity)
+		SELECT $1, $2, i.product_id, i.variant_id, i.quantity
+		FROM unnest($3::int[], $4::int[], $5::int[]) AS i(product_id, variant_id, quantity)
+	`
+	_, err = tx.Exec(ctx, insertQuery, owner.id, storeID, productIDs, variantIDs, quantities)
+	if isStockReservedViolation(err) {
+		return ErrReserveInsufficientStock
+	}
+	return err
+}
+
+// settleOrder closes an order that is being checked out at the version the
+// cashier saw, releasing its reserved stock so the sale can deduct it.
+func settleOrder(ctx context.Context, tx pgx.Tx, id int, version int) error {
+	err := lockOrder(ctx, tx, id, []string{model.OrderStatusOpen, model.OrderStatusHeld})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrOrderNotFound
+		}
+		return err
+	}
+
+	const query = `
+		UPDATE orders
+		SET status = 'settled', settled_at = NOW(), updated_at = NOW(), version = version + 1
+		WHERE id = $1 AND version = $2
+	`
+	cmdTag, err := tx.Exec(ctx, query, id, version)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrOrderChanged
+	}
+	return syncReservations(ctx, tx, id)
+}
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const scheduledPriceChangeSelectQuery = `
+	SELECT s.id, s.product_id, s.variant_id, s.price, s.currency, s.effective_at, s.status, s.note,
+		s.failure_reason, s.created_at, s.applied_at
+	FROM scheduled_price_changes s
+`
+
+type PriceHistoryRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewPriceHistoryRepository(dbPool *pgxpool.Pool) *PriceHistoryRepository {
+	return &PriceHistoryRepository{
+		dbPool: dbPool,
+	}
+}
+
+// setPriceChangeSource tags the price changes of the transaction in the price
+// history, see migrations/014_price_history.sql.
+func setPriceChangeSource(ctx context.Context, tx pgx.Tx, source string) error {
+	_, err := tx.Exec(ctx, "SELECT set_config('app.price_change_source', $1, true)", source)
+	return err
+}
+
+func scanScheduledPriceChange(row pgx.Row, s *model.ScheduledPriceChangeModel) error {
+	return row.Scan(&s.ID, &s.ProductID, &s.VariantID, &s.Price.Amount, &s.Price.Currency, &s.EffectiveAt, &s.Status, &s.Note,
+		&s.FailureReason, &s.CreatedAt, &s.AppliedAt)
+}
+
+// FindHistory lists the price changes of a product and its variants, oldest
+// first.
+func (r *PriceHistoryRepository) FindHistory(ctx context.Context, productID int) ([]model.PriceHistoryModel, error) {
+	const query = `
+		SELECT id, product_id, variant_id, old_price, old_currency, new_price, new_currency, source, changed_at
+		FROM price_history
+		WHERE product_id = $1
+		ORDER BY changed_at, id
+	`
+	rows, err := r.dbPool.Query(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.PriceHistoryModel, 0)
+	for rows.Next() {
+		var h model.PriceHistoryModel
+		var oldAmount *int64
+		var oldCurrency *string
+		err := rows.Scan(&h.ID, &h.ProductID, &h.VariantID, &oldAmount, &oldCurrency, &h.NewPrice.Amount, &h.NewPrice.Currency,
+			&h.Source, &h.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		if oldAmount != nil && oldCurrency != nil {
+			oldPrice := money.New(*oldAmount, *oldCurrency)
+			h.OldPrice = &oldPrice
+		}
+		list = append(list, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FindScheduled lists the scheduled changes of a product in the order they
+// take effect, only the ones in status when it is not empty.
+func (r *PriceHistoryRepository) FindScheduled(
+	ctx context.Context,
+	productID int,
+	status string,
+) ([]model.ScheduledPriceChangeModel, error) {
+	const where = " WHERE s.product_id = $1 AND ($2 = '' OR s.status = $2) ORDER BY s.effective_at, s.id"
+	rows, err := r.dbPool.Query(ctx, scheduledPriceChangeSelectQuery+where, productID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.ScheduledPriceChangeModel, 0)
+	for rows.Next() {
+		var s model.ScheduledPriceChangeModel
+		if err := scanScheduledPriceChange(rows, &s); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Schedule stores a pending price change. It returns nil when the product, or
+// the variant the change is for, does not exist.
+func (r *PriceHistoryRepository) Schedule(
+	ctx context.Context,
+	s *model.ScheduledPriceChangeModel,
+) (*model.ScheduledPriceChangeModel, error) {
+	const query = `
+		INSERT INTO scheduled_price_changes (product_id, variant_id, price, currency, effective_at, note)
+		SELECT p.id, $2, $3, $4, $5, $6
+		FROM products p
+		WHERE p.id = $1 AND p.deleted_at IS NULL
+			AND ($2::int IS NULL OR EXISTS (
+				SELECT 1 FROM product_variants v WHERE v.id = $2 AND v.product_id = p.id
+			))
+		RETURNING id
+	`
+	var id int
+	err := r.dbPool.QueryRow(ctx, query, s.ProductID, s.VariantID, s.Price.Amount, s.Price.Currency,
+		s.EffectiveAt, s.Note).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var out model.ScheduledPriceChangeModel
+	if err := scanScheduledPriceChange(r.dbPool.QueryRow(ctx, scheduledPriceChangeSelectQuery+" WHERE s.id = $1", id), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Cancel cancels a change that is still pending.
+func (r *PriceHistoryRepository) Cancel(
+	ctx context.Context,
+	productID int,
+	id int,
+) (bool, error) {
+	const query = `
+		UPDATE scheduled_price_changes
+		SET status = 'cancelled'
+		WHERE product_id = $1 AND id = $2 AND status = 'pending'
+	`
+	cmdTag, err := r.dbPool.Exec(ctx, query, productID, id)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// ApplyDue applies up to limit pending changes whose time has come, oldest
+// first, and returns how many were handled and the products whose price
+// changed. Rows locked by another worker
+// are skipped, so several instances can run side by side. A change for a
+// product that was deleted in the meantime, or whose currency is no longer
+// the currency of the change, is marked failed with the reason.
+func (r *PriceHistoryRepository) ApplyDue(ctx context.Context, limit int) (int, []int, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setPriceChangeSource(ctx, tx, model.PriceSourceScheduled); err != nil {
+		return 0, nil, err
+	}
+
+	const dueQuery = `
+		SELECT id, product_id, variant_id, price, currency
+		FROM scheduled_price_changes
+		WHERE status = 'pending' AND effective_at <= NOW()
+		ORDER BY effective_at, id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, dueQuery, limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	type dueChange struct {
+		id        int
+		productID int
+		variantID *int
+		price     int64
+		currency  string
+	}
+	due, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (dueChange, error) {
+		var d dueChange
+		err := row.Scan(&d.id, &d.productID, &d.variantID, &d.price, &d.currency)
+		return d, err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	changed := make([]int, 0, len(due))
+	for _, d := range due {
+		failure, err := applyScheduledPrice(ctx, tx, d.productID, d.variantID, d.price, d.currency)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if failure != "" {
+			_, err = tx.Exec(ctx,
+				"UPDATE scheduled_price_changes SET status = 'failed', failure_reason = $2 WHERE id = $1",
+				d.id, failure)
+		} else {
+			_, err = tx.Exec(ctx,
+				"UPDATE scheduled_price_changes SET status = 'applied', applied_at = NOW() WHERE id = $1",
+				d.id)
+			changed = append(changed, d.productID)
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, err
+	}
+	return len(due), changed, nil
+}
+
+// applyScheduledPrice sets the price of a product, or of its variant when
+// variantID is set. It returns why the change cannot be applied any more
+// instead when that is the case.
+func applyScheduledPrice(
+	ctx context.Context,
+	tx pgx.Tx,
+	productID int,
+	variantID *int,
+	price int64,
+	currency string,
+) (string, error) {
+	var productCurrency string
+	err := tx.QueryRow(ctx,
+		"SELECT currency FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE",
+		productID).Scan(&productCurrency)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "product was deleted", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if productCurrency != currency {
+		return fmt.Sprintf("product currency changed from %s to %s", currency, productCurrency), nil
+	}
+
+	if variantID != nil {
+		cmdTag, err := tx.Exec(ctx,
+			"UPDATE product_variants SET price = $1 WHERE id = $2 AND product_id = $3",
+			price, *variantID, productID)
+		if err != nil {
+			return "", err
+		}
+		if cmdTag.RowsAffected() == 0 {
+			return "variant was deleted", nil
+		}
+		return "", nil
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE products SET price = $1, version = version + 1 WHERE id = $2", price, productID)
+	return "", err
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrBatchSkipped marks the operations after the first failure of a batch;
+// whatever they did was rolled back with the rest of the batch.
+var ErrBatchSkipped = errors.New("rolled back, an earlier operation of the batch failed")
+
+// productBatchColumns are the columns an update operation may set.
+var productBatchColumns = []string{"name", "sku", "description", "price", "currency", "stock", "category_id"}
+
+// ProductBatchOperation is one operation of Batch. Product is read by create,
+// ID, Version and Set by update, ID and Version by delete, and CategoryID,
+// IncludeDescendants and PercentBps by reprice_category.
+type ProductBatchOperation struct {
+	Op      string
+	ID      int
+	Version int
+	Product model.ProductModel
+	// Set maps the columns to update to their new value.
+	Set map[string]any
+
+	CategoryID         int
+	IncludeDescendants bool
+	// PercentBps is the price change in basis points, e.g. 1050 for +10.5%.
+	PercentBps int
+}
+
+type ProductBatchOutcome struct {
+	ProductID int
+	Version   int
+	Affected  int
+	// RepricedIDs are the products a reprice_category operation repriced.
+	RepricedIDs []int
+	Err         error
+}
+
+// Batch sends every operation in one pgx batch inside one transaction. The
+// transaction is only committed when every operation succeeded. After the
+// first failure the rest of the batch is not looked at and is reported with
+// ErrBatchSkipped.
+func (a *ProductRepository) Batch(
+	ctx context.Context,
+	ops []ProductBatchOperation,
+) (outcomes []ProductBatchOutcome, committed bool, err error) {
+	tx, err := a.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+	if err := setPriceChangeSource(ctx, tx, model.PriceSourceBatch); err != nil {
+		return nil, false, err
+	}
+
+	batch := &pgx.Batch{}
+	for i := range ops {
+		query, args, err := productBatchQuery(&ops[i])
+		if err != nil {
+			return nil, false, err
+		}
+		batch.Queue(query, args...)
+	}
+
+	outcomes = make([]ProductBatchOutcome, len(ops))
+	failed := false
+	results := tx.SendBatch(ctx, batch)
+	for i := range ops {
+		outcome := &outcomes[i]
+		if failed {
+			outcome.Err = ErrBatchSkipped
+			continue
+		}
+
+		switch ops[i].Op {
+		case model.BatchOpCreate, model.BatchOpUpdate:
+			err = results.QueryRow().Scan(&outcome.ProductID, &outcome.Version)
+			if errors.Is(err, pgx.ErrNoRows) {
+				outcome.ProductID = ops[i].ID
+				outcome.Err = ErrProductNotFound
+				err = nil
+			}
+		case model.BatchOpDelete:
+			var cmdTag pgconn.CommandTag
+			cmdTag, err = results.Exec()
+			if err == nil && cmdTag.RowsAffected() == 0 {
+				outcome.Err = ErrProductNotFound
+			}
+			outcome.ProductID = ops[i].ID
+		case model.BatchOpRepriceCategory:
+			err = results.QueryRow().Scan(&outcome.Affected, &outcome.RepricedIDs)
+		}
+		if err != nil {
+			outcome.Err = productWriteError(err)
+		}
+		if outcome.Err != nil {
+			failed = true
+		}
+	}
+	if err := results.Close(); err != nil && !failed {
+		return nil, false, err
+	}
+	if failed {
+		a.explainBatchMisses(ctx, ops, outcomes)
+		return outcomes, false, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+	return outcomes, true, nil
+}
+
+// explainBatchMisses turns the not found of an update or delete into
+// ErrVersionMismatch when the product exists but its version moved on.
+func (a *ProductRepository) explainBatchMisses(ctx context.Context, ops []ProductBatchOperation, outcomes []ProductBatchOutcome) {
+	for i := range outcomes {
+		if errors.Is(outcomes[i].Err, ErrProductNotFound) {
+			if err := a.versionMismatch(ctx, ops[i].ID); err != nil {
+				outcomes[i].Err = err
+			}
+		}
+	}
+}
+
+func productBatchQuery(op *ProductBatchOperation) (string, []any, error) {
+	switch op.Op {
+	case model.BatchOpCreate:
+		p := &op.Product
+		const query = `
+			INSERT INTO products (name, description, price, currency, stock, category_id, sku)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, version
+		`
+		return query, []any{p.Name, p.Description, p.Price.Amount, p.Price.Currency, p.Stock, p.CategoryID, p.SKU}, nil
+
+	case model.BatchOpUpdate:
+		sets := []string{"version = version + 1"}
+		args := []any{op.ID, op.Version}
+		for _, column := range productBatchColumns {
+			value, ok := op.Set[column]
+			if !ok {
+				continue
+			}
+			args = append(args, value)
+			sets = append(sets, column+" = $"+strconv.Itoa(len(args)))
+		}
+		for column := range op.Set {
+			if !slices.Contains(productBatchColumns, column) {
+				return "", nil, errors.New("column " + column + " cannot be updated in a batch")
+			}
+		}
+		query := `
+			UPDATE products
+			SET ` + strings.Join(sets, ", ") + `
+			WHERE id = $1 AND deleted_at IS NULL AND version = $2
+			RETURNING id, version
+		`
+		return query, args, nil
+
+	case model.BatchOpDelete:
+		const query = `
+			UPDATE products
+			SET deleted_at = NOW(), version = version + 1
+			WHERE id = $1 AND deleted_at IS NULL AND version = $2
+		`
+		return query, []any{op.ID, op.Version}, nil
+
+	case model.BatchOpRepriceCategory:
+		condition := "p.category_id = $1"
+		if op.IncludeDescendants {
+			condition = "p.category_id IN (" + categorySubtreeQuery("$1") + ")"
+		}
+		// Variants carry their own price, so they are repriced along with
+		// their product. The price history triggers record both under the
+		// batch source.
+		query := `
+			WITH repriced AS (
+				UPDATE products p
+				SET price = ROUND(p.price * (10000 + $2) / 10000.0)::BIGINT, version = p.version + 1
+				WHERE p.deleted_at IS NULL AND ` + condition + `
+				RETURNING p.id
+			), repriced_variants AS (
+				UPDATE product_variants v
+				SET price = ROUND(v.price * (10000 + $2) / 10000.0)::BIGINT
+				WHERE v.product_id IN (SELECT id FROM repriced)
+			)
+			SELECT COUNT(*), COALESCE(array_agg(id ORDER BY id), '{}') FROM repriced
+		`
+		return query, []any{op.CategoryID, op.PercentBps}, nil
+	}
+	return "", nil, errors.New("unknown batch operation " + op.Op)
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const productImageColumns = `
+	id, product_id, storage_key, thumbnail_key, url, thumbnail_url,
+	content_type, size_bytes, width, height, position, created_at
+`
+
+type ProductImageRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewProductImageRepository(dbPool *pgxpool.Pool) *ProductImageRepository {
+	return &ProductImageRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanProductImage(row pgx.Row, i *model.ProductImageModel) error {
+	return row.Scan(&i.ID, &i.ProductID, &i.StorageKey, &i.ThumbnailKey, &i.URL, &i.ThumbnailURL,
+		&i.ContentType, &i.SizeBytes, &i.Width, &i.Height, &i.Position, &i.CreatedAt)
+}
+
+func (r *ProductImageRepository) FindByProduct(ctx context.Context, productID int) ([]model.ProductImageModel, error) {
+	query := `SELECT ` + productImageColumns + ` FROM product_images WHERE product_id = $1 ORDER BY position, id`
+	rows, err := r.dbPool.Query(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.ProductImageModel, 0)
+	for rows.Next() {
+		var i model.ProductImageModel
+		if err := scanProductImage(rows, &i); err != nil {
+			return nil, err
+		}
+		list = append(list, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Create appends the image after the existing images of the product.
+func (r *ProductImageRepository) Create(
+	ctx context.Context,
+	i *model.ProductImageModel,
+) (*model.ProductImageModel, error) {
+	const query = `
+		INSERT INTO product_images
+			(product_id, storage_key, thumbnail_key, url, thumbnail_url, content_type, size_bytes, width, height, position)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9,
+			COALESCE((SELECT MAX(position) + 1 FROM product_images WHERE product_id = $1), 0)
+		RETURNING ` + productImageColumns
+	var out model.ProductImageModel
+	err := scanProductImage(r.dbPool.QueryRow(ctx, query, i.ProductID, i.StorageKey, i.ThumbnailKey, i.URL,
+		i.ThumbnailURL, i.ContentType, i.SizeBytes, i.Width, i.Height), &out)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Delete removes the image row and returns it so its files can be removed.
+func (r *ProductImageRepository) Delete(
+	ctx context.Context,
+	productID int,
+	id int,
+) (*model.ProductImageModel, error) {
+	query := `DELETE FROM product_images WHERE product_id = $1 AND id = $2 RETURNING ` + productImageColumns
+	var out model.ProductImageModel
+	err := scanProductImage(r.dbPool.QueryRow(ctx, query, productID, id), &out)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &out, nil
+}
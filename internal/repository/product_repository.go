@@ -3,12 +3,98 @@ package repository
 import (
 	"context"
 	"errors"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/illusi03/golearn/internal/model"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	ErrProductNotFound  = errors.New("product not found")
+	ErrProductSKUTaken  = errors.New("product sku is already taken")
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrVersionMismatch is returned when a row changed since the client read
+	// it.
+	ErrVersionMismatch = errors.New("the record was modified by someone else, reload it and try again")
+)
+
+const productColumns = `
+	p.id, p.name, p.sku, p.description, p.price, p.currency, p.stock, rs.reserved, p.category_id,
+	c.name as category_name, img.url, img.thumbnail_url, p.deleted_at, p.version
+`
+
+// productFromClause joins the category, the primary image and the stock
+// reserved of a product.
+const productFromClause = `
+	FROM products p
+	LEFT JOIN categories c ON p.category_id = c.id
+	LEFT JOIN LATERAL (
+		SELECT pi.url, pi.thumbnail_url
+		FROM product_images pi
+		WHERE pi.product_id = p.id
+		ORDER BY pi.position, pi.id
+		LIMIT 1
+	) img ON TRUE
+	LEFT JOIN LATERAL (
+		SELECT COALESCE(SUM(ss.reserved), 0)::int AS reserved
+		FROM store_stocks ss
+		WHERE ss.product_id = p.id AND ss.variant_id IS NULL
+	) rs ON TRUE
+`
+
+const productSelectQuery = `SELECT ` + productColumns + productFromClause
+
+const highlightOptions = "StartSel=<mark>, StopSel=</mark>"
+
+// escapeHTML wraps a SQL text expression so the HTML special characters in
+// it are escaped. Highlights are built from the escaped text, so the <mark>
+// tags ts_headline adds are the only markup they contain.
+func escapeHTML(expr string) string {
+	return `replace(replace(replace(replace(replace(` + expr +
+		`, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+}
+
+// ProductFilter narrows product listings. A zero value matches every product
+// that is not deleted.
+type ProductFilter struct {
+	CategoryID *int
+	// IncludeDescendants also matches products of every category below
+	// CategoryID.
+	IncludeDescendants bool
+	IncludeDeleted     bool
+	// IDs limits the listing to these products when it is not nil.
+	IDs []int
+}
+
+// conditions returns the filter as SQL conditions joined with AND and their
+// arguments. Placeholders are numbered after the first argCount arguments of
+// the query.
+func (f ProductFilter) conditions(argCount int) (string, []any) {
+	var where []string
+	var args []any
+	if !f.IncludeDeleted {
+		where = append(where, "p.deleted_at IS NULL")
+	}
+	if f.CategoryID != nil {
+		args = append(args, *f.CategoryID)
+		param := "$" + strconv.Itoa(argCount+len(args))
+		if f.IncludeDescendants {
+			where = append(where, "p.category_id IN ("+categorySubtreeQuery(param)+")")
+		} else {
+			where = append(where, "p.category_id = "+param)
+		}
+	}
+	if f.IDs != nil {
+		args = append(args, f.IDs)
+		where = append(where, "p.id = ANY($"+strconv.Itoa(argCount+len(args))+")")
+	}
+	return strings.Join(where, " AND "), args
+}
+
 type ProductRepository struct {
 	dbPool *pgxpool.Pool
 }
@@ -19,22 +105,20 @@ func NewProductRepository(dbPool *pgxpool.Pool) *ProductRepository {
 	}
 }
 
-func (a *ProductRepository) FindAll(ctx context.Context, name string) ([]model.ProductModel, error) {
-	query := `
-		SELECT p.id, p.name, p.description, p.price, p.stock, p.category_id, c.name as category_name
-		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.id
-	`
-	args := []any{}
+func scanProduct(row pgx.Row, c *model.ProductModel) error {
+	err := row.Scan(&c.ID, &c.Name, &c.SKU, &c.Description, &c.Price.Amount, &c.Price.Currency, &c.Stock,
+		&c.Reserved, &c.CategoryID, &c.CategoryName, &c.ImageURL, &c.ThumbnailURL, &c.DeletedAt, &c.Version)
+	c.Available = c.Stock - c.Reserved
+	return err
+}
 
-	if name != "" {
-		query += " WHERE p.name ILIKE $1"
-		args = append(args, "%"+name+"%")
+func (a *ProductRepository) FindAll(ctx context.Context, filter ProductFilter) ([]model.ProductModel, error) {
+	query := productSelectQuery
+	where, args := filter.conditions(0)
+	if where != "" {
+		query += " WHERE " + where
 	}
-
-	query += " ORDER BY p.id"
-
-	rows, err := a.dbPool.Query(ctx, query, args...)
+	rows, err := a.dbPool.Query(ctx, query+" ORDER BY p.id", args...)
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +127,7 @@ func (a *ProductRepository) FindAll(ctx context.Context, name string) ([]model.P
 	list := make([]model.ProductModel, 0)
 	for rows.Next() {
 		var c model.ProductModel
-		if err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.Price, &c.Stock, &c.CategoryID, &c.CategoryName); err != nil {
+		if err := scanProduct(rows, &c); err != nil {
 			return nil, err
 		}
 		list = append(list, c)
@@ -58,16 +142,169 @@ func (a *ProductRepository) FindOne(
 	ctx context.Context,
 	id int,
 ) (*model.ProductModel, error) {
-	const query = `
-		SELECT p.id, p.name, p.description, p.price, p.stock, p.category_id, c.name as category_name
+	var c model.ProductModel
+	err := scanProduct(a.dbPool.QueryRow(ctx, productSelectQuery+" WHERE p.id = $1 AND p.deleted_at IS NULL", id), &c)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Search ranks products by full-text match on name, SKU, category and
+// description. When nothing matches, it falls back to trigram similarity on
+// the name so typos still find the product.
+func (a *ProductRepository) Search(
+	ctx context.Context,
+	text string,
+	limit int,
+	filter ProductFilter,
+) ([]model.ProductSearchModel, error) {
+	tsQuery := buildTSQuery(text)
+	if tsQuery == "" {
+		return []model.ProductSearchModel{}, nil
+	}
+
+	where, filterArgs := filter.conditions(2)
+	if where != "" {
+		where = " AND " + where
+	}
+
+	fullTextQuery := `SELECT ` + productColumns + `,
+			ts_rank_cd(p.search_vector, q.tsq, 32) AS rank,
+			ts_headline('simple', ` + escapeHTML("p.name") + `, q.tsq, '` + highlightOptions + `, HighlightAll=true'),
+			ts_headline('simple', ` + escapeHTML("COALESCE(p.description, '')") + `, q.tsq,
+				'` + highlightOptions + `, MaxFragments=2, MaxWords=20, MinWords=5')
+		` + productFromClause + `
+		CROSS JOIN to_tsquery('simple', $1) AS q(tsq)
+		WHERE p.search_vector @@ q.tsq` + where + `
+		ORDER BY rank DESC, p.id
+		LIMIT $2
+	`
+	list, err := a.findSearchResults(ctx, model.SearchMatchFullText, fullTextQuery, append([]any{tsQuery, limit}, filterArgs...)...)
+	if err != nil || len(list) > 0 {
+		return list, err
+	}
+
+	fuzzyQuery := `SELECT ` + productColumns + `,
+			GREATEST(similarity(p.name, $1), word_similarity($1, p.name)) AS rank,
+			` + escapeHTML("p.name") + `,
+			''
+		` + productFromClause + `
+		WHERE (p.name % $1 OR $1 <% p.name)` + where + `
+		ORDER BY rank DESC, p.id
+		LIMIT $2
+	`
+	return a.findSearchResults(ctx, model.SearchMatchFuzzy, fuzzyQuery, append([]any{strings.TrimSpace(text), limit}, filterArgs...)...)
+}
+
+func (a *ProductRepository) findSearchResults(
+	ctx context.Context,
+	match string,
+	query string,
+	args ...any,
+) ([]model.ProductSearchModel, error) {
+	rows, err := a.dbPool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.ProductSearchModel, 0)
+	for rows.Next() {
+		r := model.ProductSearchModel{Match: match}
+		p := &r.ProductModel
+		err := rows.Scan(&p.ID, &p.Name, &p.SKU, &p.Description, &p.Price.Amount, &p.Price.Currency, &p.Stock,
+			&p.Reserved, &p.CategoryID, &p.CategoryName, &p.ImageURL, &p.ThumbnailURL, &p.DeletedAt, &p.Version, &r.Rank, &r.Highlight.Name, &r.Highlight.Description)
+		if err != nil {
+			return nil, err
+		}
+		p.Available = p.Stock - p.Reserved
+		list = append(list, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Autocomplete suggests products while the cashier is typing. Names that
+// start with the text come first, then in-stock products and shorter names,
+// which is usually what the till is looking for.
+func (a *ProductRepository) Autocomplete(
+	ctx context.Context,
+	text string,
+	limit int,
+) ([]model.ProductSuggestionModel, error) {
+	tsQuery := buildTSQuery(text)
+	if tsQuery == "" {
+		return []model.ProductSuggestionModel{}, nil
+	}
+
+	query := `
+		SELECT p.id, p.name, p.sku, p.price, p.currency, p.stock,
+			(
+				SELECT pi.thumbnail_url FROM product_images pi
+				WHERE pi.product_id = p.id
+				ORDER BY pi.position, pi.id
+				LIMIT 1
+			),
+			ts_headline('simple', ` + escapeHTML("p.name") + `, q.tsq, '` + highlightOptions + `, HighlightAll=true')
 		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.id = $1
+		CROSS JOIN to_tsquery('simple', $1) AS q(tsq)
+		WHERE (p.search_vector @@ q.tsq OR p.name ILIKE $2) AND p.deleted_at IS NULL
+		ORDER BY p.name ILIKE $2 DESC, p.stock > 0 DESC, ts_rank_cd(p.search_vector, q.tsq, 32) DESC, LENGTH(p.name), p.id
+		LIMIT $3
 	`
+	rows, err := a.dbPool.Query(ctx, query, tsQuery, escapeLike(strings.TrimSpace(text))+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.ProductSuggestionModel, 0)
+	for rows.Next() {
+		var s model.ProductSuggestionModel
+		err := rows.Scan(&s.ID, &s.Name, &s.SKU, &s.Price.Amount, &s.Price.Currency, &s.Stock, &s.ThumbnailURL, &s.Highlight)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// buildTSQuery turns free text into a tsquery that requires every word, with
+// the last word matched as a prefix since it is usually still being typed.
+// Anything but letters and digits is dropped, so the result is always a valid
+// query.
+func buildTSQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] += ":*"
+	return strings.Join(words, " & ")
+}
+
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
+
+// FindBySKU matches the SKU case-insensitively.
+func (a *ProductRepository) FindBySKU(
+	ctx context.Context,
+	sku string,
+) (*model.ProductModel, error) {
 	var c model.ProductModel
-	err := a.dbPool.
-		QueryRow(ctx, query, id).
-		Scan(&c.ID, &c.Name, &c.Description, &c.Price, &c.Stock, &c.CategoryID, &c.CategoryName)
+	err := scanProduct(a.dbPool.QueryRow(ctx, productSelectQuery+" WHERE UPPER(p.sku) = UPPER($1) AND p.deleted_at IS NULL", sku), &c)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -77,48 +314,97 @@ func (a *ProductRepository) FindOne(
 	return &c, nil
 }
 
+// Delete soft deletes a product; past transactions keep pointing at it. A
+// version other than 0 must match the current one.
 func (a *ProductRepository) Delete(
 	ctx context.Context,
 	id int,
+	version int,
 ) (bool, error) {
 	const query = `
-		DELETE FROM products
-		WHERE id = $1
+		UPDATE products
+		SET deleted_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
 	`
-	cmdTag, err := a.dbPool.Exec(ctx, query, id)
+	cmdTag, err := a.dbPool.Exec(ctx, query, id, version)
 	if err != nil {
 		return false, err
 	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, a.versionMismatch(ctx, id)
+	}
+	return true, nil
+}
+
+// versionMismatch tells apart a write that matched no row because the
+// product is gone from one that lost a race: the first is reported as not
+// found, the second as ErrVersionMismatch.
+func (a *ProductRepository) versionMismatch(ctx context.Context, id int) error {
+	var exists bool
+	err := a.dbPool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)", id).
+		Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// Restore brings back a deleted product. A product whose category was
+// deleted in the meantime comes back without a category.
+func (a *ProductRepository) Restore(
+	ctx context.Context,
+	id int,
+) (bool, error) {
+	const query = `
+		UPDATE products p
+		SET deleted_at = NULL, version = p.version + 1,
+			category_id = (SELECT c.id FROM categories c WHERE c.id = p.category_id AND c.deleted_at IS NULL)
+		WHERE p.id = $1 AND p.deleted_at IS NOT NULL
+	`
+	cmdTag, err := a.dbPool.Exec(ctx, query, id)
+	if err != nil {
+		return false, productWriteError(err)
+	}
 	if cmdTag.RowsAffected() == 0 {
 		return false, nil
 	}
 	return true, nil
 }
 
+// Update saves the product when c.Version is 0 or matches the current
+// version, and stores the new version in c.Version.
 func (a *ProductRepository) Update(
 	ctx context.Context,
 	c *model.ProductModel,
 ) (bool, error) {
 	const query = `
 		UPDATE products
-		SET name = $1, description = $2, price = $3, stock = $4, category_id = $5
-		WHERE id = $6
+		SET name = $1, description = $2, price = $3, currency = $4, stock = $5, category_id = $6, sku = $7,
+			version = version + 1
+		WHERE id = $8 AND deleted_at IS NULL AND ($9 = 0 OR version = $9)
+		RETURNING version
 	`
-	cmdTag, err := a.dbPool.Exec(
+	err := a.dbPool.QueryRow(
 		ctx,
 		query,
 		c.Name,
 		c.Description,
-		c.Price,
+		c.Price.Amount,
+		c.Price.Currency,
 		c.Stock,
 		c.CategoryID,
+		c.SKU,
 		c.ID,
-	)
+		c.Version,
+	).Scan(&c.Version)
 	if err != nil {
-		return false, err
-	}
-	if cmdTag.RowsAffected() == 0 {
-		return false, nil
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, a.versionMismatch(ctx, c.ID)
+		}
+		return false, productWriteError(err)
 	}
 	return true, nil
 }
@@ -128,9 +414,9 @@ func (a *ProductRepository) Create(
 	c *model.ProductModel,
 ) (*model.ProductModel, error) {
 	const query = `
-		INSERT INTO products (name, description, price, stock, category_id)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, name, description, price, stock, category_id
+		INSERT INTO products (name, description, price, currency, stock, category_id, sku)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, name, sku, description, price, currency, stock, category_id, version
 	`
 	var out model.ProductModel
 	err := a.dbPool.QueryRow(
@@ -138,12 +424,171 @@ func (a *ProductRepository) Create(
 		query,
 		c.Name,
 		c.Description,
-		c.Price,
+		c.Price.Amount,
+		c.Price.Currency,
 		c.Stock,
 		c.CategoryID,
-	).Scan(&out.ID, &out.Name, &out.Description, &out.Price, &out.Stock, &out.CategoryID)
+		c.SKU,
+	).Scan(&out.ID, &out.Name, &out.SKU, &out.Description, &out.Price.Amount, &out.Price.Currency, &out.Stock, &out.CategoryID, &out.Version)
 	if err != nil {
-		return nil, err
+		return nil, productWriteError(err)
 	}
+	out.Available = out.Stock
 	return &out, nil
 }
+
+func productWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return ErrProductSKUTaken
+		case "23503":
+			return ErrCategoryNotFound
+		case "23514":
+			switch pgErr.ConstraintName {
+			case "store_stocks_stock_check":
+				return ErrStockHeldByStores
+			case "store_stocks_reserved_stock_check":
+				return ErrStockReserved
+			}
+		}
+	}
+	return err
+}
+
+// ProductImportItem is one product of an import, matched on its SKU. The
+// category is given by name and created when it does not exist yet.
+type ProductImportItem struct {
+	Product      model.ProductModel
+	CategoryName string
+}
+
+// ProductImportColumns tells which optional columns the import file has.
+// Columns that are missing keep their current value on existing products.
+type ProductImportColumns struct {
+	Description bool
+	Stock       bool
+	Category    bool
+}
+
+type ProductImportOutcome struct {
+	ProductID int
+	Created   bool
+	Err       error
+}
+
+// Import upserts the products by SKU in a single transaction. Every item is
+// attempted inside its own savepoint so one failing row does not hide the
+// errors of the rows after it. The transaction is only committed when every
+// item succeeded and rollback is false, which is how dry runs are done.
+func (a *ProductRepository) Import(
+	ctx context.Context,
+	items []ProductImportItem,
+	columns ProductImportColumns,
+	rollback bool,
+) (outcomes []ProductImportOutcome, categoriesCreated []string, committed bool, err error) {
+	tx, err := a.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer tx.Rollback(ctx)
+	if err := setPriceChangeSource(ctx, tx, model.PriceSourceImport); err != nil {
+		return nil, nil, false, err
+	}
+
+	updates := []string{
+		"name = EXCLUDED.name", "price = EXCLUDED.price", "currency = EXCLUDED.currency",
+		"version = products.version + 1",
+	}
+	if columns.Description {
+		updates = append(updates, "description = EXCLUDED.description")
+	}
+	if columns.Stock {
+		updates = append(updates, "stock = EXCLUDED.stock")
+	}
+	if columns.Category {
+		updates = append(updates, "category_id = EXCLUDED.category_id")
+	}
+	upsertQuery := `
+		INSERT INTO products (name, description, price, currency, stock, category_id, sku)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT ((UPPER(sku))) WHERE sku IS NOT NULL AND deleted_at IS NULL
+		DO UPDATE SET ` + strings.Join(updates, ", ") + `
+		RETURNING id, (xmax = 0)
+	`
+
+	categoryIDs := make(map[string]int)
+	categoriesCreated = make([]string, 0)
+	outcomes = make([]ProductImportOutcome, len(items))
+	failed := false
+
+	for i := range items {
+		item := &items[i]
+		p := &item.Product
+
+		if columns.Category && item.CategoryName != "" {
+			id, created, err := findOrCreateCategory(ctx, tx, categoryIDs, item.CategoryName)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			if created {
+				categoriesCreated = append(categoriesCreated, item.CategoryName)
+			}
+			p.CategoryID = &id
+		}
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		err = savepoint.QueryRow(ctx, upsertQuery, p.Name, p.Description, p.Price.Amount, p.Price.Currency,
+			p.Stock, p.CategoryID, p.SKU).Scan(&outcomes[i].ProductID, &outcomes[i].Created)
+		if err != nil {
+			if rollbackErr := savepoint.Rollback(ctx); rollbackErr != nil {
+				return nil, nil, false, rollbackErr
+			}
+			outcomes[i].Err = productWriteError(err)
+			failed = true
+			continue
+		}
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	if rollback || failed {
+		return outcomes, categoriesCreated, false, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, false, err
+	}
+	return outcomes, categoriesCreated, true, nil
+}
+
+// findOrCreateCategory matches categories by name case-insensitively, caching
+// the ids for the rest of the import.
+func findOrCreateCategory(
+	ctx context.Context,
+	tx pgx.Tx,
+	cache map[string]int,
+	name string,
+) (int, bool, error) {
+	key := strings.ToLower(name)
+	if id, ok := cache[key]; ok {
+		return id, false, nil
+	}
+
+	var id int
+	created := false
+	err := tx.QueryRow(ctx, "SELECT id FROM categories WHERE LOWER(name) = $1 AND deleted_at IS NULL ORDER BY id LIMIT 1", key).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = tx.QueryRow(ctx, "INSERT INTO categories (name, description) VALUES ($1, '') RETURNING id", name).Scan(&id)
+		created = true
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	cache[key] = id
+	return id, created, nil
+}
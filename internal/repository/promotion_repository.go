@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const promotionSelectQuery = `
+	SELECT
+		id, name, description, type, scope, value, amount, currency, buy_quantity, get_quantity,
+		product_id, category_id, min_subtotal, requires_coupon, starts_at, ends_at,
+		to_char(daily_start_time, 'HH24:MI'), to_char(daily_end_time, 'HH24:MI'),
+		active, created_at
+	FROM promotions
+`
+
+type PromotionRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewPromotionRepository(dbPool *pgxpool.Pool) *PromotionRepository {
+	return &PromotionRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanPromotion(row pgx.Row, p *model.PromotionModel) error {
+	var amount, minSubtotal int64
+	var currency string
+	err := row.Scan(
+		&p.ID, &p.Name, &p.Description, &p.Type, &p.Scope, &p.Value, &amount, &currency, &p.BuyQuantity, &p.GetQuantity,
+		&p.ProductID, &p.CategoryID, &minSubtotal, &p.RequiresCoupon, &p.StartsAt, &p.EndsAt,
+		&p.DailyStartTime, &p.DailyEndTime,
+		&p.Active, &p.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	p.Amount = money.New(amount, currency)
+	p.MinSubtotal = money.New(minSubtotal, currency)
+	return nil
+}
+
+func (r *PromotionRepository) findMany(ctx context.Context, query string, args ...any) ([]model.PromotionModel, error) {
+	rows, err := r.dbPool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.PromotionModel, 0)
+	for rows.Next() {
+		var p model.PromotionModel
+		if err := scanPromotion(rows, &p); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *PromotionRepository) FindAll(ctx context.Context) ([]model.PromotionModel, error) {
+	return r.findMany(ctx, promotionSelectQuery+" ORDER BY id")
+}
+
+// FindRunning returns active promotions that do not need a coupon and whose
+// date range covers now. Daily time windows are checked by the caller.
+func (r *PromotionRepository) FindRunning(ctx context.Context, now time.Time) ([]model.PromotionModel, error) {
+	const where = `
+		WHERE active
+			AND NOT requires_coupon
+			AND (starts_at IS NULL OR starts_at <= $1)
+			AND (ends_at IS NULL OR ends_at > $1)
+		ORDER BY id
+	`
+	return r.findMany(ctx, promotionSelectQuery+where, now)
+}
+
+func (r *PromotionRepository) FindOne(
+	ctx context.Context,
+	id int,
+) (*model.PromotionModel, error) {
+	var p model.PromotionModel
+	err := scanPromotion(r.dbPool.QueryRow(ctx, promotionSelectQuery+" WHERE id = $1", id), &p)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PromotionRepository) Delete(
+	ctx context.Context,
+	id int,
+) (bool, error) {
+	const query = `
+		DELETE FROM promotions
+		WHERE id = $1
+	`
+	cmdTag, err := r.dbPool.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *PromotionRepository) Update(
+	ctx context.Context,
+	p *model.PromotionModel,
+) (bool, error) {
+	const query = `
+		UPDATE promotions
+		SET name = $1, description = $2, type = $3, scope = $4, value = $5, amount = $6, currency = $7,
+			buy_quantity = $8, get_quantity = $9, product_id = $10, category_id = $11,
+			min_subtotal = $12, requires_coupon = $13, starts_at = $14, ends_at = $15,
+			daily_start_time = $16::time, daily_end_time = $17::time, active = $18
+		WHERE id = $19
+	`
+	cmdTag, err := r.dbPool.Exec(
+		ctx,
+		query,
+		p.Name, p.Description, p.Type, p.Scope, p.Value, p.Amount.Amount, p.Amount.Currency,
+		p.BuyQuantity, p.GetQuantity, p.ProductID, p.CategoryID,
+		p.MinSubtotal.Amount, p.RequiresCoupon, p.StartsAt, p.EndsAt,
+		p.DailyStartTime, p.DailyEndTime, p.Active,
+		p.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *PromotionRepository) Create(
+	ctx context.Context,
+	p *model.PromotionModel,
+) (*model.PromotionModel, error) {
+	const query = `
+		INSERT INTO promotions (
+			name, description, type, scope, value, amount, currency, buy_quantity, get_quantity,
+			product_id, category_id, min_subtotal, requires_coupon, starts_at, ends_at,
+			daily_start_time, daily_end_time, active
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16::time, $17::time, $18)
+		RETURNING id
+	`
+	var id int
+	err := r.dbPool.QueryRow(
+		ctx,
+		query,
+		p.Name, p.Description, p.Type, p.Scope, p.Value, p.Amount.Amount, p.Amount.Currency, p.BuyQuantity, p.GetQuantity,
+		p.ProductID, p.CategoryID, p.MinSubtotal.Amount, p.RequiresCoupon, p.StartsAt, p.EndsAt,
+		p.DailyStartTime, p.DailyEndTime, p.Active,
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, id)
+}
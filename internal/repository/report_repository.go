@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,23 +17,189 @@ func NewReportRepository(dbPool *pgxpool.Pool) *ReportRepository {
 	return &ReportRepository{dbPool: dbPool}
 }
 
-func (r *ReportRepository) GetReport(ctx context.Context, startDate, endDate time.Time) (*model.ReportModel, error) {
-	report := &model.ReportModel{}
+// GetReport summarises the transactions of one currency; amounts in
+// different currencies are never added together. A nil storeID reports on
+// all stores together.
+func (r *ReportRepository) GetReport(
+	ctx context.Context,
+	startDate, endDate time.Time,
+	currency string,
+	storeID *int,
+) (*model.ReportModel, error) {
+	report := &model.ReportModel{
+		Currency: currency,
+		StoreID:  storeID,
+		TotalTax: money.Zero(currency),
+	}
 
 	const summaryQuery = `
 		SELECT 
 			COALESCE(SUM(total_amount), 0) as total_revenue,
 			COUNT(*) as total_transaction
 		FROM transactions
-		WHERE created_at >= $1 AND created_at < $2
+		WHERE created_at >= $1 AND created_at < $2 AND currency = $3
+			AND ($4::int IS NULL OR store_id = $4)
 	`
-	err := r.dbPool.QueryRow(ctx, summaryQuery, startDate, endDate).Scan(
-		&report.TotalRevenue,
+	var totalRevenue int64
+	err := r.dbPool.QueryRow(ctx, summaryQuery, startDate, endDate, currency, storeID).Scan(
+		&totalRevenue,
 		&report.TotalTransaction,
 	)
 	if err != nil {
 		return nil, err
 	}
+	report.TotalRevenue = money.New(totalRevenue, currency)
+
+	const methodQuery = `
+		SELECT
+			py.method,
+			COALESCE(SUM(py.amount), 0) as total,
+			COUNT(DISTINCT py.transaction_id) as total_transaction
+		FROM payments py
+		JOIN transactions t ON t.id = py.transaction_id
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND t.currency = $3
+			AND ($4::int IS NULL OR t.store_id = $4)
+		GROUP BY py.method
+		ORDER BY total DESC
+	`
+	rows, err := r.dbPool.Query(ctx, methodQuery, startDate, endDate, currency, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report.RevenueByMethod = make([]model.PaymentMethodRevenueModel, 0)
+	for rows.Next() {
+		var m model.PaymentMethodRevenueModel
+		var total int64
+		if err := rows.Scan(&m.Method, &total, &m.TotalTransaction); err != nil {
+			return nil, err
+		}
+		m.Total = money.New(total, currency)
+		report.RevenueByMethod = append(report.RevenueByMethod, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	const taxQuery = `
+		SELECT
+			dt.tax_rate_id,
+			dt.name,
+			dt.rate_bps,
+			dt.inclusive,
+			COALESCE(SUM(dt.taxable_amount), 0) as taxable_amount,
+			COALESCE(SUM(dt.tax_amount), 0) as tax_amount
+		FROM transaction_detail_taxes dt
+		JOIN transaction_details td ON td.id = dt.transaction_detail_id
+		JOIN transactions t ON t.id = td.transaction_id
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND t.currency = $3
+			AND ($4::int IS NULL OR t.store_id = $4)
+		GROUP BY dt.tax_rate_id, dt.name, dt.rate_bps, dt.inclusive
+		ORDER BY dt.name, dt.rate_bps
+	`
+	taxRows, err := r.dbPool.Query(ctx, taxQuery, startDate, endDate, currency, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer taxRows.Close()
+
+	report.TaxBreakdown = make([]model.TaxSummaryModel, 0)
+	for taxRows.Next() {
+		var t model.TaxSummaryModel
+		var taxable, taxAmount int64
+		if err := taxRows.Scan(&t.TaxRateID, &t.Name, &t.RateBps, &t.Inclusive, &taxable, &taxAmount); err != nil {
+			return nil, err
+		}
+		t.TaxableAmount = money.New(taxable, currency)
+		t.TaxAmount = money.New(taxAmount, currency)
+		if report.TotalTax, err = report.TotalTax.Add(t.TaxAmount); err != nil {
+			return nil, err
+		}
+		report.TaxBreakdown = append(report.TaxBreakdown, t)
+	}
+	if err := taxRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Every category is paired with itself and each of its descendants, so
+	// lines count towards their own category and all of its ancestors.
+	const categoryQuery = `
+		WITH RECURSIVE closure AS (
+			SELECT id AS ancestor_id, id AS category_id FROM categories
+			UNION ALL
+			SELECT cl.ancestor_id, c.id
+			FROM closure cl
+			JOIN categories c ON c.parent_id = cl.category_id
+		)
+		SELECT
+			a.id,
+			a.parent_id,
+			a.name,
+			COALESCE(SUM(td.quantity), 0) as qty_sold,
+			COALESCE(SUM(td.subtotal - td.discount_amount), 0) as total
+		FROM closure cl
+		JOIN categories a ON a.id = cl.ancestor_id
+		JOIN products p ON p.category_id = cl.category_id
+		JOIN transaction_details td ON td.product_id = p.id
+		JOIN transactions t ON t.id = td.transaction_id
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND t.currency = $3
+			AND ($4::int IS NULL OR t.store_id = $4)
+		GROUP BY a.id, a.parent_id, a.name
+		ORDER BY total DESC, a.id
+	`
+	categoryRows, err := r.dbPool.Query(ctx, categoryQuery, startDate, endDate, currency, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer categoryRows.Close()
+
+	report.SalesByCategory = make([]model.CategorySalesModel, 0)
+	for categoryRows.Next() {
+		var c model.CategorySalesModel
+		var total int64
+		if err := categoryRows.Scan(&c.CategoryID, &c.ParentID, &c.Name, &c.QtySold, &total); err != nil {
+			return nil, err
+		}
+		c.Total = money.New(total, currency)
+		report.SalesByCategory = append(report.SalesByCategory, c)
+	}
+	if err := categoryRows.Err(); err != nil {
+		return nil, err
+	}
+
+	const storeQuery = `
+		SELECT
+			s.id,
+			s.name,
+			COALESCE(SUM(t.total_amount), 0) as total_revenue,
+			COUNT(*) as total_transaction
+		FROM transactions t
+		JOIN stores s ON s.id = t.store_id
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND t.currency = $3
+			AND ($4::int IS NULL OR t.store_id = $4)
+		GROUP BY s.id, s.name
+		ORDER BY total_revenue DESC, s.id
+	`
+	storeRows, err := r.dbPool.Query(ctx, storeQuery, startDate, endDate, currency, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer storeRows.Close()
+
+	report.SalesByStore = make([]model.StoreSalesModel, 0)
+	for storeRows.Next() {
+		var s model.StoreSalesModel
+		var total int64
+		if err := storeRows.Scan(&s.StoreID, &s.Name, &total, &s.TotalTransaction); err != nil {
+			return nil, err
+		}
+		s.TotalRevenue = money.New(total, currency)
+		report.SalesByStore = append(report.SalesByStore, s)
+	}
+	if err := storeRows.Err(); err != nil {
+		return nil, err
+	}
 
 	const bestSellerQuery = `
 		SELECT 
@@ -41,13 +208,14 @@ func (r *ReportRepository) GetReport(ctx context.Context, startDate, endDate tim
 		FROM transaction_details td
 		JOIN transactions t ON t.id = td.transaction_id
 		JOIN products p ON p.id = td.product_id
-		WHERE t.created_at >= $1 AND t.created_at < $2
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND t.currency = $3
+			AND ($4::int IS NULL OR t.store_id = $4)
 		GROUP BY p.id, p.name
 		ORDER BY qty_sold DESC
 		LIMIT 1
 	`
 	var bestSeller model.BestSellerModel
-	err = r.dbPool.QueryRow(ctx, bestSellerQuery, startDate, endDate).Scan(
+	err = r.dbPool.QueryRow(ctx, bestSellerQuery, startDate, endDate, currency, storeID).Scan(
 		&bestSeller.Name,
 		&bestSeller.QtySold,
 	)
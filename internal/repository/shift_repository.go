@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNoOpenShift      = errors.New("no open shift, open a shift before checkout")
+	ErrShiftAlreadyOpen = errors.New("another shift is still open in this store, close it first")
+	ErrShiftClosed      = errors.New("shift is already closed")
+)
+
+// shiftSelectQuery computes sales live from the linked transactions, so open
+// shifts always report an up to date expected cash amount. Only cash payments
+// in the currency of the shift end up in the drawer.
+const shiftSelectQuery = `
+	SELECT
+		s.id, s.store_id, s.status, s.currency, s.opening_float,
+		COALESCE(t.total_sales, 0), COALESCE(p.cash_sales, 0), COALESCE(t.total_transaction, 0),
+		COALESCE(s.expected_cash, s.opening_float + COALESCE(p.cash_sales, 0)),
+		s.counted_cash, s.variance, s.opening_note, s.closing_note,
+		s.opened_at, s.closed_at
+	FROM shifts s
+	LEFT JOIN LATERAL (
+		SELECT
+			SUM(total_amount) FILTER (WHERE currency = s.currency) AS total_sales,
+			COUNT(*) AS total_transaction
+		FROM transactions
+		WHERE shift_id = s.id
+	) t ON TRUE
+	LEFT JOIN LATERAL (
+		SELECT SUM(py.amount) AS cash_sales
+		FROM payments py
+		JOIN transactions tr ON tr.id = py.transaction_id
+		WHERE tr.shift_id = s.id AND py.method = 'cash' AND tr.currency = s.currency
+	) p ON TRUE
+`
+
+type ShiftRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewShiftRepository(dbPool *pgxpool.Pool) *ShiftRepository {
+	return &ShiftRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanShift(row pgx.Row, s *model.ShiftModel) error {
+	var openingFloat, totalSales, cashSales, expectedCash int64
+	var countedCash, variance *int64
+	err := row.Scan(
+		&s.ID, &s.StoreID, &s.Status, &s.Currency, &openingFloat,
+		&totalSales, &cashSales, &s.TotalTransaction, &expectedCash,
+		&countedCash, &variance, &s.OpeningNote, &s.ClosingNote,
+		&s.OpenedAt, &s.ClosedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	s.OpeningFloat = money.New(openingFloat, s.Currency)
+	s.TotalSales = money.New(totalSales, s.Currency)
+	s.CashSales = money.New(cashSales, s.Currency)
+	s.ExpectedCash = money.New(expectedCash, s.Currency)
+	if countedCash != nil {
+		m := money.New(*countedCash, s.Currency)
+		s.CountedCash = &m
+	}
+	if variance != nil {
+		m := money.New(*variance, s.Currency)
+		s.Variance = &m
+	}
+	return nil
+}
+
+// FindAll lists the shifts of a store, or of every store when storeID is nil.
+func (r *ShiftRepository) FindAll(ctx context.Context, storeID *int) ([]model.ShiftModel, error) {
+	const query = shiftSelectQuery + `
+		WHERE $1::int IS NULL OR s.store_id = $1
+		ORDER BY s.opened_at DESC
+	`
+	rows, err := r.dbPool.Query(ctx, query, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.ShiftModel, 0)
+	for rows.Next() {
+		var s model.ShiftModel
+		if err := scanShift(rows, &s); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *ShiftRepository) FindOne(
+	ctx context.Context,
+	id int,
+) (*model.ShiftModel, error) {
+	var s model.ShiftModel
+	err := scanShift(r.dbPool.QueryRow(ctx, shiftSelectQuery+" WHERE s.id = $1", id), &s)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *ShiftRepository) FindOpen(ctx context.Context, storeID int) (*model.ShiftModel, error) {
+	var s model.ShiftModel
+	err := scanShift(r.dbPool.QueryRow(ctx, shiftSelectQuery+" WHERE s.status = 'open' AND s.store_id = $1", storeID), &s)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *ShiftRepository) Open(
+	ctx context.Context,
+	s *model.ShiftModel,
+) (*model.ShiftModel, error) {
+	const query = `
+		INSERT INTO shifts (store_id, currency, opening_float, opening_note)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	var id int
+	err := r.dbPool.QueryRow(ctx, query, s.StoreID, s.OpeningFloat.Currency, s.OpeningFloat.Amount, s.OpeningNote).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrShiftAlreadyOpen
+		}
+		return nil, err
+	}
+	return r.FindOne(ctx, id)
+}
+
+// Update edits the opening details of a shift. Closed shifts are locked and
+// return ErrShiftClosed.
+func (r *ShiftRepository) Update(
+	ctx context.Context,
+	s *model.ShiftModel,
+) (bool, error) {
+	const query = `
+		UPDATE shifts
+		SET opening_float = $1, opening_note = $2
+		WHERE id = $3 AND status = 'open' AND currency = $4
+	`
+	cmdTag, err := r.dbPool.Exec(ctx, query, s.OpeningFloat.Amount, s.OpeningNote, s.ID, s.OpeningFloat.Currency)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		existing, err := r.FindOne(ctx, s.ID)
+		if err != nil {
+			return false, err
+		}
+		if existing != nil {
+			if existing.Status == model.ShiftStatusClosed {
+				return false, ErrShiftClosed
+			}
+			return false, money.ErrCurrencyMismatch
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// Close locks the shift row, which waits for any checkout still holding the
+// shift, then stores the expected cash, the counted cash and their variance.
+func (r *ShiftRepository) Close(
+	ctx context.Context,
+	id int,
+	countedCash money.Money,
+	note string,
+) (*model.ShiftModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status, currency string
+	err = tx.QueryRow(ctx, `SELECT status, currency FROM shifts WHERE id = $1 FOR UPDATE`, id).Scan(&status, &currency)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if status == model.ShiftStatusClosed {
+		return nil, ErrShiftClosed
+	}
+	if countedCash.Currency != currency {
+		return nil, money.ErrCurrencyMismatch
+	}
+
+	const closeQuery = `
+		UPDATE shifts s
+		SET status = 'closed',
+			expected_cash = s.opening_float + t.cash_sales,
+			counted_cash = $2,
+			variance = $2 - (s.opening_float + t.cash_sales),
+			closing_note = $3,
+			closed_at = NOW()
+		FROM (
+			SELECT COALESCE(SUM(py.amount), 0) AS cash_sales
+			FROM payments py
+			JOIN transactions tr ON tr.id = py.transaction_id
+			WHERE tr.shift_id = $1 AND py.method = 'cash' AND tr.currency = $4
+		) t
+		WHERE s.id = $1
+	`
+	if _, err = tx.Exec(ctx, closeQuery, id, countedCash.Amount, note, currency); err != nil {
+		return nil, err
+	}
+
+	var s model.ShiftModel
+	if err = scanShift(tx.QueryRow(ctx, shiftSelectQuery+" WHERE s.id = $1", id), &s); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,397 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrTransferInsufficientStock = errors.New("source store does not have enough stock to dispatch this transfer")
+	ErrTransferStockMismatch     = errors.New("incoming stock of the destination store does not match the transfer")
+)
+
+// TransferStatusError is returned when a transfer is not in a status that
+// allows the requested step.
+type TransferStatusError struct {
+	Status string
+}
+
+func (e *TransferStatusError) Error() string {
+	return fmt.Sprintf("transfer is %s", e.Status)
+}
+
+const stockTransferSelectQuery = `
+	SELECT
+		t.id, t.source_store_id, ss.name, t.destination_store_id, ds.name, t.status, t.note,
+		t.requested_at, t.dispatched_at, t.received_at, t.cancelled_at
+	FROM stock_transfers t
+	JOIN stores ss ON ss.id = t.source_store_id
+	JOIN stores ds ON ds.id = t.destination_store_id
+`
+
+type StockTransferRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewStockTransferRepository(dbPool *pgxpool.Pool) *StockTransferRepository {
+	return &StockTransferRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanStockTransfer(row pgx.Row, t *model.StockTransferModel) error {
+	return row.Scan(
+		&t.ID, &t.SourceStoreID, &t.SourceStoreName, &t.DestinationStoreID, &t.DestinationStoreName,
+		&t.Status, &t.Note, &t.RequestedAt, &t.DispatchedAt, &t.ReceivedAt, &t.CancelledAt,
+	)
+}
+
+// FindAll lists the transfers leaving or reaching a store, or those of every
+// store when storeID is nil, optionally limited to one status.
+func (r *StockTransferRepository) FindAll(
+	ctx context.Context,
+	storeID *int,
+	status string,
+) ([]model.StockTransferModel, error) {
+	const query = stockTransferSelectQuery + `
+		WHERE ($1::int IS NULL OR t.source_store_id = $1 OR t.destination_store_id = $1)
+			AND ($2 = '' OR t.status = $2)
+		ORDER BY t.requested_at DESC, t.id DESC
+	`
+	rows, err := r.dbPool.Query(ctx, query, storeID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.StockTransferModel, 0)
+	for rows.Next() {
+		var t model.StockTransferModel
+		if err := scanStockTransfer(rows, &t); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachItems(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *StockTransferRepository) FindOne(
+	ctx context.Context,
+	id int,
+) (*model.StockTransferModel, error) {
+	var t model.StockTransferModel
+	err := scanStockTransfer(r.dbPool.QueryRow(ctx, stockTransferSelectQuery+" WHERE t.id = $1", id), &t)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	list := []model.StockTransferModel{t}
+	if err := r.attachItems(ctx, list); err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+func (r *StockTransferRepository) attachItems(ctx context.Context, transfers []model.StockTransferModel) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(transfers))
+	byID := make(map[int]*model.StockTransferModel, len(transfers))
+	for i := range transfers {
+		transfers[i].Items = []model.StockTransferItemModel{}
+		ids[i] = transfers[i].ID
+		byID[transfers[i].ID] = &transfers[i]
+	}
+
+	const query = `
+		SELECT i.id, i.transfer_id, i.product_id, p.name, i.variant_id, v.name, i.quantity
+		FROM stock_transfer_items i
+		JOIN products p ON p.id = i.product_id
+		LEFT JOIN product_variants v ON v.id = i.variant_id
+		WHERE i.transfer_id = ANY($1)
+		ORDER BY i.id
+	`
+	rows, err := r.dbPool.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item model.StockTransferItemModel
+		err := rows.Scan(&item.ID, &item.TransferID, &item.ProductID, &item.ProductName,
+			&item.VariantID, &item.VariantName, &item.Quantity)
+		if err != nil {
+			return err
+		}
+		transfer := byID[item.TransferID]
+		transfer.Items = append(transfer.Items, item)
+	}
+	return rows.Err()
+}
+
+// Create records a requested transfer. Stock does not move until the
+// transfer is dispatched.
+func (r *StockTransferRepository) Create(
+	ctx context.Context,
+	t *model.StockTransferModel,
+) (*model.StockTransferModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const transferQuery = `
+		INSERT INTO stock_transfers (source_store_id, destination_store_id, note)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	var id int
+	err = tx.QueryRow(ctx, transferQuery, t.SourceStoreID, t.DestinationStoreID, t.Note).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, ErrStoreNotFound
+		}
+		return nil, err
+	}
+
+	productIDs := make([]int, len(t.Items))
+	variantIDs := make([]*int, len(t.Items))
+	quantities := make([]int, len(t.Items))
+	for i, item := range t.Items {
+		productIDs[i] = item.ProductID
+		variantIDs[i] = item.VariantID
+		quantities[i] = item.Quantity
+	}
+
+	// Variants must belong to the product they are listed under
+	const itemQuery = `
+		INSERT INTO stock_transfer_items (transfer_id, product_id, variant_id, quantity)
+		SELECT $1, i.product_id, i.variant_id, i.quantity
+		FROM unnest($2::int[], $3::int[], $4::int[]) WITH ORDINALITY AS i(product_id, variant_id, quantity, n)
+		JOIN products p ON p.id = i.product_id AND p.deleted_at IS NULL
+		LEFT JOIN product_variants v ON v.id = i.variant_id
+		WHERE i.variant_id IS NULL OR v.product_id = i.product_id
+		ORDER BY i.n
+	`
+	cmdTag, err := tx.Exec(ctx, itemQuery, id, productIDs, variantIDs, quantities)
+	if err != nil {
+		return nil, err
+	}
+	if cmdTag.RowsAffected() != int64(len(t.Items)) {
+		return nil, ErrStockItemNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, id)
+}
+
+// Dispatch takes the stock out of the source store with the same conditional
+// update a checkout uses, and books it as incoming at the destination store.
+func (r *StockTransferRepository) Dispatch(ctx context.Context, id int) (*model.StockTransferModel, error) {
+	return r.transition(ctx, id, model.TransferStatusRequested, model.TransferStatusInTransit, "dispatched_at",
+		func(tx pgx.Tx, sourceStoreID, destinationStoreID int) error {
+			productQuantities, variantQuantities, err := transferQuantities(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			for _, stock := range []struct {
+				column     string
+				quantities map[int]int
+			}{
+				{"product_id", productQuantities},
+				{"variant_id", variantQuantities},
+			} {
+				err := deductStoreStock(ctx, tx, sourceStoreID, stock.column, stock.quantities)
+				if errors.Is(err, ErrInsufficientStock) {
+					return ErrTransferInsufficientStock
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			const incomingQuery = `
+				INSERT INTO store_stocks (store_id, product_id, variant_id, incoming)
+				SELECT $1, i.product_id, i.variant_id, i.quantity
+				FROM stock_transfer_items i
+				WHERE i.transfer_id = $2
+				ORDER BY i.product_id, i.variant_id NULLS FIRST
+				ON CONFLICT (store_id, product_id, COALESCE(variant_id, 0))
+				DO UPDATE SET incoming = store_stocks.incoming + EXCLUDED.incoming
+			`
+			_, err = tx.Exec(ctx, incomingQuery, destinationStoreID, id)
+			return err
+		})
+}
+
+// Receive moves the incoming stock of the transfer into the stock of the
+// destination store.
+func (r *StockTransferRepository) Receive(ctx context.Context, id int) (*model.StockTransferModel, error) {
+	return r.transition(ctx, id, model.TransferStatusInTransit, model.TransferStatusReceived, "received_at",
+		func(tx pgx.Tx, sourceStoreID, destinationStoreID int) error {
+			return receiveIncoming(ctx, tx, id, destinationStoreID, destinationStoreID)
+		})
+}
+
+// Cancel drops a requested transfer, or sends the stock of a transfer in
+// transit back to the source store.
+func (r *StockTransferRepository) Cancel(ctx context.Context, id int) (*model.StockTransferModel, error) {
+	transfer, err := r.transition(ctx, id, model.TransferStatusRequested, model.TransferStatusCancelled, "cancelled_at", nil)
+	var statusErr *TransferStatusError
+	if !errors.As(err, &statusErr) || statusErr.Status != model.TransferStatusInTransit {
+		return transfer, err
+	}
+
+	return r.transition(ctx, id, model.TransferStatusInTransit, model.TransferStatusCancelled, "cancelled_at",
+		func(tx pgx.Tx, sourceStoreID, destinationStoreID int) error {
+			return receiveIncoming(ctx, tx, id, destinationStoreID, sourceStoreID)
+		})
+}
+
+// transition moves a transfer from one status to the next and runs the stock
+// movement of that step in the same database transaction. The status update
+// is conditional, so two requests racing for the same step cannot both move
+// the stock.
+func (r *StockTransferRepository) transition(
+	ctx context.Context,
+	id int,
+	from, to string,
+	timestampColumn string,
+	move func(tx pgx.Tx, sourceStoreID, destinationStoreID int) error,
+) (*model.StockTransferModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := fmt.Sprintf(`
+		UPDATE stock_transfers
+		SET status = $3, %s = NOW()
+		WHERE id = $1 AND status = $2
+		RETURNING source_store_id, destination_store_id
+	`, timestampColumn)
+	var sourceStoreID, destinationStoreID int
+	err = tx.QueryRow(ctx, query, id, from, to).Scan(&sourceStoreID, &destinationStoreID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		var status string
+		err = tx.QueryRow(ctx, `SELECT status FROM stock_transfers WHERE id = $1`, id).Scan(&status)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, &TransferStatusError{Status: status}
+	}
+
+	if move != nil {
+		if err = move(tx, sourceStoreID, destinationStoreID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, id)
+}
+
+// transferQuantities splits the items of a transfer into product and variant
+// quantities, the way a checkout deducts them.
+func transferQuantities(ctx context.Context, tx pgx.Tx, id int) (map[int]int, map[int]int, error) {
+	rows, err := tx.Query(ctx, `SELECT product_id, variant_id, quantity FROM stock_transfer_items WHERE transfer_id = $1`, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	productQuantities := make(map[int]int)
+	variantQuantities := make(map[int]int)
+	for rows.Next() {
+		var productID, quantity int
+		var variantID *int
+		if err := rows.Scan(&productID, &variantID, &quantity); err != nil {
+			return nil, nil, err
+		}
+		if variantID != nil {
+			variantQuantities[*variantID] += quantity
+		} else {
+			productQuantities[productID] += quantity
+		}
+	}
+	return productQuantities, variantQuantities, rows.Err()
+}
+
+// receiveIncoming takes the items of a transfer off the incoming stock of the
+// destination store and adds them to the stock of targetStoreID, which is the
+// destination on receipt or the source when a transfer in transit is
+// cancelled. Products moved directly are bumped to a new version.
+func receiveIncoming(ctx context.Context, tx pgx.Tx, id, destinationStoreID, targetStoreID int) error {
+	const incomingQuery = `
+		UPDATE store_stocks s
+		SET incoming = s.incoming - i.quantity
+		FROM stock_transfer_items i
+		WHERE i.transfer_id = $1 AND s.store_id = $2
+			AND s.product_id = i.product_id AND s.variant_id IS NOT DISTINCT FROM i.variant_id
+			AND s.incoming >= i.quantity
+	`
+	cmdTag, err := tx.Exec(ctx, incomingQuery, id, destinationStoreID)
+	if err != nil {
+		return err
+	}
+
+	var items int64
+	if err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM stock_transfer_items WHERE transfer_id = $1`, id).Scan(&items); err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() != items {
+		return ErrTransferStockMismatch
+	}
+
+	const stockQuery = `
+		INSERT INTO store_stocks (store_id, product_id, variant_id, stock)
+		SELECT $2, i.product_id, i.variant_id, i.quantity
+		FROM stock_transfer_items i
+		WHERE i.transfer_id = $1
+		ORDER BY i.product_id, i.variant_id NULLS FIRST
+		ON CONFLICT (store_id, product_id, COALESCE(variant_id, 0))
+		DO UPDATE SET stock = store_stocks.stock + EXCLUDED.stock
+	`
+	if _, err = tx.Exec(ctx, stockQuery, id, targetStoreID); err != nil {
+		return err
+	}
+
+	const versionQuery = `
+		UPDATE products SET version = version + 1
+		WHERE id IN (SELECT product_id FROM stock_transfer_items WHERE transfer_id = $1 AND variant_id IS NULL)
+	`
+	_, err = tx.Exec(ctx, versionQuery, id)
+	return err
+}
@@ -0,0 +1,463 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrStocktakeAlreadyOpen = errors.New("another stocktake is still open in this store, finalize or cancel it first")
+	ErrStocktakeItemUnknown = errors.New("product or variant is not part of this stocktake")
+	ErrNegativeCount        = errors.New("counted quantity cannot drop below zero")
+)
+
+// StocktakeStatusError is returned when a stocktake is no longer open.
+type StocktakeStatusError struct {
+	Status string
+}
+
+func (e *StocktakeStatusError) Error() string {
+	return fmt.Sprintf("stocktake is %s", e.Status)
+}
+
+const stocktakeSelectQuery = `
+	SELECT
+		st.id, st.store_id, s.name, st.status, st.note,
+		COALESCE(i.total_items, 0), COALESCE(i.counted_items, 0), COALESCE(i.total_variance, 0),
+		st.created_at, st.finalized_at, st.cancelled_at
+	FROM stocktakes st
+	JOIN stores s ON s.id = st.store_id
+	LEFT JOIN LATERAL (
+		SELECT
+			COUNT(*) AS total_items,
+			COUNT(counted) AS counted_items,
+			SUM(counted - expected) AS total_variance
+		FROM stocktake_items
+		WHERE stocktake_id = st.id
+	) i ON TRUE
+`
+
+type StocktakeRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewStocktakeRepository(dbPool *pgxpool.Pool) *StocktakeRepository {
+	return &StocktakeRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanStocktake(row pgx.Row, st *model.StocktakeModel) error {
+	return row.Scan(
+		&st.ID, &st.StoreID, &st.StoreName, &st.Status, &st.Note,
+		&st.TotalItems, &st.CountedItems, &st.TotalVariance,
+		&st.CreatedAt, &st.FinalizedAt, &st.CancelledAt,
+	)
+}
+
+// FindAll lists the stocktakes of a store, or of every store when storeID is
+// nil, without their items.
+func (r *StocktakeRepository) FindAll(ctx context.Context, storeID *int) ([]model.StocktakeModel, error) {
+	const query = stocktakeSelectQuery + `
+		WHERE $1::int IS NULL OR st.store_id = $1
+		ORDER BY st.created_at DESC, st.id DESC
+	`
+	rows, err := r.dbPool.Query(ctx, query, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.StocktakeModel, 0)
+	for rows.Next() {
+		var st model.StocktakeModel
+		if err := scanStocktake(rows, &st); err != nil {
+			return nil, err
+		}
+		list = append(list, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FindOne returns a stocktake with its items, counted or not.
+func (r *StocktakeRepository) FindOne(
+	ctx context.Context,
+	id int,
+) (*model.StocktakeModel, error) {
+	var st model.StocktakeModel
+	err := scanStocktake(r.dbPool.QueryRow(ctx, stocktakeSelectQuery+" WHERE st.id = $1", id), &st)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	const itemQuery = `
+		SELECT i.product_id, p.name, i.variant_id, v.name, i.expected, i.counted, i.counted - i.expected, i.counted_at
+		FROM stocktake_items i
+		JOIN products p ON p.id = i.product_id
+		LEFT JOIN product_variants v ON v.id = i.variant_id
+		WHERE i.stocktake_id = $1
+		ORDER BY p.name, i.product_id, v.name NULLS FIRST, i.variant_id
+	`
+	rows, err := r.dbPool.Query(ctx, itemQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	st.Items = make([]model.StocktakeItemModel, 0, st.TotalItems)
+	for rows.Next() {
+		var item model.StocktakeItemModel
+		err := rows.Scan(&item.ProductID, &item.ProductName, &item.VariantID, &item.VariantName,
+			&item.Expected, &item.Counted, &item.Variance, &item.CountedAt)
+		if err != nil {
+			return nil, err
+		}
+		st.Items = append(st.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Create opens a stocktake and snapshots the stock the store holds of every
+// live product without variants and of every variant.
+func (r *StocktakeRepository) Create(
+	ctx context.Context,
+	st *model.StocktakeModel,
+) (*model.StocktakeModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	err = tx.QueryRow(ctx, `INSERT INTO stocktakes (store_id, note) VALUES ($1, $2) RETURNING id`, st.StoreID, st.Note).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return nil, ErrStocktakeAlreadyOpen
+			case "23503":
+				return nil, ErrStoreNotFound
+			}
+		}
+		return nil, err
+	}
+
+	const snapshotQuery = `
+		INSERT INTO stocktake_items (stocktake_id, product_id, variant_id, expected)
+		SELECT $1, p.id, NULL, COALESCE(ss.stock, 0)
+		FROM products p
+		LEFT JOIN store_stocks ss ON ss.store_id = $2 AND ss.product_id = p.id AND ss.variant_id IS NULL
+		WHERE p.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+		UNION ALL
+		SELECT $1, v.product_id, v.id, COALESCE(ss.stock, 0)
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id AND p.deleted_at IS NULL
+		LEFT JOIN store_stocks ss ON ss.store_id = $2 AND ss.variant_id = v.id
+	`
+	if _, err = tx.Exec(ctx, snapshotQuery, id, st.StoreID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, id)
+}
+
+// Count records counted quantities. With replace false they are added to the
+// current count, so several devices can count the same item side by side;
+// a negative quantity takes back a miscount. The stocktake row is held for
+// share, which makes a concurrent finalize wait for the count to land.
+func (r *StocktakeRepository) Count(
+	ctx context.Context,
+	id int,
+	items []model.StocktakeItemModel,
+	replace bool,
+) (bool, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockOpenStocktake(ctx, tx, id, "FOR SHARE"); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	productIDs := make([]int, len(items))
+	variantIDs := make([]*int, len(items))
+	quantities := make([]int, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+		variantIDs[i] = item.VariantID
+		quantities[i] = *item.Counted
+	}
+
+	counted := "COALESCE(si.counted, 0) + c.quantity"
+	if replace {
+		counted = "c.quantity"
+	}
+	query := fmt.Sprintf(`
+		UPDATE stocktake_items si
+		SET counted = %s, counted_at = NOW()
+		FROM unnest($2::int[], $3::int[], $4::int[]) AS c(product_id, variant_id, quantity)
+		WHERE si.stocktake_id = $1 AND si.product_id = c.product_id
+			AND si.variant_id IS NOT DISTINCT FROM c.variant_id
+	`, counted)
+	cmdTag, err := tx.Exec(ctx, query, id, productIDs, variantIDs, quantities)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23514" {
+			return false, ErrNegativeCount
+		}
+		return false, err
+	}
+	if cmdTag.RowsAffected() != int64(len(items)) {
+		return false, ErrStocktakeItemUnknown
+	}
+	return true, tx.Commit(ctx)
+}
+
+// Finalize closes the stocktake and posts its variances. Each variance is
+// applied to the current stock of the store rather than overwriting it with
+// the count, so sales and transfers made while counting are kept; stock
+// never drops below zero, and the adjustments record the change that was
+// actually applied. A count that leaves less stock than is reserved
+// for orders and carts fails with ErrStockReserved.
+func (r *StocktakeRepository) Finalize(
+	ctx context.Context,
+	id int,
+	zeroUncounted bool,
+) (*model.StocktakeModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const finalizeQuery = `
+		UPDATE stocktakes
+		SET status = 'finalized', finalized_at = NOW()
+		WHERE id = $1 AND status = 'open'
+		RETURNING store_id
+	`
+	var storeID int
+	if err = tx.QueryRow(ctx, finalizeQuery, id).Scan(&storeID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, stocktakeStatusError(ctx, tx, id)
+		}
+		return nil, err
+	}
+
+	if zeroUncounted {
+		_, err = tx.Exec(ctx, `UPDATE stocktake_items SET counted = 0 WHERE stocktake_id = $1 AND counted IS NULL`, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The old stock comes from a second reference to store_stocks, which
+	// still sees the rows as they were before this update
+	const adjustQuery = `
+		UPDATE store_stocks s
+		SET stock = GREATEST(s.stock + i.counted - i.expected, 0)
+		FROM stocktake_items i, store_stocks old
+		WHERE i.stocktake_id = $1 AND i.counted <> i.expected
+			AND s.store_id = $2 AND s.product_id = i.product_id
+			AND s.variant_id IS NOT DISTINCT FROM i.variant_id
+			AND old.id = s.id
+		RETURNING s.product_id, s.variant_id, s.stock - old.stock
+	`
+	var applied stockDeltas
+	if err = applied.collect(tx.Query(ctx, adjustQuery, id, storeID)); err != nil {
+		if isStockReservedViolation(err) {
+			return nil, ErrStockReserved
+		}
+		return nil, err
+	}
+
+	// Items the store had no stock row for start from nothing
+	const insertQuery = `
+		INSERT INTO store_stocks (store_id, product_id, variant_id, stock)
+		SELECT $2, i.product_id, i.variant_id, GREATEST(i.counted - i.expected, 0)
+		FROM stocktake_items i
+		WHERE i.stocktake_id = $1 AND i.counted <> i.expected
+		ORDER BY i.product_id, i.variant_id NULLS FIRST
+		ON CONFLICT (store_id, product_id, COALESCE(variant_id, 0)) DO NOTHING
+		RETURNING product_id, variant_id, stock
+	`
+	if err = applied.collect(tx.Query(ctx, insertQuery, id, storeID)); err != nil {
+		return nil, err
+	}
+
+	// Record what the stock actually moved by, which differs from the
+	// variance when the stock was clamped at zero
+	const adjustmentQuery = `
+		INSERT INTO stock_adjustments (store_id, product_id, variant_id, quantity, reason, stocktake_id)
+		SELECT $2, a.product_id, a.variant_id, a.quantity, $3, $1
+		FROM unnest($4::int[], $5::int[], $6::int[]) AS a(product_id, variant_id, quantity)
+		WHERE a.quantity <> 0
+		ORDER BY a.product_id, a.variant_id NULLS FIRST
+	`
+	_, err = tx.Exec(ctx, adjustmentQuery, id, storeID, model.AdjustmentReasonStocktake,
+		applied.productIDs, applied.variantIDs, applied.quantities)
+	if err != nil {
+		return nil, err
+	}
+
+	const versionQuery = `
+		UPDATE products SET version = version + 1
+		WHERE id IN (
+			SELECT product_id FROM stocktake_items
+			WHERE stocktake_id = $1 AND variant_id IS NULL AND counted <> expected
+		)
+	`
+	if _, err = tx.Exec(ctx, versionQuery, id); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, id)
+}
+
+// stockDeltas gathers the stock changes returned by the statements of a
+// finalize, column by column so they can be passed to unnest.
+type stockDeltas struct {
+	productIDs []int
+	variantIDs []*int
+	quantities []int
+}
+
+// collect appends the product, variant and quantity of every returned row.
+func (d *stockDeltas) collect(rows pgx.Rows, err error) error {
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			productID int
+			variantID *int
+			quantity  int
+		)
+		if err := rows.Scan(&productID, &variantID, &quantity); err != nil {
+			return err
+		}
+		d.productIDs = append(d.productIDs, productID)
+		d.variantIDs = append(d.variantIDs, variantID)
+		d.quantities = append(d.quantities, quantity)
+	}
+	return rows.Err()
+}
+
+// Cancel closes an open stocktake without touching any stock.
+func (r *StocktakeRepository) Cancel(ctx context.Context, id int) (*model.StocktakeModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const query = `
+		UPDATE stocktakes
+		SET status = 'cancelled', cancelled_at = NOW()
+		WHERE id = $1 AND status = 'open'
+	`
+	cmdTag, err := tx.Exec(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return nil, stocktakeStatusError(ctx, tx, id)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, id)
+}
+
+// FindAdjustments lists the stock adjustments a finalized stocktake posted.
+func (r *StocktakeRepository) FindAdjustments(ctx context.Context, id int) ([]model.StockAdjustmentModel, error) {
+	const query = `
+		SELECT a.id, a.store_id, a.product_id, p.name, a.variant_id, v.name, a.quantity, a.reason,
+			a.stocktake_id, a.created_at
+		FROM stock_adjustments a
+		JOIN products p ON p.id = a.product_id
+		LEFT JOIN product_variants v ON v.id = a.variant_id
+		WHERE a.stocktake_id = $1
+		ORDER BY a.id
+	`
+	rows, err := r.dbPool.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.StockAdjustmentModel, 0)
+	for rows.Next() {
+		var a model.StockAdjustmentModel
+		err := rows.Scan(&a.ID, &a.StoreID, &a.ProductID, &a.ProductName, &a.VariantID, &a.VariantName,
+			&a.Quantity, &a.Reason, &a.StocktakeID, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// lockOpenStocktake locks an open stocktake, returning pgx.ErrNoRows when it
+// does not exist and a StocktakeStatusError when it is no longer open.
+func lockOpenStocktake(ctx context.Context, tx pgx.Tx, id int, lock string) error {
+	var status string
+	err := tx.QueryRow(ctx, `SELECT status FROM stocktakes WHERE id = $1 `+lock, id).Scan(&status)
+	if err != nil {
+		return err
+	}
+	if status != model.StocktakeStatusOpen {
+		return &StocktakeStatusError{Status: status}
+	}
+	return nil
+}
+
+// stocktakeStatusError explains why an open stocktake could not be updated:
+// nil when it does not exist, otherwise its current status.
+func stocktakeStatusError(ctx context.Context, tx pgx.Tx, id int) error {
+	var status string
+	err := tx.QueryRow(ctx, `SELECT status FROM stocktakes WHERE id = $1`, id).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &StocktakeStatusError{Status: status}
+}
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrStoreNotFound     = errors.New("store not found")
+	ErrStoreCodeTaken    = errors.New("store code is already used by another store")
+	ErrDefaultStore      = errors.New("the default store cannot be deleted, make another store the default first")
+	ErrStoreHasStock     = errors.New("store still holds or expects stock, move or clear it first")
+	ErrStoreInUse        = errors.New("store has shifts, transactions, orders, transfers or stocktakes and cannot be deleted")
+	ErrStockItemNotFound = errors.New("product or variant not found")
+	ErrStockHeldByStores = errors.New("stock cannot drop below the stock held by stores other than the default store")
+	ErrStockReserved     = errors.New("stock cannot drop below the stock reserved for orders and carts")
+)
+
+// isStockReservedViolation tells whether err is a store stock write that
+// would leave less stock than is reserved.
+func isStockReservedViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23514" && pgErr.ConstraintName == "store_stocks_reserved_stock_check"
+}
+
+const storeSelectQuery = `
+	SELECT s.id, s.name, s.code, s.address, s.is_default, s.created_at
+	FROM stores s
+`
+
+const storeStockSelectQuery = `
+	SELECT ss.store_id, s.name, ss.product_id, p.name, ss.variant_id, v.name, ss.stock, ss.incoming, ss.reserved
+	FROM store_stocks ss
+	JOIN stores s ON s.id = ss.store_id
+	JOIN products p ON p.id = ss.product_id
+	LEFT JOIN product_variants v ON v.id = ss.variant_id
+`
+
+type StoreRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewStoreRepository(dbPool *pgxpool.Pool) *StoreRepository {
+	return &StoreRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanStore(row pgx.Row, s *model.StoreModel) error {
+	return row.Scan(&s.ID, &s.Name, &s.Code, &s.Address, &s.IsDefault, &s.CreatedAt)
+}
+
+func (r *StoreRepository) FindAll(ctx context.Context) ([]model.StoreModel, error) {
+	rows, err := r.dbPool.Query(ctx, storeSelectQuery+" ORDER BY s.id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.StoreModel, 0)
+	for rows.Next() {
+		var s model.StoreModel
+		if err := scanStore(rows, &s); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *StoreRepository) FindOne(
+	ctx context.Context,
+	id int,
+) (*model.StoreModel, error) {
+	return r.findOne(ctx, storeSelectQuery+" WHERE s.id = $1", id)
+}
+
+// FindDefault returns the store used when a request does not name one.
+func (r *StoreRepository) FindDefault(ctx context.Context) (*model.StoreModel, error) {
+	return r.findOne(ctx, storeSelectQuery+" WHERE s.is_default")
+}
+
+func (r *StoreRepository) findOne(ctx context.Context, query string, args ...any) (*model.StoreModel, error) {
+	var s model.StoreModel
+	if err := scanStore(r.dbPool.QueryRow(ctx, query, args...), &s); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Create adds a store. A store created as the default takes over from the
+// current default store.
+func (r *StoreRepository) Create(
+	ctx context.Context,
+	s *model.StoreModel,
+) (*model.StoreModel, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if s.IsDefault {
+		if err = clearDefaultStore(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	const query = `
+		INSERT INTO stores (name, code, address, is_default)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, name, code, address, is_default, created_at
+	`
+	var out model.StoreModel
+	err = scanStore(tx.QueryRow(ctx, query, s.Name, s.Code, s.Address, s.IsDefault), &out)
+	if err != nil {
+		return nil, storeWriteError(err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Update edits a store. Setting IsDefault makes it the default store; the
+// default store only stops being the default when another store takes over.
+func (r *StoreRepository) Update(
+	ctx context.Context,
+	s *model.StoreModel,
+) (bool, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if s.IsDefault {
+		if err = clearDefaultStore(ctx, tx); err != nil {
+			return false, err
+		}
+	}
+
+	const query = `
+		UPDATE stores
+		SET name = $1, code = $2, address = $3, is_default = is_default OR $4
+		WHERE id = $5
+	`
+	cmdTag, err := tx.Exec(ctx, query, s.Name, s.Code, s.Address, s.IsDefault, s.ID)
+	if err != nil {
+		return false, storeWriteError(err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, tx.Commit(ctx)
+}
+
+func clearDefaultStore(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `UPDATE stores SET is_default = FALSE WHERE is_default`)
+	return err
+}
+
+// Delete removes a store that is not the default, holds no stock and has
+// never had a shift, a transaction, an order, a transfer or a stocktake.
+func (r *StoreRepository) Delete(
+	ctx context.Context,
+	id int,
+) (bool, error) {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var isDefault bool
+	err = tx.QueryRow(ctx, `SELECT is_default FROM stores WHERE id = $1 FOR UPDATE`, id).Scan(&isDefault)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	if isDefault {
+		return false, ErrDefaultStore
+	}
+
+	var held int
+	err = tx.QueryRow(ctx, `SELECT COALESCE(SUM(stock + incoming), 0) FROM store_stocks WHERE store_id = $1`, id).Scan(&held)
+	if err != nil {
+		return false, err
+	}
+	if held > 0 {
+		return false, ErrStoreHasStock
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM stores WHERE id = $1`, id); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return false, ErrStoreInUse
+		}
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+func storeWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "stores_code_key" {
+		return ErrStoreCodeTaken
+	}
+	return err
+}
+
+func (r *StoreRepository) findStocks(ctx context.Context, query string, args ...any) ([]model.StoreStockModel, error) {
+	rows, err := r.dbPool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.StoreStockModel, 0)
+	for rows.Next() {
+		var s model.StoreStockModel
+		err := rows.Scan(&s.StoreID, &s.StoreName, &s.ProductID, &s.ProductName, &s.VariantID, &s.VariantName, &s.Stock, &s.Incoming, &s.Reserved)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FindStocks lists the stock a store holds of every live product.
+func (r *StoreRepository) FindStocks(ctx context.Context, storeID int) ([]model.StoreStockModel, error) {
+	const query = storeStockSelectQuery + `
+		WHERE ss.store_id = $1 AND p.deleted_at IS NULL
+		ORDER BY p.name, ss.product_id, ss.variant_id NULLS FIRST
+	`
+	return r.findStocks(ctx, query, storeID)
+}
+
+// FindStocksByProduct breaks the stock of a product and its variants down
+// per store.
+func (r *StoreRepository) FindStocksByProduct(ctx context.Context, productID int) ([]model.StoreStockModel, error) {
+	const query = storeStockSelectQuery + `
+		WHERE ss.product_id = $1
+		ORDER BY ss.store_id, ss.variant_id NULLS FIRST
+	`
+	return r.findStocks(ctx, query, productID)
+}
+
+// FindStock returns the stock a store can sell of a product, or of one of
+// its variants when variantID is set: what it holds less what is reserved,
+// except for the reservations of orderID and cartID when they are set.
+func (r *StoreRepository) FindStock(
+	ctx context.Context,
+	storeID, productID int,
+	variantID *int,
+	orderID, cartID *int,
+) (int, error) {
+	const query = `
+		SELECT
+			COALESCE(SUM(stock - reserved), 0) + COALESCE((
+				SELECT SUM(quantity)
+				FROM stock_reservations
+				WHERE (order_id = $4 OR cart_id = $5) AND store_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3
+			), 0)
+		FROM store_stocks
+		WHERE store_id = $1 AND product_id = $2 AND variant_id IS NOT DISTINCT FROM $3
+	`
+	var stock int
+	err := r.dbPool.QueryRow(ctx, query, storeID, productID, variantID, orderID, cartID).Scan(&stock)
+	return stock, err
+}
+
+// SetStocks overwrites the stock levels of a store. The totals on products
+// and variants follow through the store_stocks trigger; products are bumped
+// to a new version since their stock changed. Stock cannot be set below what
+// is reserved for orders and carts.
+func (r *StoreRepository) SetStocks(ctx context.Context, storeID int, items []model.StoreStockModel) error {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	productIDs := make([]int, len(items))
+	variantIDs := make([]*int, len(items))
+	stocks := make([]int, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+		variantIDs[i] = item.VariantID
+		stocks[i] = item.Stock
+	}
+
+	const query = `
+		INSERT INTO store_stocks (store_id, product_id, variant_id, stock)
+		SELECT $1, i.product_id, i.variant_id, i.stock
+		FROM unnest($2::int[], $3::int[], $4::int[]) AS i(product_id, variant_id, stock)
+		JOIN products p ON p.id = i.product_id AND p.deleted_at IS NULL
+		LEFT JOIN product_variants v ON v.id = i.variant_id
+		WHERE i.variant_id IS NULL OR v.product_id = i.product_id
+		ORDER BY i.product_id, i.variant_id NULLS FIRST
+		ON CONFLICT (store_id, product_id, COALESCE(variant_id, 0))
+		DO UPDATE SET stock = EXCLUDED.stock
+	`
+	cmdTag, err := tx.Exec(ctx, query, storeID, productIDs, variantIDs, stocks)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" && pgErr.ConstraintName == "store_stocks_store_id_fkey" {
+			return ErrStoreNotFound
+		}
+		if isStockReservedViolation(err) {
+			return ErrStockReserved
+		}
+		return err
+	}
+	if cmdTag.RowsAffected() != int64(len(items)) {
+		return ErrStockItemNotFound
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE products SET version = version + 1 WHERE id = ANY($1)`, productIDs)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
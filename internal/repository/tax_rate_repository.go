@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrTaxRateNotFound = errors.New("tax rate not found")
+
+const taxRateSelectQuery = `
+	SELECT tr.id, tr.name, tr.code, tr.rate_bps, tr.inclusive, tr.compound, tr.active, tr.created_at
+	FROM tax_rates tr
+`
+
+type TaxRateRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewTaxRateRepository(dbPool *pgxpool.Pool) *TaxRateRepository {
+	return &TaxRateRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanTaxRate(row pgx.Row, t *model.TaxRateModel) error {
+	return row.Scan(&t.ID, &t.Name, &t.Code, &t.RateBps, &t.Inclusive, &t.Compound, &t.Active, &t.CreatedAt)
+}
+
+func (r *TaxRateRepository) findMany(ctx context.Context, query string, args ...any) ([]model.TaxRateModel, error) {
+	rows, err := r.dbPool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.TaxRateModel, 0)
+	for rows.Next() {
+		var t model.TaxRateModel
+		if err := scanTaxRate(rows, &t); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *TaxRateRepository) FindAll(ctx context.Context) ([]model.TaxRateModel, error) {
+	return r.findMany(ctx, taxRateSelectQuery+" ORDER BY tr.id")
+}
+
+func (r *TaxRateRepository) FindOne(
+	ctx context.Context,
+	id int,
+) (*model.TaxRateModel, error) {
+	var t model.TaxRateModel
+	err := scanTaxRate(r.dbPool.QueryRow(ctx, taxRateSelectQuery+" WHERE tr.id = $1", id), &t)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *TaxRateRepository) FindByProduct(ctx context.Context, productID int) ([]model.TaxRateModel, error) {
+	const query = taxRateSelectQuery + `
+		JOIN product_tax_rates ptr ON ptr.tax_rate_id = tr.id
+		WHERE ptr.product_id = $1
+		ORDER BY tr.id
+	`
+	return r.findMany(ctx, query, productID)
+}
+
+func (r *TaxRateRepository) FindByCategory(ctx context.Context, categoryID int) ([]model.TaxRateModel, error) {
+	const query = taxRateSelectQuery + `
+		JOIN category_tax_rates ctr ON ctr.tax_rate_id = tr.id
+		WHERE ctr.category_id = $1
+		ORDER BY tr.id
+	`
+	return r.findMany(ctx, query, categoryID)
+}
+
+// FindForProducts resolves the active tax rates that apply to each product:
+// its own rates when it has any, otherwise the rates of its category.
+func (r *TaxRateRepository) FindForProducts(
+	ctx context.Context,
+	productIDs []int,
+) (map[int][]model.TaxRateModel, error) {
+	const query = `
+		SELECT p.id, tr.id, tr.name, tr.code, tr.rate_bps, tr.inclusive, tr.compound, tr.active, tr.created_at
+		FROM products p
+		JOIN tax_rates tr ON tr.active AND (
+			tr.id IN (SELECT ptr.tax_rate_id FROM product_tax_rates ptr WHERE ptr.product_id = p.id)
+			OR (
+				NOT EXISTS (SELECT 1 FROM product_tax_rates ptr WHERE ptr.product_id = p.id)
+				AND tr.id IN (SELECT ctr.tax_rate_id FROM category_tax_rates ctr WHERE ctr.category_id = p.category_id)
+			)
+		)
+		WHERE p.id = ANY($1)
+		ORDER BY p.id, tr.compound, tr.id
+	`
+	rows, err := r.dbPool.Query(ctx, query, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make(map[int][]model.TaxRateModel)
+	for rows.Next() {
+		var productID int
+		var t model.TaxRateModel
+		err := rows.Scan(&productID, &t.ID, &t.Name, &t.Code, &t.RateBps, &t.Inclusive, &t.Compound, &t.Active, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		rates[productID] = append(rates[productID], t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (r *TaxRateRepository) SetProductRates(ctx context.Context, productID int, taxRateIDs []int) error {
+	return r.replaceAssignments(ctx, "product_tax_rates", "product_id", productID, taxRateIDs)
+}
+
+func (r *TaxRateRepository) SetCategoryRates(ctx context.Context, categoryID int, taxRateIDs []int) error {
+	return r.replaceAssignments(ctx, "category_tax_rates", "category_id", categoryID, taxRateIDs)
+}
+
+func (r *TaxRateRepository) replaceAssignments(
+	ctx context.Context,
+	table string,
+	column string,
+	ownerID int,
+	taxRateIDs []int,
+) error {
+	tx, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "DELETE FROM "+table+" WHERE "+column+" = $1", ownerID); err != nil {
+		return err
+	}
+	if len(taxRateIDs) > 0 {
+		query := "INSERT INTO " + table + " (" + column + ", tax_rate_id) SELECT $1, unnest($2::int[])"
+		if _, err = tx.Exec(ctx, query, ownerID, taxRateIDs); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" && pgErr.ConstraintName == table+"_tax_rate_id_fkey" {
+				return ErrTaxRateNotFound
+			}
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *TaxRateRepository) Delete(
+	ctx context.Context,
+	id int,
+) (bool, error) {
+	const query = `
+		DELETE FROM tax_rates
+		WHERE id = $1
+	`
+	cmdTag, err := r.dbPool.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *TaxRateRepository) Update(
+	ctx context.Context,
+	t *model.TaxRateModel,
+) (bool, error) {
+	const query = `
+		UPDATE tax_rates
+		SET name = $1, code = $2, rate_bps = $3, inclusive = $4, compound = $5, active = $6
+		WHERE id = $7
+	`
+	cmdTag, err := r.dbPool.Exec(ctx, query, t.Name, t.Code, t.RateBps, t.Inclusive, t.Compound, t.Active, t.ID)
+	if err != nil {
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *TaxRateRepository) Create(
+	ctx context.Context,
+	t *model.TaxRateModel,
+) (*model.TaxRateModel, error) {
+	const query = `
+		INSERT INTO tax_rates (name, code, rate_bps, inclusive, compound, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, name, code, rate_bps, inclusive, compound, active, created_at
+	`
+	var out model.TaxRateModel
+	err := scanTaxRate(r.dbPool.QueryRow(ctx, query, t.Name, t.Code, t.RateBps, t.Inclusive, t.Compound, t.Active), &out)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -2,14 +2,28 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var ErrInsufficientStock = errors.New("insufficient stock: concurrent modification detected")
+
+// TransactionFilter narrows the transactions listed. Nil fields do not
+// filter.
+type TransactionFilter struct {
+	StoreID    *int
+	CustomerID *int
+}
+
 type TransactionRepository struct {
 	dbPool *pgxpool.Pool
 }
@@ -30,36 +44,128 @@ func (r *TransactionRepository) Create(
 	}
 	defer tx.Rollback(ctx)
 
+	// Hold the open shift of the store so it cannot be closed while this
+	// checkout is in flight
+	const shiftQuery = `
+		SELECT id FROM shifts
+		WHERE status = 'open' AND store_id = $1
+		FOR SHARE
+	`
+	err = tx.QueryRow(ctx, shiftQuery, transaction.StoreID).Scan(&transaction.ShiftID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoOpenShift
+		}
+		return nil, err
+	}
+
+	// Close the order being settled and release the stock it reserved
+	if transaction.OrderID != nil {
+		if err := settleOrder(ctx, tx, *transaction.OrderID, transaction.OrderVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check out the cart, turning the stock it reserved into deductions below
+	if transaction.CartID != nil {
+		if err := consumeCart(ctx, tx, *transaction.CartID, transaction.StoreID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Consume the coupon, guarded against its usage limit
+	if transaction.CouponID != nil {
+		const couponQuery = `
+			UPDATE coupons
+			SET used_count = used_count + 1
+			WHERE id = $1 AND (usage_limit IS NULL OR used_count < usage_limit)
+		`
+		result, err := tx.Exec(ctx, couponQuery, *transaction.CouponID)
+		if err != nil {
+			return nil, err
+		}
+		if result.RowsAffected() == 0 {
+			return nil, ErrCouponLimitReached
+		}
+	}
+
 	// Insert transaction
 	const txQuery = `
-		INSERT INTO transactions (total_amount)
-		VALUES ($1)
-		RETURNING id, total_amount, created_at
+		INSERT INTO transactions
+			(currency, subtotal_amount, discount_amount, tax_amount, total_amount, promotion_id, coupon_id, shift_id,
+			store_id, customer_id, points_earned, points_redeemed, order_id, cart_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at
 	`
-	err = tx.QueryRow(ctx, txQuery, transaction.TotalAmount).Scan(
+	err = tx.QueryRow(
+		ctx,
+		txQuery,
+		transaction.Currency,
+		transaction.SubtotalAmount.Amount,
+		transaction.DiscountAmount.Amount,
+		transaction.TaxAmount.Amount,
+		transaction.TotalAmount.Amount,
+		transaction.PromotionID,
+		transaction.CouponID,
+		transaction.ShiftID,
+		transaction.StoreID,
+		transaction.CustomerID,
+		transaction.PointsEarned,
+		transaction.PointsRedeemed,
+		transaction.OrderID,
+		transaction.CartID,
+	).Scan(
 		&transaction.ID,
-		&transaction.TotalAmount,
 		&transaction.CreatedAt,
 	)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.ConstraintName == "transactions_customer_id_fkey" {
+			return nil, ErrCustomerNotFound
+		}
 		return nil, err
 	}
 
+	// Spend the points paid with, then credit the points earned
+	if transaction.CustomerID != nil && transaction.PointsRedeemed > 0 {
+		_, err = debitPoints(ctx, tx, *transaction.CustomerID, &transaction.ID, model.LoyaltyEntryRedeem,
+			transaction.PointsRedeemed, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if transaction.CustomerID != nil && transaction.PointsEarned > 0 {
+		expiresAt, err := pointsExpiry(ctx, tx, transaction.Currency)
+		if err != nil {
+			return nil, err
+		}
+		_, err = creditPoints(ctx, tx, *transaction.CustomerID, &transaction.ID, model.LoyaltyEntryEarn,
+			transaction.PointsEarned, expiresAt, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Batch insert transaction details
 	if len(transaction.Details) > 0 {
 		valueStrings := make([]string, len(transaction.Details))
-		args := make([]interface{}, 0, len(transaction.Details)*4)
+		args := make([]interface{}, 0, len(transaction.Details)*10)
 
 		for i := range transaction.Details {
 			transaction.Details[i].TransactionID = transaction.ID
 			detail := &transaction.Details[i]
-			offset := i * 4
-			valueStrings[i] = fmt.Sprintf("($%d, $%d, $%d, $%d)", offset+1, offset+2, offset+3, offset+4)
-			args = append(args, detail.TransactionID, detail.ProductID, detail.Quantity, detail.Subtotal)
+			offset := i * 10
+			valueStrings[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+				offset+1, offset+2, offset+3, offset+4, offset+5, offset+6, offset+7, offset+8, offset+9, offset+10)
+			args = append(args, detail.TransactionID, detail.ProductID, detail.VariantID, detail.VariantName,
+				detail.Quantity, detail.UnitPrice.Amount, detail.Subtotal.Amount,
+				detail.DiscountAmount.Amount, detail.PromotionID, detail.TaxAmount.Amount)
 		}
 
 		detailQuery := fmt.Sprintf(`
-			INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal)
+			INSERT INTO transaction_details
+				(transaction_id, product_id, variant_id, variant_name, quantity, unit_price, subtotal,
+				discount_amount, promotion_id, tax_amount)
 			VALUES %s
 			RETURNING id
 		`, strings.Join(valueStrings, ", "))
@@ -77,31 +183,183 @@ func (r *TransactionRepository) Create(
 			}
 			i++
 		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
 
-		// Batch deduct stock from products
-		stockUpdateStrings := make([]string, len(transaction.Details))
-		stockArgs := make([]interface{}, 0, len(transaction.Details)*2)
+		// Batch insert the modifiers picked per line
+		modifierStrings := make([]string, 0)
+		modifierArgs := make([]interface{}, 0)
+		for i := range transaction.Details {
+			detail := &transaction.Details[i]
+			for j := range detail.Modifiers {
+				detail.Modifiers[j].TransactionDetailID = detail.ID
+				modifier := &detail.Modifiers[j]
+				offset := len(modifierArgs)
+				modifierStrings = append(modifierStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)",
+					offset+1, offset+2, offset+3, offset+4, offset+5))
+				modifierArgs = append(modifierArgs, modifier.TransactionDetailID, modifier.ModifierID,
+					modifier.GroupName, modifier.Name, modifier.PriceDelta.Amount)
+			}
+		}
+
+		if len(modifierStrings) > 0 {
+			modifierQuery := fmt.Sprintf(`
+				INSERT INTO transaction_detail_modifiers
+					(transaction_detail_id, modifier_id, group_name, name, price_delta)
+				VALUES %s
+				RETURNING id
+			`, strings.Join(modifierStrings, ", "))
+
+			modifierRows, err := tx.Query(ctx, modifierQuery, modifierArgs...)
+			if err != nil {
+				return nil, err
+			}
+			defer modifierRows.Close()
 
-		for i, detail := range transaction.Details {
-			offset := i * 2
-			stockUpdateStrings[i] = fmt.Sprintf("($%d::int, $%d::int)", offset+1, offset+2)
-			stockArgs = append(stockArgs, detail.ProductID, detail.Quantity)
+			ids := make([]int, 0, len(modifierStrings))
+			for modifierRows.Next() {
+				var id int
+				if err := modifierRows.Scan(&id); err != nil {
+					return nil, err
+				}
+				ids = append(ids, id)
+			}
+			if err := modifierRows.Err(); err != nil {
+				return nil, err
+			}
+
+			k := 0
+			for i := range transaction.Details {
+				for j := range transaction.Details[i].Modifiers {
+					transaction.Details[i].Modifiers[j].ID = ids[k]
+					k++
+				}
+			}
+		}
+
+		// Batch insert the taxes charged per line
+		taxStrings := make([]string, 0)
+		taxArgs := make([]interface{}, 0)
+		for i := range transaction.Details {
+			detail := &transaction.Details[i]
+			for j := range detail.Taxes {
+				detail.Taxes[j].TransactionDetailID = detail.ID
+				tax := &detail.Taxes[j]
+				offset := len(taxArgs)
+				taxStrings = append(taxStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+					offset+1, offset+2, offset+3, offset+4, offset+5, offset+6, offset+7))
+				taxArgs = append(taxArgs, tax.TransactionDetailID, tax.TaxRateID, tax.Name, tax.RateBps,
+					tax.Inclusive, tax.TaxableAmount.Amount, tax.TaxAmount.Amount)
+			}
 		}
 
-		stockQuery := fmt.Sprintf(`
-			UPDATE products AS p
-			SET stock = p.stock - v.quantity
-			FROM (VALUES %s) AS v(product_id, quantity)
-			WHERE p.id = v.product_id AND p.stock >= v.quantity
-		`, strings.Join(stockUpdateStrings, ", "))
+		if len(taxStrings) > 0 {
+			taxQuery := fmt.Sprintf(`
+				INSERT INTO transaction_detail_taxes
+					(transaction_detail_id, tax_rate_id, name, rate_bps, inclusive, taxable_amount, tax_amount)
+				VALUES %s
+				RETURNING id
+			`, strings.Join(taxStrings, ", "))
 
-		result, err := tx.Exec(ctx, stockQuery, stockArgs...)
+			taxRows, err := tx.Query(ctx, taxQuery, taxArgs...)
+			if err != nil {
+				return nil, err
+			}
+			defer taxRows.Close()
+
+			ids := make([]int, 0, len(taxStrings))
+			for taxRows.Next() {
+				var id int
+				if err := taxRows.Scan(&id); err != nil {
+					return nil, err
+				}
+				ids = append(ids, id)
+			}
+			if err := taxRows.Err(); err != nil {
+				return nil, err
+			}
+
+			k := 0
+			for i := range transaction.Details {
+				for j := range transaction.Details[i].Taxes {
+					transaction.Details[i].Taxes[j].ID = ids[k]
+					k++
+				}
+			}
+		}
+
+		// Deduct stock from the variant sold, or from the product when it has
+		// no variants, out of the store the sale happened in. Modifiers are
+		// not stocked per store.
+		productQuantities := make(map[int]int)
+		variantQuantities := make(map[int]int)
+		modifierQuantities := make(map[int]int)
+		for _, detail := range transaction.Details {
+			if detail.VariantID != nil {
+				variantQuantities[*detail.VariantID] += detail.Quantity
+			} else {
+				productQuantities[detail.ProductID] += detail.Quantity
+			}
+			for _, modifier := range detail.Modifiers {
+				if modifier.ModifierID != nil {
+					modifierQuantities[*modifier.ModifierID] += detail.Quantity
+				}
+			}
+		}
+
+		for _, stock := range []struct {
+			column     string
+			quantities map[int]int
+		}{
+			{"product_id", productQuantities},
+			{"variant_id", variantQuantities},
+		} {
+			if err := deductStoreStock(ctx, tx, transaction.StoreID, stock.column, stock.quantities); err != nil {
+				return nil, err
+			}
+		}
+		if err := deductStock(ctx, tx, "modifiers", modifierQuantities); err != nil {
+			return nil, err
+		}
+	}
+
+	// Batch insert payments
+	if len(transaction.Payments) > 0 {
+		valueStrings := make([]string, len(transaction.Payments))
+		args := make([]interface{}, 0, len(transaction.Payments)*6)
+
+		for i := range transaction.Payments {
+			transaction.Payments[i].TransactionID = transaction.ID
+			payment := &transaction.Payments[i]
+			offset := i * 6
+			valueStrings[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)",
+				offset+1, offset+2, offset+3, offset+4, offset+5, offset+6)
+			args = append(args, payment.TransactionID, payment.Method, payment.Amount.Amount,
+				payment.TenderedAmount.Amount, payment.ChangeAmount.Amount, payment.Reference)
+		}
+
+		paymentQuery := fmt.Sprintf(`
+			INSERT INTO payments (transaction_id, method, amount, tendered_amount, change_amount, reference)
+			VALUES %s
+			RETURNING id
+		`, strings.Join(valueStrings, ", "))
+
+		rows, err := tx.Query(ctx, paymentQuery, args...)
 		if err != nil {
 			return nil, err
 		}
+		defer rows.Close()
 
-		if result.RowsAffected() != int64(len(transaction.Details)) {
-			return nil, fmt.Errorf("insufficient stock: concurrent modification detected")
+		i := 0
+		for rows.Next() {
+			if err := rows.Scan(&transaction.Payments[i].ID); err != nil {
+				return nil, err
+			}
+			i++
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
 		}
 	}
 
@@ -112,19 +370,116 @@ func (r *TransactionRepository) Create(
 	return transaction, nil
 }
 
+// deductStock subtracts the quantities from the stock column of table in one
+// statement. Rows are matched in id order to keep lock order stable between
+// concurrent checkouts; a NULL stock is left untracked.
+func deductStock(ctx context.Context, tx pgx.Tx, table string, quantities map[int]int) error {
+	if len(quantities) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	valueStrings := make([]string, len(ids))
+	args := make([]interface{}, 0, len(ids)*2)
+	for i, id := range ids {
+		offset := i * 2
+		valueStrings[i] = fmt.Sprintf("($%d::int, $%d::int)", offset+1, offset+2)
+		args = append(args, id, quantities[id])
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE %s AS s
+		SET stock = s.stock - v.quantity
+		FROM (VALUES %s) AS v(id, quantity)
+		WHERE s.id = v.id AND (s.stock IS NULL OR s.stock >= v.quantity)
+	`, table, strings.Join(valueStrings, ", "))
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() != int64(len(ids)) {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// deductStoreStock subtracts the quantities from the stock a store holds,
+// keyed by product_id for products sold without a variant or by variant_id.
+// Stock reserved for orders and carts cannot be sold; a store without a
+// stock row for an item has none of it. Products sold directly are bumped
+// to a new version as their total stock changed.
+func deductStoreStock(ctx context.Context, tx pgx.Tx, storeID int, column string, quantities map[int]int) error {
+	if len(quantities) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	valueStrings := make([]string, len(ids))
+	args := make([]interface{}, 0, len(ids)*2+1)
+	args = append(args, storeID)
+	for i, id := range ids {
+		offset := i*2 + 1
+		valueStrings[i] = fmt.Sprintf("($%d::int, $%d::int)", offset+1, offset+2)
+		args = append(args, id, quantities[id])
+	}
+
+	match := "s.variant_id = v.id"
+	if column == "product_id" {
+		match = "s.variant_id IS NULL AND s.product_id = v.id"
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE store_stocks AS s
+		SET stock = s.stock - v.quantity
+		FROM (VALUES %s) AS v(id, quantity)
+		WHERE s.store_id = $1 AND %s AND s.stock - s.reserved >= v.quantity
+	`, strings.Join(valueStrings, ", "), match)
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() != int64(len(ids)) {
+		return ErrInsufficientStock
+	}
+
+	if column == "product_id" {
+		// Selling changes the stock an admin may be editing at the same time
+		_, err = tx.Exec(ctx, `UPDATE products SET version = version + 1 WHERE id = ANY($1)`, ids)
+	}
+	return err
+}
+
 func (r *TransactionRepository) FindAll(
 	ctx context.Context,
+	filter TransactionFilter,
 ) ([]model.TransactionModel, error) {
 	const query = `
 		SELECT 
-			t.id, t.total_amount, t.created_at,
-			td.id, td.product_id, p.name, td.quantity, td.subtotal
+			t.id, t.currency, t.subtotal_amount, t.discount_amount, t.tax_amount, t.total_amount,
+			t.promotion_id, t.coupon_id, t.shift_id, t.store_id, t.customer_id, t.points_earned,
+			t.points_redeemed, t.order_id, t.cart_id, t.created_at,
+			td.id, td.product_id, p.name, td.variant_id, td.variant_name, td.quantity, td.unit_price, td.subtotal,
+			td.discount_amount, td.promotion_id, td.tax_amount
 		FROM transactions t
 		LEFT JOIN transaction_details td ON td.transaction_id = t.id
 		LEFT JOIN products p ON p.id = td.product_id
+		WHERE ($1::int IS NULL OR t.store_id = $1) AND ($2::int IS NULL OR t.customer_id = $2)
 		ORDER BY t.created_at DESC, td.id ASC
 	`
-	rows, err := r.dbPool.Query(ctx, query)
+	rows, err := r.dbPool.Query(ctx, query, filter.StoreID, filter.CustomerID)
 	if err != nil {
 		return nil, err
 	}
@@ -135,17 +490,39 @@ func (r *TransactionRepository) FindAll(
 
 	for rows.Next() {
 		var txID int
-		var totalAmount int
+		var currency string
+		var subtotalAmount int64
+		var discountAmount int64
+		var taxAmount int64
+		var totalAmount int64
+		var promotionID *int
+		var couponID *int
+		var shiftID *int
+		var storeID int
+		var customerID *int
+		var pointsEarned int
+		var pointsRedeemed int
+		var orderID *int
+		var cartID *int
 		var createdAt time.Time
 		var detailID *int
 		var productID *int
 		var productName *string
+		var variantID *int
+		var variantName *string
 		var quantity *int
-		var subtotal *int
+		var unitPrice *int64
+		var subtotal *int64
+		var detailDiscount *int64
+		var detailPromotionID *int
+		var detailTax *int64
 
 		err = rows.Scan(
-			&txID, &totalAmount, &createdAt,
-			&detailID, &productID, &productName, &quantity, &subtotal,
+			&txID, &currency, &subtotalAmount, &discountAmount, &taxAmount, &totalAmount,
+			&promotionID, &couponID, &shiftID, &storeID, &customerID, &pointsEarned,
+			&pointsRedeemed, &orderID, &cartID, &createdAt,
+			&detailID, &productID, &productName, &variantID, &variantName, &quantity, &unitPrice, &subtotal,
+			&detailDiscount, &detailPromotionID, &detailTax,
 		)
 		if err != nil {
 			return nil, err
@@ -153,26 +530,62 @@ func (r *TransactionRepository) FindAll(
 
 		if _, exists := txMap[txID]; !exists {
 			txMap[txID] = &model.TransactionModel{
-				ID:          txID,
-				TotalAmount: totalAmount,
-				CreatedAt:   createdAt,
-				Details:     []model.TransactionDetailModel{},
+				ID:             txID,
+				Currency:       currency,
+				SubtotalAmount: money.New(subtotalAmount, currency),
+				DiscountAmount: money.New(discountAmount, currency),
+				TaxAmount:      money.New(taxAmount, currency),
+				TotalAmount:    money.New(totalAmount, currency),
+				PromotionID:    promotionID,
+				CouponID:       couponID,
+				ShiftID:        shiftID,
+				StoreID:        storeID,
+				CustomerID:     customerID,
+				PointsEarned:   pointsEarned,
+				PointsRedeemed: pointsRedeemed,
+				OrderID:        orderID,
+				CartID:         cartID,
+				CreatedAt:      createdAt,
+				Details:        []model.TransactionDetailModel{},
+				Payments:       []model.PaymentModel{},
 			}
 			txOrder = append(txOrder, txID)
 		}
 
 		if detailID != nil {
 			txMap[txID].Details = append(txMap[txID].Details, model.TransactionDetailModel{
-				ID:            *detailID,
-				TransactionID: txID,
-				ProductID:     *productID,
-				ProductName:   productName,
-				Quantity:      *quantity,
-				Subtotal:      *subtotal,
+				ID:             *detailID,
+				TransactionID:  txID,
+				ProductID:      *productID,
+				ProductName:    productName,
+				VariantID:      variantID,
+				VariantName:    variantName,
+				Quantity:       *quantity,
+				UnitPrice:      money.New(*unitPrice, currency),
+				Subtotal:       money.New(*subtotal, currency),
+				DiscountAmount: money.New(*detailDiscount, currency),
+				PromotionID:    detailPromotionID,
+				TaxAmount:      money.New(*detailTax, currency),
+				Modifiers:      []model.TransactionDetailModifierModel{},
+				Taxes:          []model.TransactionDetailTaxModel{},
 			})
 		}
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachPayments(ctx, txMap, txOrder); err != nil {
+		return nil, err
+	}
+	if err := r.attachDetailModifiers(ctx, txMap, txOrder); err != nil {
+		return nil, err
+	}
+	if err := r.attachDetailTaxes(ctx, txMap, txOrder); err != nil {
+		return nil, err
+	}
+
 	transactions := make([]model.TransactionModel, 0, len(txOrder))
 	for _, id := range txOrder {
 		transactions = append(transactions, *txMap[id])
@@ -180,3 +593,131 @@ func (r *TransactionRepository) FindAll(
 
 	return transactions, nil
 }
+
+func (r *TransactionRepository) attachPayments(
+	ctx context.Context,
+	txMap map[int]*model.TransactionModel,
+	txIDs []int,
+) error {
+	if len(txIDs) == 0 {
+		return nil
+	}
+
+	const query = `
+		SELECT id, transaction_id, method, amount, tendered_amount, change_amount, reference
+		FROM payments
+		WHERE transaction_id = ANY($1)
+		ORDER BY id
+	`
+	rows, err := r.dbPool.Query(ctx, query, txIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p model.PaymentModel
+		var amount, tendered, change int64
+		err := rows.Scan(&p.ID, &p.TransactionID, &p.Method, &amount,
+			&tendered, &change, &p.Reference)
+		if err != nil {
+			return err
+		}
+		transaction := txMap[p.TransactionID]
+		p.Amount = money.New(amount, transaction.Currency)
+		p.TenderedAmount = money.New(tendered, transaction.Currency)
+		p.ChangeAmount = money.New(change, transaction.Currency)
+		transaction.Payments = append(transaction.Payments, p)
+	}
+	return rows.Err()
+}
+
+func (r *TransactionRepository) attachDetailModifiers(
+	ctx context.Context,
+	txMap map[int]*model.TransactionModel,
+	txIDs []int,
+) error {
+	if len(txIDs) == 0 {
+		return nil
+	}
+
+	const query = `
+		SELECT dm.id, td.transaction_id, dm.transaction_detail_id, dm.modifier_id, dm.group_name, dm.name, dm.price_delta
+		FROM transaction_detail_modifiers dm
+		JOIN transaction_details td ON td.id = dm.transaction_detail_id
+		WHERE td.transaction_id = ANY($1)
+		ORDER BY dm.id
+	`
+	rows, err := r.dbPool.Query(ctx, query, txIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var txID int
+		var priceDelta int64
+		var m model.TransactionDetailModifierModel
+		err := rows.Scan(&m.ID, &txID, &m.TransactionDetailID, &m.ModifierID, &m.GroupName, &m.Name, &priceDelta)
+		if err != nil {
+			return err
+		}
+		transaction := txMap[txID]
+		m.PriceDelta = money.New(priceDelta, transaction.Currency)
+		details := transaction.Details
+		for i := range details {
+			if details[i].ID == m.TransactionDetailID {
+				details[i].Modifiers = append(details[i].Modifiers, m)
+				break
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func (r *TransactionRepository) attachDetailTaxes(
+	ctx context.Context,
+	txMap map[int]*model.TransactionModel,
+	txIDs []int,
+) error {
+	if len(txIDs) == 0 {
+		return nil
+	}
+
+	const query = `
+		SELECT
+			dt.id, td.transaction_id, dt.transaction_detail_id, dt.tax_rate_id, dt.name,
+			dt.rate_bps, dt.inclusive, dt.taxable_amount, dt.tax_amount
+		FROM transaction_detail_taxes dt
+		JOIN transaction_details td ON td.id = dt.transaction_detail_id
+		WHERE td.transaction_id = ANY($1)
+		ORDER BY dt.id
+	`
+	rows, err := r.dbPool.Query(ctx, query, txIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var txID int
+		var taxable, taxAmount int64
+		var t model.TransactionDetailTaxModel
+		err := rows.Scan(&t.ID, &txID, &t.TransactionDetailID, &t.TaxRateID, &t.Name,
+			&t.RateBps, &t.Inclusive, &taxable, &taxAmount)
+		if err != nil {
+			return err
+		}
+		transaction := txMap[txID]
+		t.TaxableAmount = money.New(taxable, transaction.Currency)
+		t.TaxAmount = money.New(taxAmount, transaction.Currency)
+		details := transaction.Details
+		for i := range details {
+			if details[i].ID == t.TransactionDetailID {
+				details[i].Taxes = append(details[i].Taxes, t)
+				break
+			}
+		}
+	}
+	return rows.Err()
+}
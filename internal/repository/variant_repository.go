@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrVariantSKUTaken   = errors.New("variant sku is already taken")
+	ErrVariantInTransfer = errors.New("variant is part of a stock transfer and cannot be deleted")
+)
+
+// Variants are priced in the currency of their product.
+const variantSelectQuery = `
+	SELECT
+		v.id, v.product_id, v.name, v.sku, v.price, p.currency, v.stock,
+		(SELECT COALESCE(SUM(ss.reserved), 0)::int FROM store_stocks ss WHERE ss.variant_id = v.id),
+		v.created_at
+	FROM product_variants v
+	JOIN products p ON p.id = v.product_id
+`
+
+type VariantRepository struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewVariantRepository(dbPool *pgxpool.Pool) *VariantRepository {
+	return &VariantRepository{
+		dbPool: dbPool,
+	}
+}
+
+func scanVariant(row pgx.Row, v *model.ProductVariantModel) error {
+	err := row.Scan(&v.ID, &v.ProductID, &v.Name, &v.SKU, &v.Price.Amount, &v.Price.Currency, &v.Stock,
+		&v.Reserved, &v.CreatedAt)
+	v.Available = v.Stock - v.Reserved
+	return err
+}
+
+func (r *VariantRepository) FindByProduct(ctx context.Context, productID int) ([]model.ProductVariantModel, error) {
+	rows, err := r.dbPool.Query(ctx, variantSelectQuery+" WHERE v.product_id = $1 ORDER BY v.id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.ProductVariantModel, 0)
+	for rows.Next() {
+		var v model.ProductVariantModel
+		if err := scanVariant(rows, &v); err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *VariantRepository) FindOne(
+	ctx context.Context,
+	productID int,
+	id int,
+) (*model.ProductVariantModel, error) {
+	var v model.ProductVariantModel
+	err := scanVariant(r.dbPool.QueryRow(ctx, variantSelectQuery+" WHERE v.product_id = $1 AND v.id = $2", productID, id), &v)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// FindBySKU matches the SKU case-insensitively.
+func (r *VariantRepository) FindBySKU(
+	ctx context.Context,
+	sku string,
+) (*model.ProductVariantModel, error) {
+	var v model.ProductVariantModel
+	err := scanVariant(r.dbPool.QueryRow(ctx, variantSelectQuery+" WHERE UPPER(v.sku) = UPPER($1)", sku), &v)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *VariantRepository) Create(
+	ctx context.Context,
+	v *model.ProductVariantModel,
+) (*model.ProductVariantModel, error) {
+	const query = `
+		INSERT INTO product_variants (product_id, name, sku, price, stock)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	out := *v
+	err := r.dbPool.QueryRow(ctx, query, v.ProductID, v.Name, v.SKU, v.Price.Amount, v.Stock).
+		Scan(&out.ID, &out.CreatedAt)
+	if err != nil {
+		return nil, variantWriteError(err)
+	}
+	out.Available = out.Stock
+	return &out, nil
+}
+
+func (r *VariantRepository) Update(
+	ctx context.Context,
+	v *model.ProductVariantModel,
+) (bool, error) {
+	const query = `
+		UPDATE product_variants
+		SET name = $1, sku = $2, price = $3, stock = $4
+		WHERE product_id = $5 AND id = $6
+	`
+	cmdTag, err := r.dbPool.Exec(ctx, query, v.Name, v.SKU, v.Price.Amount, v.Stock, v.ProductID, v.ID)
+	if err != nil {
+		return false, variantWriteError(err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *VariantRepository) Delete(
+	ctx context.Context,
+	productID int,
+	id int,
+) (bool, error) {
+	const query = `
+		DELETE FROM product_variants
+		WHERE product_id = $1 AND id = $2
+	`
+	cmdTag, err := r.dbPool.Exec(ctx, query, productID, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" && pgErr.ConstraintName == "stock_transfer_items_variant_id_fkey" {
+			return false, ErrVariantInTransfer
+		}
+		return false, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func variantWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505":
+			return ErrVariantSKUTaken
+		case pgErr.Code == "23514" && pgErr.ConstraintName == "store_stocks_stock_check":
+			return ErrStockHeldByStores
+		case pgErr.Code == "23514" && pgErr.ConstraintName == "store_stocks_reserved_stock_check":
+			return ErrStockReserved
+		}
+	}
+	return err
+}
@@ -0,0 +1,10 @@
+package request
+
+type ProductBarcodeRequest struct {
+	Barcodes []BarcodeRequest `json:"barcodes"`
+}
+
+type BarcodeRequest struct {
+	Barcode   string `json:"barcode"`
+	VariantID *int   `json:"variant_id"`
+}
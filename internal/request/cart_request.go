@@ -0,0 +1,21 @@
+package request
+
+// CartRequest opens a cart in the store given by StoreID, or in the default
+// store when it is omitted. Items are picked by id or barcode like checkout
+// items; their modifiers do not matter for the stock they reserve.
+type CartRequest struct {
+	StoreID *int       `json:"store_id"`
+	Items   []CartItem `json:"items"`
+}
+
+type CartItem struct {
+	ProductID int    `json:"product_id"`
+	Barcode   string `json:"barcode"`
+	VariantID *int   `json:"variant_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// CartItemsRequest replaces the items of a cart.
+type CartItemsRequest struct {
+	Items []CartItem `json:"items"`
+}
@@ -3,4 +3,19 @@ package request
 type CategoryRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	ParentID    *int   `json:"parent_id"`
+}
+
+// MoveCategoryRequest moves a category below another one, or to the top
+// level when parent_id is null.
+type MoveCategoryRequest struct {
+	ParentID *int `json:"parent_id"`
+}
+
+// CategoryPatchRequest is a JSON Merge Patch of a category. ParentID is only
+// read to reject it: moves go through the move endpoint.
+type CategoryPatchRequest struct {
+	Name        Optional[string] `json:"name"`
+	Description Optional[string] `json:"description"`
+	ParentID    Optional[int]    `json:"parent_id"`
 }
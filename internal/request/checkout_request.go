@@ -1,10 +1,32 @@
 package request
 
+import "github.com/illusi03/golearn/internal/money"
+
+// CheckoutItem references a product by product_id or by a scanned barcode.
 type CheckoutItem struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID   int    `json:"product_id"`
+	Barcode     string `json:"barcode"`
+	VariantID   *int   `json:"variant_id"`
+	ModifierIDs []int  `json:"modifier_ids"`
+	Quantity    int    `json:"quantity"`
+}
+
+type CheckoutPayment struct {
+	Method         string      `json:"method"`
+	Amount         money.Money `json:"amount"`
+	TenderedAmount money.Money `json:"tendered_amount"`
+	Reference      string      `json:"reference"`
 }
 
+// CheckoutRequest sells from the store given by StoreID, or from the default
+// store when it is omitted. CustomerID optionally records who bought.
+// CartID checks out a cart of the store; the stock it reserved counts as
+// available to the sale.
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	StoreID    *int              `json:"store_id"`
+	CustomerID *int              `json:"customer_id"`
+	Items      []CheckoutItem    `json:"items"`
+	Payments   []CheckoutPayment `json:"payments"`
+	CouponCode string            `json:"coupon_code"`
+	CartID     *int              `json:"cart_id"`
 }
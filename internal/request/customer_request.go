@@ -0,0 +1,7 @@
+package request
+
+type CustomerRequest struct {
+	Name  string  `json:"name"`
+	Phone *string `json:"phone"`
+	Email *string `json:"email"`
+}
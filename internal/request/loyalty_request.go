@@ -0,0 +1,29 @@
+package request
+
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
+// LoyaltyProgramRequest sets the program of the currency in the URL; both
+// amounts must be in that currency.
+type LoyaltyProgramRequest struct {
+	EarnAmount money.Money `json:"earn_amount"`
+	EarnPoints int         `json:"earn_points"`
+	PointValue money.Money `json:"point_value"`
+	ExpiryDays *int        `json:"expiry_days"`
+	Active     *bool       `json:"active"`
+}
+
+type LoyaltyMultiplierRequest struct {
+	MultiplierBps int `json:"multiplier_bps"`
+}
+
+// LoyaltyAdjustRequest credits points to a customer, or takes them away when
+// Points is negative. Credited points never expire unless ExpiresAt is set.
+type LoyaltyAdjustRequest struct {
+	Points    int        `json:"points"`
+	Note      string     `json:"note"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
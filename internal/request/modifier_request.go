@@ -0,0 +1,20 @@
+package request
+
+import "github.com/illusi03/golearn/internal/money"
+
+// ModifierGroupRequest replaces the modifiers of a group: entries with an id
+// update that modifier, entries without one are added and modifiers left out
+// are removed.
+type ModifierGroupRequest struct {
+	Name      string            `json:"name"`
+	MinSelect int               `json:"min_select"`
+	MaxSelect *int              `json:"max_select"`
+	Modifiers []ModifierRequest `json:"modifiers"`
+}
+
+type ModifierRequest struct {
+	ID         int         `json:"id"`
+	Name       string      `json:"name"`
+	PriceDelta money.Money `json:"price_delta"`
+	Stock      *int        `json:"stock"`
+}
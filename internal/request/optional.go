@@ -0,0 +1,32 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Optional is a field of a JSON Merge Patch (RFC 7396). Set reports whether
+// the field was present at all, Null whether it was sent as null, which
+// clears the field.
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// Ptr returns nil for a null field and a pointer to the value otherwise.
+func (o Optional[T]) Ptr() *T {
+	if o.Null {
+		return nil
+	}
+	return &o.Value
+}
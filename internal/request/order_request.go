@@ -0,0 +1,38 @@
+package request
+
+// OrderRequest opens an order in the store given by StoreID, or in the
+// default store when it is omitted. Items are picked like checkout items.
+type OrderRequest struct {
+	StoreID      *int           `json:"store_id"`
+	CustomerID   *int           `json:"customer_id"`
+	Label        string         `json:"label"`
+	Note         string         `json:"note"`
+	ReserveStock bool           `json:"reserve_stock"`
+	Items        []CheckoutItem `json:"items"`
+}
+
+// OrderUpdateRequest edits the details of an order, not its items.
+type OrderUpdateRequest struct {
+	CustomerID   *int   `json:"customer_id"`
+	Label        string `json:"label"`
+	Note         string `json:"note"`
+	ReserveStock bool   `json:"reserve_stock"`
+}
+
+// OrderItemsRequest adds items to an order. Items matching a line already
+// on the order add to its quantity.
+type OrderItemsRequest struct {
+	Items []CheckoutItem `json:"items"`
+}
+
+type OrderItemQuantityRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+// SettleOrderRequest pays for an order. Version is the order version the
+// cashier last saw; the settlement is refused when the order changed since.
+type SettleOrderRequest struct {
+	Version    int               `json:"version"`
+	Payments   []CheckoutPayment `json:"payments"`
+	CouponCode string            `json:"coupon_code"`
+}
@@ -0,0 +1,16 @@
+package request
+
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
+// PriceScheduleRequest schedules a new price for a product, or for one of its
+// variants when VariantID is set. The price must be in the product currency.
+type PriceScheduleRequest struct {
+	VariantID   *int        `json:"variant_id"`
+	Price       money.Money `json:"price"`
+	EffectiveAt time.Time   `json:"effective_at"`
+	Note        string      `json:"note"`
+}
@@ -0,0 +1,27 @@
+package request
+
+type ProductBatchRequest struct {
+	Operations []ProductBatchOperation `json:"operations"`
+}
+
+// ProductBatchOperation is one operation of a batch. Which fields are read
+// depends on Op:
+//   - create: product
+//   - update: id, version and patch, a JSON Merge Patch of the product
+//   - delete: id and version
+//   - reprice_category: category_id, percent and include_descendants
+//
+// Update and delete require the version of the product they change, like
+// If-Match on the single product endpoints; an outdated version fails the
+// operation.
+type ProductBatchOperation struct {
+	Op      string              `json:"op"`
+	ID      int                 `json:"id"`
+	Version int                 `json:"version"`
+	Product ProductRequest      `json:"product"`
+	Patch   ProductPatchRequest `json:"patch"`
+
+	CategoryID         int     `json:"category_id"`
+	Percent            float64 `json:"percent"`
+	IncludeDescendants bool    `json:"include_descendants"`
+}
@@ -1,9 +1,58 @@
 package request
 
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
 type ProductRequest struct {
-	Name        string `json:"name"`
-	Price       int    `json:"price"`
-	Stock       int    `json:"stock"`
-	Description string `json:"description"`
-	CategoryId  int    `json:"category_id"`
+	Name        string      `json:"name"`
+	SKU         *string     `json:"sku"`
+	Price       money.Money `json:"price"`
+	Stock       int         `json:"stock"`
+	Description string      `json:"description"`
+	CategoryId  int         `json:"category_id"`
+}
+
+// ProductReplaceRequest is the body of a product PUT, which replaces the
+// whole product. Name, price and stock must be sent so a partial body cannot
+// zero them; sku and category_id left out or null are cleared. Partial
+// updates go through PATCH.
+type ProductReplaceRequest struct {
+	Name        *string      `json:"name"`
+	SKU         *string      `json:"sku"`
+	Price       *money.Money `json:"price"`
+	Stock       *int         `json:"stock"`
+	Description string       `json:"description"`
+	CategoryID  *int         `json:"category_id"`
+}
+
+// ProductPatchRequest is a JSON Merge Patch of a product: fields left out
+// keep their value and null clears the nullable ones (sku, category_id).
+type ProductPatchRequest struct {
+	Name        Optional[string]     `json:"name"`
+	SKU         Optional[string]     `json:"sku"`
+	Price       Optional[MoneyPatch] `json:"price"`
+	Stock       Optional[int]        `json:"stock"`
+	Description Optional[string]     `json:"description"`
+	CategoryID  Optional[int]        `json:"category_id"`
+}
+
+// MoneyPatch patches an amount and/or its currency. A bare integer patches
+// the amount only, keeping the current currency.
+type MoneyPatch struct {
+	Amount   Optional[int64]  `json:"amount"`
+	Currency Optional[string] `json:"currency"`
+}
+
+func (m *MoneyPatch) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] != '{' {
+		m.Amount.Set = true
+		return json.Unmarshal(trimmed, &m.Amount.Value)
+	}
+	type plain MoneyPatch
+	return json.Unmarshal(trimmed, (*plain)(m))
 }
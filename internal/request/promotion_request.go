@@ -0,0 +1,36 @@
+package request
+
+import (
+	"time"
+
+	"github.com/illusi03/golearn/internal/money"
+)
+
+type PromotionRequest struct {
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	Type           string      `json:"type"`
+	Scope          string      `json:"scope"`
+	Value          int         `json:"value"`
+	Amount         money.Money `json:"amount"`
+	BuyQuantity    int         `json:"buy_quantity"`
+	GetQuantity    int         `json:"get_quantity"`
+	ProductID      *int        `json:"product_id"`
+	CategoryID     *int        `json:"category_id"`
+	MinSubtotal    money.Money `json:"min_subtotal"`
+	RequiresCoupon bool        `json:"requires_coupon"`
+	StartsAt       *time.Time  `json:"starts_at"`
+	EndsAt         *time.Time  `json:"ends_at"`
+	DailyStartTime *string     `json:"daily_start_time"`
+	DailyEndTime   *string     `json:"daily_end_time"`
+	Active         *bool       `json:"active"`
+}
+
+type CouponRequest struct {
+	Code        string     `json:"code"`
+	PromotionID int        `json:"promotion_id"`
+	UsageLimit  *int       `json:"usage_limit"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	Active      *bool      `json:"active"`
+}
@@ -0,0 +1,21 @@
+package request
+
+import "github.com/illusi03/golearn/internal/money"
+
+// OpenShiftRequest opens a shift in the store given by StoreID, or in the
+// default store when it is omitted.
+type OpenShiftRequest struct {
+	StoreID      *int        `json:"store_id"`
+	OpeningFloat money.Money `json:"opening_float"`
+	Note         string      `json:"note"`
+}
+
+type UpdateShiftRequest struct {
+	OpeningFloat money.Money `json:"opening_float"`
+	Note         string      `json:"note"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash money.Money `json:"counted_cash"`
+	Note        string      `json:"note"`
+}
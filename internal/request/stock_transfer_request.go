@@ -0,0 +1,14 @@
+package request
+
+type StockTransferItem struct {
+	ProductID int  `json:"product_id"`
+	VariantID *int `json:"variant_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+type StockTransferRequest struct {
+	SourceStoreID      int                 `json:"source_store_id"`
+	DestinationStoreID int                 `json:"destination_store_id"`
+	Note               string              `json:"note"`
+	Items              []StockTransferItem `json:"items"`
+}
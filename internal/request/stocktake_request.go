@@ -0,0 +1,27 @@
+package request
+
+type StocktakeRequest struct {
+	StoreID *int   `json:"store_id"`
+	Note    string `json:"note"`
+}
+
+type StocktakeCountItem struct {
+	ProductID int    `json:"product_id"`
+	VariantID *int   `json:"variant_id"`
+	Barcode   string `json:"barcode"`
+	Quantity  int    `json:"quantity"`
+}
+
+// StocktakeCountRequest submits counted quantities. With mode "add", the
+// default, they are added to what other devices counted before; "set"
+// replaces the count.
+type StocktakeCountRequest struct {
+	Mode  string               `json:"mode"`
+	Items []StocktakeCountItem `json:"items"`
+}
+
+// FinalizeStocktakeRequest finalizes a stocktake. Items nobody counted keep
+// their stock unless ZeroUncounted is set.
+type FinalizeStocktakeRequest struct {
+	ZeroUncounted bool `json:"zero_uncounted"`
+}
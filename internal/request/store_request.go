@@ -0,0 +1,20 @@
+package request
+
+type StoreRequest struct {
+	Name      string `json:"name"`
+	Code      string `json:"code"`
+	Address   string `json:"address"`
+	IsDefault bool   `json:"is_default"`
+}
+
+type StoreStockItem struct {
+	ProductID int  `json:"product_id"`
+	VariantID *int `json:"variant_id"`
+	Stock     int  `json:"stock"`
+}
+
+// SetStoreStockRequest sets the stock levels of a store. Items that are not
+// listed keep their current stock.
+type SetStoreStockRequest struct {
+	Items []StoreStockItem `json:"items"`
+}
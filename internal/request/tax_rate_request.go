@@ -0,0 +1,14 @@
+package request
+
+type TaxRateRequest struct {
+	Name      string `json:"name"`
+	Code      string `json:"code"`
+	RateBps   int    `json:"rate_bps"`
+	Inclusive bool   `json:"inclusive"`
+	Compound  bool   `json:"compound"`
+	Active    *bool  `json:"active"`
+}
+
+type TaxRateAssignmentRequest struct {
+	TaxRateIDs []int `json:"tax_rate_ids"`
+}
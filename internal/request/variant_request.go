@@ -0,0 +1,10 @@
+package request
+
+import "github.com/illusi03/golearn/internal/money"
+
+type VariantRequest struct {
+	Name  string      `json:"name"`
+	SKU   *string     `json:"sku"`
+	Price money.Money `json:"price"`
+	Stock int         `json:"stock"`
+}
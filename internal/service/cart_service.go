@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+	"github.com/illusi03/golearn/internal/request"
+)
+
+// cartWorkerBatch bounds how many carts are expired per statement.
+const cartWorkerBatch = 500
+
+type CartService struct {
+	cartRepository     *repository.CartRepository
+	productRepository  *repository.ProductRepository
+	productService     *ProductService
+	storeService       *StoreService
+	transactionService *TransactionService
+	ttl                time.Duration
+}
+
+// NewCartService returns a service whose carts reserve their stock for ttl
+// after every change.
+func NewCartService(
+	cartRepository *repository.CartRepository,
+	productRepository *repository.ProductRepository,
+	productService *ProductService,
+	storeService *StoreService,
+	transactionService *TransactionService,
+	ttl time.Duration,
+) *CartService {
+	return &CartService{
+		cartRepository:     cartRepository,
+		productRepository:  productRepository,
+		productService:     productService,
+		storeService:       storeService,
+		transactionService: transactionService,
+		ttl:                ttl,
+	}
+}
+
+var cartStatuses = []string{
+	model.CartStatusActive,
+	model.CartStatusCheckedOut,
+	model.CartStatusReleased,
+	model.CartStatusExpired,
+}
+
+func (s *CartService) FindAll(ctx context.Context, storeID *int, status string) ([]model.CartModel, error) {
+	if status != "" && !slices.Contains(cartStatuses, status) {
+		return nil, fmt.Errorf("unknown cart status %q", status)
+	}
+	return s.cartRepository.FindAll(ctx, storeID, status)
+}
+
+func (s *CartService) FindOne(ctx context.Context, id int) (*model.CartModel, error) {
+	return s.cartRepository.FindOne(ctx, id)
+}
+
+func (s *CartService) Create(ctx context.Context, req *request.CartRequest) (*model.CartModel, error) {
+	store, err := s.storeService.resolve(ctx, req.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.cartItems(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	cart, err := s.cartRepository.Create(ctx, store.ID, items, s.ttl)
+	if err != nil || cart == nil {
+		return cart, err
+	}
+	s.storeService.publishReserved(cart.StoreID, nil, cartReserved(cart))
+	return cart, nil
+}
+
+// SetItems replaces the items of a cart and renews its reservation. An
+// empty list keeps the cart open without holding any stock. It returns nil
+// when the cart does not exist.
+func (s *CartService) SetItems(ctx context.Context, id int, reqItems []request.CartItem) (*model.CartModel, error) {
+	items, err := s.cartItems(ctx, reqItems)
+	if err != nil {
+		return nil, err
+	}
+	return s.reserving(ctx, id, func() (*model.CartModel, error) {
+		return s.cartRepository.SetItems(ctx, id, items, s.ttl)
+	})
+}
+
+// Extend renews the reservation of a cart. It returns nil when the cart
+// does not exist.
+func (s *CartService) Extend(ctx context.Context, id int) (*model.CartModel, error) {
+	return s.cartRepository.Extend(ctx, id, s.ttl)
+}
+
+// Release gives the stock of a cart back. It returns nil when the cart does
+// not exist.
+func (s *CartService) Release(ctx context.Context, id int) (*model.CartModel, error) {
+	return s.reserving(ctx, id, func() (*model.CartModel, error) {
+		return s.cartRepository.Release(ctx, id)
+	})
+}
+
+// reserving runs a change to a cart and publishes the items whose stock its
+// reservation took or gave back.
+func (s *CartService) reserving(
+	ctx context.Context,
+	id int,
+	change func() (*model.CartModel, error),
+) (*model.CartModel, error) {
+	before, err := s.cartRepository.FindOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	after, err := change()
+	if err != nil || after == nil {
+		return after, err
+	}
+	s.storeService.publishReserved(after.StoreID, cartReserved(before), cartReserved(after))
+	return after, nil
+}
+
+// cartReserved sums the stock an active cart holds back per item.
+func cartReserved(c *model.CartModel) map[reservationKey]int {
+	reserved := make(map[reservationKey]int)
+	if c == nil || c.Status != model.CartStatusActive {
+		return reserved
+	}
+	for _, item := range c.Items {
+		reserved[newReservationKey(item.ProductID, item.VariantID)] += item.Quantity
+	}
+	return reserved
+}
+
+// Run expires carts whose reservation ran out every interval until ctx is
+// done.
+func (s *CartService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.expireDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *CartService) expireDue(ctx context.Context) {
+	for {
+		expired, released, err := s.cartRepository.ExpireDue(ctx, cartWorkerBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to expire carts: %v", err)
+			}
+			return
+		}
+		s.publishReleased(released)
+		if expired > 0 {
+			log.Printf("Expired %d carts", expired)
+		}
+		if expired < cartWorkerBatch {
+			return
+		}
+	}
+}
+
+// publishReleased publishes the stock that expired carts gave back, per
+// store.
+func (s *CartService) publishReleased(released []model.StoreStockModel) {
+	byStore := make(map[int]map[reservationKey]int)
+	for _, r := range released {
+		if byStore[r.StoreID] == nil {
+			byStore[r.StoreID] = make(map[reservationKey]int)
+		}
+		byStore[r.StoreID][newReservationKey(r.ProductID, r.VariantID)] += r.Reserved
+	}
+	for storeID, before := range byStore {
+		s.storeService.publishReserved(storeID, before, nil)
+	}
+}
+
+// cartItems checks the items to put in a cart. Items picked by barcode are
+// resolved to their product and variant.
+func (s *CartService) cartItems(ctx context.Context, reqItems []request.CartItem) ([]model.CartItemModel, error) {
+	items := make([]model.CartItemModel, len(reqItems))
+	for i := range reqItems {
+		item := &reqItems[i]
+		if err := s.productService.resolveBarcode(ctx, item.Barcode, &item.ProductID, &item.VariantID); err != nil {
+			return nil, err
+		}
+		if item.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than 0")
+		}
+		product, err := s.productRepository.FindOne(ctx, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			return nil, fmt.Errorf("product with id %d not found", item.ProductID)
+		}
+		if _, err := s.transactionService.resolveVariant(ctx, product, item.VariantID); err != nil {
+			return nil, err
+		}
+		items[i] = model.CartItemModel{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		}
+	}
+	return items, nil
+}
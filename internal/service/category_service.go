@@ -2,11 +2,18 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"github.com/illusi03/golearn/internal/model"
 	"github.com/illusi03/golearn/internal/repository"
 )
 
+var (
+	ErrUnknownDeleteStrategy  = errors.New("strategy must be one of reject, reassign or nullify")
+	ErrReassignTargetRequired = errors.New("reassign_to is required to reassign products")
+	ErrReassignToSelf         = errors.New("products cannot be reassigned to the category being deleted")
+)
+
 type CategoryService struct {
 	categoryRepository *repository.CategoryRepository
 }
@@ -17,16 +24,32 @@ func NewCategoryService(categoryRepository *repository.CategoryRepository) *Cate
 	}
 }
 
-func (a *CategoryService) FindAll(ctx context.Context) ([]model.CategoryModel, error) {
-	return a.categoryRepository.FindAll(ctx)
+func (a *CategoryService) FindAll(ctx context.Context, includeDeleted bool) ([]model.CategoryModel, error) {
+	return a.categoryRepository.FindAll(ctx, includeDeleted)
 }
 
 func (a *CategoryService) FindOne(ctx context.Context, id int) (*model.CategoryModel, error) {
 	return a.categoryRepository.FindOne(ctx, id)
 }
 
-func (a *CategoryService) Delete(ctx context.Context, id int) (bool, error) {
-	return a.categoryRepository.Delete(ctx, id)
+// Delete removes a category using one of the model.CategoryDelete*
+// strategies; an empty strategy rejects the delete while products use it.
+func (a *CategoryService) Delete(ctx context.Context, id int, strategy string, reassignTo *int, version int) (bool, error) {
+	switch strategy {
+	case "":
+		strategy = model.CategoryDeleteReject
+	case model.CategoryDeleteReject, model.CategoryDeleteNullify:
+	case model.CategoryDeleteReassign:
+		if reassignTo == nil {
+			return false, ErrReassignTargetRequired
+		}
+		if *reassignTo == id {
+			return false, ErrReassignToSelf
+		}
+	default:
+		return false, ErrUnknownDeleteStrategy
+	}
+	return a.categoryRepository.Delete(ctx, id, strategy, reassignTo, version)
 }
 
 func (a *CategoryService) Update(ctx context.Context, model *model.CategoryModel) (bool, error) {
@@ -36,3 +59,76 @@ func (a *CategoryService) Update(ctx context.Context, model *model.CategoryModel
 func (a *CategoryService) Create(ctx context.Context, model *model.CategoryModel) (*model.CategoryModel, error) {
 	return a.categoryRepository.Create(ctx, model)
 }
+
+// FindTree returns the categories nested below their parents, starting from
+// the top-level ones.
+func (a *CategoryService) FindTree(ctx context.Context) ([]model.CategoryTreeModel, error) {
+	list, err := a.categoryRepository.FindAll(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]model.CategoryModel)
+	roots := make([]model.CategoryModel, 0)
+	for _, c := range list {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+		children[*c.ParentID] = append(children[*c.ParentID], c)
+	}
+
+	var build func(nodes []model.CategoryModel, depth int) []model.CategoryTreeModel
+	build = func(nodes []model.CategoryModel, depth int) []model.CategoryTreeModel {
+		tree := make([]model.CategoryTreeModel, len(nodes))
+		for i, c := range nodes {
+			tree[i] = model.CategoryTreeModel{
+				CategoryModel: c,
+				Depth:         depth,
+				Children:      build(children[c.ID], depth+1),
+			}
+		}
+		return tree
+	}
+	return build(roots, 0), nil
+}
+
+// Move places the category below parentID, or at the top level when parentID
+// is nil. It returns false when the category does not exist.
+func (a *CategoryService) Move(ctx context.Context, id int, parentID *int, version int) (bool, error) {
+	if parentID != nil && *parentID == id {
+		return false, repository.ErrCategoryCycle
+	}
+	return a.categoryRepository.Move(ctx, id, parentID, version)
+}
+
+func (a *CategoryService) Restore(ctx context.Context, id int) (bool, error) {
+	return a.categoryRepository.Restore(ctx, id)
+}
+
+// Patch applies a partial update to the current category and saves it,
+// failing with repository.ErrVersionMismatch on a concurrent change. It
+// returns nil when the category does not exist.
+func (a *CategoryService) Patch(
+	ctx context.Context,
+	id int,
+	version int,
+	apply func(*model.CategoryModel) error,
+) (*model.CategoryModel, error) {
+	category, err := a.categoryRepository.FindOne(ctx, id)
+	if err != nil || category == nil {
+		return nil, err
+	}
+	if version != 0 && version != category.Version {
+		return nil, repository.ErrVersionMismatch
+	}
+
+	if err := apply(category); err != nil {
+		return nil, err
+	}
+	found, err := a.categoryRepository.Update(ctx, category)
+	if err != nil || !found {
+		return nil, err
+	}
+	return a.categoryRepository.FindOne(ctx, id)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+type CouponService struct {
+	couponRepository    *repository.CouponRepository
+	promotionRepository *repository.PromotionRepository
+}
+
+func NewCouponService(
+	couponRepository *repository.CouponRepository,
+	promotionRepository *repository.PromotionRepository,
+) *CouponService {
+	return &CouponService{
+		couponRepository:    couponRepository,
+		promotionRepository: promotionRepository,
+	}
+}
+
+func (s *CouponService) FindAll(ctx context.Context) ([]model.CouponModel, error) {
+	return s.couponRepository.FindAll(ctx)
+}
+
+func (s *CouponService) FindOne(ctx context.Context, id int) (*model.CouponModel, error) {
+	return s.couponRepository.FindOne(ctx, id)
+}
+
+func (s *CouponService) Delete(ctx context.Context, id int) (bool, error) {
+	return s.couponRepository.Delete(ctx, id)
+}
+
+func (s *CouponService) Update(ctx context.Context, coupon *model.CouponModel) (bool, error) {
+	if err := s.validate(ctx, coupon); err != nil {
+		return false, err
+	}
+	return s.couponRepository.Update(ctx, coupon)
+}
+
+func (s *CouponService) Create(ctx context.Context, coupon *model.CouponModel) (*model.CouponModel, error) {
+	if err := s.validate(ctx, coupon); err != nil {
+		return nil, err
+	}
+	return s.couponRepository.Create(ctx, coupon)
+}
+
+func (s *CouponService) validate(ctx context.Context, coupon *model.CouponModel) error {
+	coupon.Code = strings.ToUpper(strings.TrimSpace(coupon.Code))
+	if coupon.Code == "" || strings.ContainsAny(coupon.Code, " \t") {
+		return errors.New("coupon code is required and cannot contain spaces")
+	}
+	if coupon.UsageLimit != nil && *coupon.UsageLimit <= 0 {
+		return errors.New("usage_limit must be greater than 0")
+	}
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+
+	promotion, err := s.promotionRepository.FindOne(ctx, coupon.PromotionID)
+	if err != nil {
+		return err
+	}
+	if promotion == nil {
+		return errors.New("promotion not found")
+	}
+	return nil
+}
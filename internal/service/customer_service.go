@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+type CustomerService struct {
+	customerRepository    *repository.CustomerRepository
+	transactionRepository *repository.TransactionRepository
+}
+
+func NewCustomerService(
+	customerRepository *repository.CustomerRepository,
+	transactionRepository *repository.TransactionRepository,
+) *CustomerService {
+	return &CustomerService{
+		customerRepository:    customerRepository,
+		transactionRepository: transactionRepository,
+	}
+}
+
+const (
+	defaultCustomerLimit = 20
+	maxCustomerLimit     = 100
+)
+
+// FindAll searches customers by name, phone or email. An empty text lists
+// customers by name.
+func (s *CustomerService) FindAll(ctx context.Context, text string, limit int) ([]model.CustomerModel, error) {
+	text = strings.TrimSpace(text)
+	return s.customerRepository.FindAll(ctx, text, phoneDigits(text), clampLimit(limit, defaultCustomerLimit, maxCustomerLimit))
+}
+
+// FindOne returns the customer with their lifetime value per currency.
+func (s *CustomerService) FindOne(ctx context.Context, id int) (*model.CustomerModel, error) {
+	customer, err := s.customerRepository.FindOne(ctx, id)
+	if err != nil || customer == nil {
+		return customer, err
+	}
+	if customer.LifetimeValue, err = s.customerRepository.FindLifetimeValue(ctx, id); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+// FindTransactions returns the purchase history of a customer, newest first,
+// or nil when the customer does not exist.
+func (s *CustomerService) FindTransactions(ctx context.Context, id int) ([]model.TransactionModel, error) {
+	customer, err := s.customerRepository.FindOne(ctx, id)
+	if err != nil || customer == nil {
+		return nil, err
+	}
+	return s.transactionRepository.FindAll(ctx, repository.TransactionFilter{CustomerID: &id})
+}
+
+func (s *CustomerService) Create(ctx context.Context, customer *model.CustomerModel) (*model.CustomerModel, error) {
+	if err := validateCustomer(customer); err != nil {
+		return nil, err
+	}
+	return s.customerRepository.Create(ctx, customer)
+}
+
+func (s *CustomerService) Update(ctx context.Context, customer *model.CustomerModel) (bool, error) {
+	if err := validateCustomer(customer); err != nil {
+		return false, err
+	}
+	return s.customerRepository.Update(ctx, customer)
+}
+
+func (s *CustomerService) Delete(ctx context.Context, id int) (bool, error) {
+	return s.customerRepository.Delete(ctx, id)
+}
+
+// validateCustomer requires a name and normalizes the contact details: phone
+// numbers keep only their digits and emails are lower cased. Blank contact
+// details are cleared.
+func validateCustomer(c *model.CustomerModel) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return errors.New("customer name is required")
+	}
+
+	if c.Phone != nil {
+		phone := phoneDigits(*c.Phone)
+		c.Phone = nil
+		if phone != "" {
+			if len(phone) < 6 || len(phone) > 15 {
+				return errors.New("phone number must have between 6 and 15 digits")
+			}
+			c.Phone = &phone
+		}
+	}
+
+	if c.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*c.Email))
+		c.Email = nil
+		if email != "" {
+			at := strings.Index(email, "@")
+			if at < 1 || at != strings.LastIndex(email, "@") || !strings.Contains(email[at:], ".") {
+				return errors.New("email is not valid")
+			}
+			c.Email = &email
+		}
+	}
+	return nil
+}
+
+func phoneDigits(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, text)
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+// loyaltyWorkerBatch bounds how many credits are expired per statement.
+const loyaltyWorkerBatch = 500
+
+const (
+	defaultLoyaltyEntryLimit = 50
+	maxLoyaltyEntryLimit     = 200
+)
+
+// maxEarnedPoints keeps the points of a single sale within the ledger column.
+const maxEarnedPoints = 1<<31 - 1
+
+type LoyaltyService struct {
+	loyaltyRepository  *repository.LoyaltyRepository
+	customerRepository *repository.CustomerRepository
+	categoryRepository *repository.CategoryRepository
+}
+
+func NewLoyaltyService(
+	loyaltyRepository *repository.LoyaltyRepository,
+	customerRepository *repository.CustomerRepository,
+	categoryRepository *repository.CategoryRepository,
+) *LoyaltyService {
+	return &LoyaltyService{
+		loyaltyRepository:  loyaltyRepository,
+		customerRepository: customerRepository,
+		categoryRepository: categoryRepository,
+	}
+}
+
+func (s *LoyaltyService) FindPrograms(ctx context.Context) ([]model.LoyaltyProgramModel, error) {
+	return s.loyaltyRepository.FindPrograms(ctx)
+}
+
+func (s *LoyaltyService) FindProgram(ctx context.Context, currency string) (*model.LoyaltyProgramModel, error) {
+	return s.loyaltyRepository.FindProgram(ctx, strings.ToUpper(currency))
+}
+
+func (s *LoyaltyService) SaveProgram(
+	ctx context.Context,
+	program *model.LoyaltyProgramModel,
+) (*model.LoyaltyProgramModel, error) {
+	program.Currency = strings.ToUpper(program.Currency)
+	if err := validateLoyaltyProgram(program); err != nil {
+		return nil, err
+	}
+	return s.loyaltyRepository.SaveProgram(ctx, program)
+}
+
+func (s *LoyaltyService) DeleteProgram(ctx context.Context, currency string) (bool, error) {
+	return s.loyaltyRepository.DeleteProgram(ctx, strings.ToUpper(currency))
+}
+
+func validateLoyaltyProgram(p *model.LoyaltyProgramModel) error {
+	if !money.IsSupported(p.Currency) {
+		return fmt.Errorf("unsupported currency %q", p.Currency)
+	}
+	if p.EarnAmount.Currency != p.Currency || p.PointValue.Currency != p.Currency {
+		return fmt.Errorf("earn_amount and point_value must be in %s", p.Currency)
+	}
+	if p.EarnAmount.Amount <= 0 {
+		return errors.New("earn_amount must be greater than 0")
+	}
+	if p.EarnPoints <= 0 {
+		return errors.New("earn_points must be greater than 0")
+	}
+	if p.PointValue.Amount <= 0 {
+		return errors.New("point_value must be greater than 0")
+	}
+	if p.ExpiryDays != nil && *p.ExpiryDays <= 0 {
+		return errors.New("expiry_days must be greater than 0, or null for points that never expire")
+	}
+	return nil
+}
+
+func (s *LoyaltyService) FindMultipliers(ctx context.Context) ([]model.LoyaltyMultiplierModel, error) {
+	return s.loyaltyRepository.FindMultipliers(ctx)
+}
+
+// SetMultiplier returns false when the category does not exist.
+func (s *LoyaltyService) SetMultiplier(ctx context.Context, categoryID int, multiplierBps int) (bool, error) {
+	if multiplierBps < 0 || multiplierBps > 100000 {
+		return false, errors.New("multiplier_bps must be between 0 and 100000")
+	}
+	category, err := s.categoryRepository.FindOne(ctx, categoryID)
+	if err != nil || category == nil {
+		return false, err
+	}
+	if err := s.loyaltyRepository.SetMultiplier(ctx, categoryID, multiplierBps); err != nil {
+		if errors.Is(err, repository.ErrCategoryNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *LoyaltyService) DeleteMultiplier(ctx context.Context, categoryID int) (bool, error) {
+	return s.loyaltyRepository.DeleteMultiplier(ctx, categoryID)
+}
+
+// FindBalance returns nil when the customer does not exist.
+func (s *LoyaltyService) FindBalance(ctx context.Context, customerID int) (*model.LoyaltyBalanceModel, error) {
+	customer, err := s.customerRepository.FindOne(ctx, customerID)
+	if err != nil || customer == nil {
+		return nil, err
+	}
+	return s.loyaltyRepository.FindBalance(ctx, customerID)
+}
+
+// FindEntries returns the points ledger of a customer, newest first, or nil
+// when the customer does not exist.
+func (s *LoyaltyService) FindEntries(ctx context.Context, customerID int, limit int) ([]model.LoyaltyEntryModel, error) {
+	customer, err := s.customerRepository.FindOne(ctx, customerID)
+	if err != nil || customer == nil {
+		return nil, err
+	}
+	return s.loyaltyRepository.FindEntries(ctx, customerID,
+		clampLimit(limit, defaultLoyaltyEntryLimit, maxLoyaltyEntryLimit))
+}
+
+func (s *LoyaltyService) Adjust(
+	ctx context.Context,
+	customerID int,
+	points int,
+	note string,
+	expiresAt *time.Time,
+) (*model.LoyaltyEntryModel, error) {
+	if points == 0 {
+		return nil, errors.New("points cannot be 0")
+	}
+	if expiresAt != nil {
+		if points < 0 {
+			return nil, errors.New("expires_at only applies to points that are credited")
+		}
+		if !expiresAt.After(time.Now()) {
+			return nil, errors.New("expires_at must be in the future")
+		}
+	}
+	return s.loyaltyRepository.Adjust(ctx, customerID, points, strings.TrimSpace(note), expiresAt)
+}
+
+// Run expires points past their expiry every interval until ctx is done.
+func (s *LoyaltyService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.expireDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *LoyaltyService) expireDue(ctx context.Context) {
+	for {
+		expired, err := s.loyaltyRepository.ExpireDue(ctx, loyaltyWorkerBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to expire loyalty points: %v", err)
+			}
+			return
+		}
+		if expired > 0 {
+			log.Printf("Expired loyalty points of %d entries", expired)
+		}
+		if expired < loyaltyWorkerBatch {
+			return
+		}
+	}
+}
+
+// applyPoints works out the points a checkout redeems through its points
+// payments and the points it earns. Only the part of the sale not paid
+// with points earns points, after discounts and before exclusive taxes;
+// lines of a category with a multiplier earn at that multiple.
+func (s *LoyaltyService) applyPoints(
+	ctx context.Context,
+	transaction *model.TransactionModel,
+	categoryIDs []*int,
+) error {
+	currency := transaction.Currency
+	paidWithPoints := money.Zero(currency)
+	for _, p := range transaction.Payments {
+		if p.Method != model.PaymentMethodPoints {
+			continue
+		}
+		var err error
+		if paidWithPoints, err = paidWithPoints.Add(p.Amount); err != nil {
+			return err
+		}
+	}
+
+	if transaction.CustomerID == nil {
+		if !paidWithPoints.IsZero() {
+			return errors.New("points can only be redeemed by a customer, set customer_id")
+		}
+		return nil
+	}
+
+	program, err := s.loyaltyRepository.FindProgram(ctx, currency)
+	if err != nil {
+		return err
+	}
+	if program == nil || !program.Active {
+		if !paidWithPoints.IsZero() {
+			return fmt.Errorf("points cannot be redeemed for sales in %s", currency)
+		}
+		return nil
+	}
+
+	if paidWithPoints.Amount%program.PointValue.Amount != 0 {
+		return fmt.Errorf("points payments must be a multiple of the point value (%s)", program.PointValue)
+	}
+	transaction.PointsRedeemed = int(paidWithPoints.Amount / program.PointValue.Amount)
+
+	ids := make([]int, 0, len(categoryIDs))
+	for _, id := range categoryIDs {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	rates, err := s.loyaltyRepository.FindMultiplierRates(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	lineTotal := money.Zero(currency)
+	eligible := money.Zero(currency)
+	for i, d := range transaction.Details {
+		net, err := d.Subtotal.Sub(d.DiscountAmount)
+		if err != nil {
+			return err
+		}
+		if lineTotal, err = lineTotal.Add(net); err != nil {
+			return err
+		}
+		if categoryIDs[i] != nil {
+			if bps, ok := rates[*categoryIDs[i]]; ok {
+				if net, err = net.MulDiv(int64(bps), 10000); err != nil {
+					return err
+				}
+			}
+		}
+		if eligible, err = eligible.Add(net); err != nil {
+			return err
+		}
+	}
+	if lineTotal.Amount <= 0 || transaction.TotalAmount.Amount <= 0 {
+		return nil
+	}
+
+	// Spread the cart discount and the points payment over the lines in
+	// proportion to their amount
+	net, err := transaction.SubtotalAmount.Sub(transaction.DiscountAmount)
+	if err != nil {
+		return err
+	}
+	if eligible, err = eligible.MulDiv(net.Amount, lineTotal.Amount); err != nil {
+		return err
+	}
+	paidOtherwise, err := transaction.TotalAmount.Sub(paidWithPoints)
+	if err != nil {
+		return err
+	}
+	if eligible, err = eligible.MulDiv(paidOtherwise.Amount, transaction.TotalAmount.Amount); err != nil {
+		return err
+	}
+	if eligible.Amount <= 0 {
+		return nil
+	}
+
+	earned := eligible.Amount / program.EarnAmount.Amount * int64(program.EarnPoints)
+	if earned > int64(maxEarnedPoints) {
+		earned = int64(maxEarnedPoints)
+	}
+	transaction.PointsEarned = int(earned)
+	return nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+type ModifierService struct {
+	modifierRepository *repository.ModifierRepository
+	productRepository  *repository.ProductRepository
+}
+
+func NewModifierService(
+	modifierRepository *repository.ModifierRepository,
+	productRepository *repository.ProductRepository,
+) *ModifierService {
+	return &ModifierService{
+		modifierRepository: modifierRepository,
+		productRepository:  productRepository,
+	}
+}
+
+// FindGroupsByProduct returns nil when the product does not exist.
+func (s *ModifierService) FindGroupsByProduct(ctx context.Context, productID int) ([]model.ModifierGroupModel, error) {
+	product, err := s.productRepository.FindOne(ctx, productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+	return s.modifierRepository.FindGroupsByProduct(ctx, productID)
+}
+
+func (s *ModifierService) DeleteGroup(ctx context.Context, productID int, id int) (bool, error) {
+	return s.modifierRepository.DeleteGroup(ctx, productID, id)
+}
+
+func (s *ModifierService) UpdateGroup(ctx context.Context, group *model.ModifierGroupModel) (bool, error) {
+	if err := s.validate(ctx, group); err != nil {
+		return false, err
+	}
+	return s.modifierRepository.UpdateGroup(ctx, group)
+}
+
+func (s *ModifierService) CreateGroup(ctx context.Context, group *model.ModifierGroupModel) (*model.ModifierGroupModel, error) {
+	if err := s.validate(ctx, group); err != nil {
+		return nil, err
+	}
+	return s.modifierRepository.CreateGroup(ctx, group)
+}
+
+func (s *ModifierService) validate(ctx context.Context, g *model.ModifierGroupModel) error {
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		return errors.New("modifier group name is required")
+	}
+	if g.MinSelect < 0 {
+		return errors.New("min_select cannot be negative")
+	}
+	if g.MaxSelect != nil && *g.MaxSelect < g.MinSelect {
+		return errors.New("max_select cannot be lower than min_select")
+	}
+	if g.MinSelect > len(g.Modifiers) {
+		return errors.New("min_select cannot be higher than the number of modifiers")
+	}
+
+	product, err := s.productRepository.FindOne(ctx, g.ProductID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return repository.ErrProductNotFound
+	}
+
+	seen := make(map[int]bool)
+	for i := range g.Modifiers {
+		m := &g.Modifiers[i]
+		m.Name = strings.TrimSpace(m.Name)
+		if m.Name == "" {
+			return errors.New("modifier name is required")
+		}
+		if m.ID != 0 {
+			if seen[m.ID] {
+				return errors.New("the requested modifier was duplicate")
+			}
+			seen[m.ID] = true
+		}
+		if m.Stock != nil && *m.Stock < 0 {
+			return fmt.Errorf("stock of modifier %s cannot be negative", m.Name)
+		}
+		if m.PriceDelta.Currency == "" {
+			m.PriceDelta.Currency = product.Price.Currency
+		}
+		if m.PriceDelta.Currency != product.Price.Currency {
+			return fmt.Errorf("modifier %s must be priced in the product currency %s", m.Name, product.Price.Currency)
+		}
+		if m.PriceDelta.IsNegative() {
+			return fmt.Errorf("price_delta of modifier %s cannot be negative", m.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+	"github.com/illusi03/golearn/internal/request"
+)
+
+type OrderService struct {
+	orderRepository    *repository.OrderRepository
+	customerRepository *repository.CustomerRepository
+	storeService       *StoreService
+	transactionService *TransactionService
+}
+
+func NewOrderService(
+	orderRepository *repository.OrderRepository,
+	customerRepository *repository.CustomerRepository,
+	storeService *StoreService,
+	transactionService *TransactionService,
+) *OrderService {
+	return &OrderService{
+		orderRepository:    orderRepository,
+		customerRepository: customerRepository,
+		storeService:       storeService,
+		transactionService: transactionService,
+	}
+}
+
+var orderStatuses = []string{
+	model.OrderStatusOpen,
+	model.OrderStatusHeld,
+	model.OrderStatusSettled,
+	model.OrderStatusCancelled,
+}
+
+func (s *OrderService) FindAll(ctx context.Context, storeID *int, status string) ([]model.OrderModel, error) {
+	if status != "" && !slices.Contains(orderStatuses, status) {
+		return nil, fmt.Errorf("unknown order status %q", status)
+	}
+	return s.orderRepository.FindAll(ctx, storeID, status)
+}
+
+func (s *OrderService) FindOne(ctx context.Context, id int) (*model.OrderModel, error) {
+	return s.orderRepository.FindOne(ctx, id)
+}
+
+func (s *OrderService) Create(ctx context.Context, req *request.OrderRequest) (*model.OrderModel, error) {
+	store, err := s.storeService.resolve(ctx, req.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateCustomer(ctx, req.CustomerID); err != nil {
+		return nil, err
+	}
+	items, err := s.orderItems(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.orderRepository.Create(ctx, &model.OrderModel{
+		StoreID:      store.ID,
+		CustomerID:   req.CustomerID,
+		Label:        strings.TrimSpace(req.Label),
+		Note:         req.Note,
+		ReserveStock: req.ReserveStock,
+		Items:        items,
+	})
+	if err != nil || order == nil {
+		return order, err
+	}
+	s.storeService.publishReserved(order.StoreID, nil, orderReserved(order))
+	return order, nil
+}
+
+// Update returns nil when the order does not exist.
+func (s *OrderService) Update(ctx context.Context, id int, req *request.OrderUpdateRequest) (*model.OrderModel, error) {
+	if err := s.validateCustomer(ctx, req.CustomerID); err != nil {
+		return nil, err
+	}
+	return s.reserving(ctx, id, func() (*model.OrderModel, error) {
+		return s.orderRepository.Update(ctx, &model.OrderModel{
+			ID:           id,
+			CustomerID:   req.CustomerID,
+			Label:        strings.TrimSpace(req.Label),
+			Note:         req.Note,
+			ReserveStock: req.ReserveStock,
+		})
+	})
+}
+
+func (s *OrderService) AddItems(ctx context.Context, id int, reqItems []request.CheckoutItem) (*model.OrderModel, error) {
+	if len(reqItems) == 0 {
+		return nil, errors.New("order items cannot be empty")
+	}
+	items, err := s.orderItems(ctx, reqItems)
+	if err != nil {
+		return nil, err
+	}
+	return s.reserving(ctx, id, func() (*model.OrderModel, error) {
+		return s.orderRepository.AddItems(ctx, id, items)
+	})
+}
+
+// SetItemQuantity changes the quantity of an order line; a quantity of 0
+// removes the line.
+func (s *OrderService) SetItemQuantity(ctx context.Context, id, itemID, quantity int) (*model.OrderModel, error) {
+	if quantity < 0 {
+		return nil, errors.New("quantity cannot be negative")
+	}
+	if quantity == 0 {
+		return s.RemoveItem(ctx, id, itemID)
+	}
+	return s.reserving(ctx, id, func() (*model.OrderModel, error) {
+		return s.orderRepository.SetItemQuantity(ctx, id, itemID, quantity)
+	})
+}
+
+func (s *OrderService) RemoveItem(ctx context.Context, id, itemID int) (*model.OrderModel, error) {
+	return s.reserving(ctx, id, func() (*model.OrderModel, error) {
+		return s.orderRepository.RemoveItem(ctx, id, itemID)
+	})
+}
+
+func (s *OrderService) Hold(ctx context.Context, id int) (*model.OrderModel, error) {
+	return s.reserving(ctx, id, func() (*model.OrderModel, error) {
+		return s.orderRepository.Hold(ctx, id)
+	})
+}
+
+func (s *OrderService) Resume(ctx context.Context, id int) (*model.OrderModel, error) {
+	return s.reserving(ctx, id, func() (*model.OrderModel, error) {
+		return s.orderRepository.Resume(ctx, id)
+	})
+}
+
+func (s *OrderService) Cancel(ctx context.Context, id int) (*model.OrderModel, error) {
+	return s.reserving(ctx, id, func() (*model.OrderModel, error) {
+		return s.orderRepository.Cancel(ctx, id)
+	})
+}
+
+// reserving runs a change to an order and publishes the items whose stock
+// its reservation took or gave back.
+func (s *OrderService) reserving(
+	ctx context.Context,
+	id int,
+	change func() (*model.OrderModel, error),
+) (*model.OrderModel, error) {
+	before, err := s.orderRepository.FindOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	after, err := change()
+	if err != nil || after == nil {
+		return after, err
+	}
+	s.storeService.publishReserved(after.StoreID, orderReserved(before), orderReserved(after))
+	return after, nil
+}
+
+// orderReserved sums the stock an order holds back per item: all of its
+// items while it is open or held and reserves stock, nothing otherwise.
+func orderReserved(o *model.OrderModel) map[reservationKey]int {
+	reserved := make(map[reservationKey]int)
+	if o == nil || !o.ReserveStock || (o.Status != model.OrderStatusOpen && o.Status != model.OrderStatusHeld) {
+		return reserved
+	}
+	for _, item := range o.Items {
+		reserved[newReservationKey(item.ProductID, item.VariantID)] += item.Quantity
+	}
+	return reserved
+}
+
+// Settle checks the order out through the regular checkout, priced at the
+// current prices, and closes it in the same database transaction. A zero
+// version settles the order as it is when loaded here.
+func (s *OrderService) Settle(
+	ctx context.Context,
+	id int,
+	req *request.SettleOrderRequest,
+) (*model.TransactionModel, error) {
+	order, err := s.orderRepository.FindOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, repository.ErrOrderNotFound
+	}
+	if order.Status != model.OrderStatusOpen && order.Status != model.OrderStatusHeld {
+		return nil, &repository.OrderStatusError{Status: order.Status}
+	}
+	if req.Version != 0 && req.Version != order.Version {
+		return nil, repository.ErrOrderChanged
+	}
+	if len(order.Items) == 0 {
+		return nil, errors.New("order has no items")
+	}
+
+	checkout := &request.CheckoutRequest{
+		StoreID:    &order.StoreID,
+		CustomerID: order.CustomerID,
+		Items:      make([]request.CheckoutItem, len(order.Items)),
+		Payments:   req.Payments,
+		CouponCode: req.CouponCode,
+	}
+	for i, item := range order.Items {
+		checkout.Items[i] = request.CheckoutItem{
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			ModifierIDs: item.ModifierIDs,
+			Quantity:    item.Quantity,
+		}
+	}
+	return s.transactionService.checkout(ctx, checkout, order)
+}
+
+func (s *OrderService) validateCustomer(ctx context.Context, customerID *int) error {
+	if customerID == nil {
+		return nil
+	}
+	customer, err := s.customerRepository.FindOne(ctx, *customerID)
+	if err != nil {
+		return err
+	}
+	if customer == nil {
+		return fmt.Errorf("customer with id %d not found", *customerID)
+	}
+	return nil
+}
+
+// orderItems checks the items to put on an order and turns them into order
+// lines with their modifiers sorted, so equal lines merge.
+func (s *OrderService) orderItems(ctx context.Context, reqItems []request.CheckoutItem) ([]model.OrderItemModel, error) {
+	items := make([]model.OrderItemModel, len(reqItems))
+	for i := range reqItems {
+		item := &reqItems[i]
+		if err := s.transactionService.validateItem(ctx, item); err != nil {
+			return nil, err
+		}
+		modifierIDs := slices.Clone(item.ModifierIDs)
+		slices.Sort(modifierIDs)
+		items[i] = model.OrderItemModel{
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			ModifierIDs: modifierIDs,
+			Quantity:    item.Quantity,
+		}
+	}
+	return items, nil
+}
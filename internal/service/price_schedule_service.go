@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/illusi03/golearn/internal/event"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+// priceWorkerBatch bounds how many due changes are applied per transaction.
+const priceWorkerBatch = 100
+
+type PriceScheduleService struct {
+	priceHistoryRepository *repository.PriceHistoryRepository
+	productRepository      *repository.ProductRepository
+	events                 productEvents
+}
+
+func NewPriceScheduleService(
+	priceHistoryRepository *repository.PriceHistoryRepository,
+	productRepository *repository.ProductRepository,
+	bus *event.Bus,
+) *PriceScheduleService {
+	return &PriceScheduleService{
+		priceHistoryRepository: priceHistoryRepository,
+		productRepository:      productRepository,
+		events:                 productEvents{productRepository: productRepository, bus: bus},
+	}
+}
+
+// Timeline returns nil when the product does not exist.
+func (s *PriceScheduleService) Timeline(ctx context.Context, productID int) (*model.PriceTimelineModel, error) {
+	product, err := s.productRepository.FindOne(ctx, productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+
+	timeline := &model.PriceTimelineModel{
+		ProductID:    product.ID,
+		CurrentPrice: product.Price,
+	}
+	if timeline.History, err = s.priceHistoryRepository.FindHistory(ctx, productID); err != nil {
+		return nil, err
+	}
+	if timeline.Upcoming, err = s.priceHistoryRepository.FindScheduled(ctx, productID, model.PriceChangePending); err != nil {
+		return nil, err
+	}
+	return timeline, nil
+}
+
+// FindScheduled returns nil when the product does not exist.
+func (s *PriceScheduleService) FindScheduled(ctx context.Context, productID int) ([]model.ScheduledPriceChangeModel, error) {
+	product, err := s.productRepository.FindOne(ctx, productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+	return s.priceHistoryRepository.FindScheduled(ctx, productID, "")
+}
+
+// Schedule returns nil when the product, or the variant the change is for,
+// does not exist.
+func (s *PriceScheduleService) Schedule(
+	ctx context.Context,
+	change *model.ScheduledPriceChangeModel,
+) (*model.ScheduledPriceChangeModel, error) {
+	if err := validatePrice(&change.Price); err != nil {
+		return nil, err
+	}
+	if change.EffectiveAt.IsZero() {
+		return nil, errors.New("effective_at is required")
+	}
+	if !change.EffectiveAt.After(time.Now()) {
+		return nil, errors.New("effective_at must be in the future")
+	}
+
+	product, err := s.productRepository.FindOne(ctx, change.ProductID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+	if change.Price.Currency != product.Price.Currency {
+		return nil, fmt.Errorf("price must be in the product currency %s", product.Price.Currency)
+	}
+	return s.priceHistoryRepository.Schedule(ctx, change)
+}
+
+func (s *PriceScheduleService) Cancel(ctx context.Context, productID int, id int) (bool, error) {
+	return s.priceHistoryRepository.Cancel(ctx, productID, id)
+}
+
+// Run applies due price changes every interval until ctx is done.
+func (s *PriceScheduleService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.applyDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *PriceScheduleService) applyDue(ctx context.Context) {
+	for {
+		applied, changed, err := s.priceHistoryRepository.ApplyDue(ctx, priceWorkerBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to apply scheduled prices: %v", err)
+			}
+			return
+		}
+		s.events.updated(ctx, changed)
+		if applied > 0 {
+			log.Printf("Applied %d scheduled price changes", applied)
+		}
+		if applied < priceWorkerBatch {
+			return
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/illusi03/golearn/internal/event"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/illusi03/golearn/internal/repository"
+	"github.com/illusi03/golearn/internal/request"
+)
+
+// maxBatchOperations keeps one batch within one reasonable round trip.
+const maxBatchOperations = 500
+
+type ProductBatchService struct {
+	productRepository  *repository.ProductRepository
+	categoryRepository *repository.CategoryRepository
+	events             productEvents
+}
+
+func NewProductBatchService(
+	productRepository *repository.ProductRepository,
+	categoryRepository *repository.CategoryRepository,
+	storeRepository *repository.StoreRepository,
+	bus *event.Bus,
+) *ProductBatchService {
+	return &ProductBatchService{
+		productRepository:  productRepository,
+		categoryRepository: categoryRepository,
+		events: productEvents{
+			productRepository: productRepository,
+			storeRepository:   storeRepository,
+			bus:               bus,
+		},
+	}
+}
+
+// Batch validates every operation and runs them in one transaction. Nothing
+// is saved unless every operation is valid and succeeds.
+func (s *ProductBatchService) Batch(
+	ctx context.Context,
+	operations []request.ProductBatchOperation,
+) (*model.ProductBatchResultModel, error) {
+	if len(operations) == 0 {
+		return nil, errors.New("operations are required")
+	}
+	if len(operations) > maxBatchOperations {
+		return nil, fmt.Errorf("a batch can have at most %d operations", maxBatchOperations)
+	}
+
+	result := &model.ProductBatchResultModel{
+		Total:   len(operations),
+		Results: make([]model.ProductBatchItemModel, len(operations)),
+	}
+	ops := make([]repository.ProductBatchOperation, len(operations))
+	for i := range operations {
+		result.Results[i] = model.ProductBatchItemModel{Index: i, Op: operations[i].Op}
+		op, err := s.buildOperation(ctx, &operations[i])
+		if err != nil {
+			result.Results[i].Status = model.BatchStatusError
+			result.Results[i].Error = err.Error()
+			result.Failed++
+			continue
+		}
+		ops[i] = *op
+	}
+	if result.Failed > 0 {
+		for i := range result.Results {
+			if result.Results[i].Status == "" {
+				result.Results[i].Status = model.BatchStatusSkipped
+			}
+		}
+		return result, nil
+	}
+
+	outcomes, committed, err := s.productRepository.Batch(ctx, ops)
+	if err != nil {
+		return nil, err
+	}
+	result.Committed = committed
+	for i, outcome := range outcomes {
+		item := &result.Results[i]
+		item.Affected = outcome.Affected
+		if outcome.ProductID != 0 {
+			item.ProductID = &outcome.ProductID
+		}
+		if outcome.Version != 0 {
+			item.Version = &outcome.Version
+		}
+		switch {
+		case errors.Is(outcome.Err, repository.ErrBatchSkipped):
+			item.Status = model.BatchStatusSkipped
+			item.Error = outcome.Err.Error()
+		case outcome.Err != nil:
+			item.Status = model.BatchStatusError
+			item.Error = outcome.Err.Error()
+			result.Failed++
+		default:
+			item.Status = model.BatchStatusOK
+			result.Succeeded++
+		}
+	}
+	if committed {
+		s.publish(ctx, ops, outcomes)
+	}
+	return result, nil
+}
+
+// publish publishes the products a committed batch changed, and the stock of
+// the ones it created with stock or set the stock of.
+func (s *ProductBatchService) publish(
+	ctx context.Context,
+	ops []repository.ProductBatchOperation,
+	outcomes []repository.ProductBatchOutcome,
+) {
+	changed := make([]int, 0, len(ops))
+	stocked := make([]int, 0)
+	for i, outcome := range outcomes {
+		switch ops[i].Op {
+		case model.BatchOpRepriceCategory:
+			changed = append(changed, outcome.RepricedIDs...)
+		case model.BatchOpCreate:
+			changed = append(changed, outcome.ProductID)
+			if ops[i].Product.Stock != 0 {
+				stocked = append(stocked, outcome.ProductID)
+			}
+		case model.BatchOpUpdate:
+			changed = append(changed, outcome.ProductID)
+			if _, ok := ops[i].Set["stock"]; ok {
+				stocked = append(stocked, outcome.ProductID)
+			}
+		default:
+			changed = append(changed, outcome.ProductID)
+		}
+	}
+	s.events.updated(ctx, changed)
+	s.events.catalogStock(ctx, stocked)
+}
+
+func (s *ProductBatchService) buildOperation(
+	ctx context.Context,
+	r *request.ProductBatchOperation,
+) (*repository.ProductBatchOperation, error) {
+	op := &repository.ProductBatchOperation{Op: r.Op, ID: r.ID, Version: r.Version}
+
+	switch r.Op {
+	case model.BatchOpCreate:
+		op.Product = model.ProductModel{
+			Name:        r.Product.Name,
+			SKU:         r.Product.SKU,
+			Description: r.Product.Description,
+			Price:       r.Product.Price,
+			Stock:       r.Product.Stock,
+		}
+		if r.Product.CategoryId > 0 {
+			op.Product.CategoryID = &r.Product.CategoryId
+		}
+		if err := validateProduct(&op.Product); err != nil {
+			return nil, err
+		}
+
+	case model.BatchOpUpdate:
+		if r.ID <= 0 {
+			return nil, errors.New("id is required")
+		}
+		if r.Version <= 0 {
+			return nil, errors.New("version is required")
+		}
+		set, err := productPatchColumns(&r.Patch)
+		if err != nil {
+			return nil, err
+		}
+		op.Set = set
+
+	case model.BatchOpDelete:
+		if r.ID <= 0 {
+			return nil, errors.New("id is required")
+		}
+		if r.Version <= 0 {
+			return nil, errors.New("version is required")
+		}
+
+	case model.BatchOpRepriceCategory:
+		if r.Percent <= -100 {
+			return nil, errors.New("percent must be greater than -100")
+		}
+		category, err := s.categoryRepository.FindOne(ctx, r.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		if category == nil {
+			return nil, repository.ErrCategoryNotFound
+		}
+		op.CategoryID = r.CategoryID
+		op.IncludeDescendants = r.IncludeDescendants
+		op.PercentBps = int(math.Round(r.Percent * 100))
+
+	default:
+		return nil, fmt.Errorf("op must be one of %s, %s, %s or %s",
+			model.BatchOpCreate, model.BatchOpUpdate, model.BatchOpDelete, model.BatchOpRepriceCategory)
+	}
+	return op, nil
+}
+
+// productPatchColumns validates a merge patch with the rules of
+// validateProduct and turns it into the columns to update.
+func productPatchColumns(patch *request.ProductPatchRequest) (map[string]any, error) {
+	set := make(map[string]any)
+	if patch.Name.Set {
+		if patch.Name.Null {
+			return nil, errors.New("name cannot be null")
+		}
+		if err := validateProductName(patch.Name.Value); err != nil {
+			return nil, err
+		}
+		set["name"] = patch.Name.Value
+	}
+	if patch.SKU.Set {
+		sku, err := normalizeSKU(patch.SKU.Ptr())
+		if err != nil {
+			return nil, err
+		}
+		set["sku"] = sku
+	}
+	if patch.Description.Set {
+		set["description"] = patch.Description.Value
+	}
+	if patch.Stock.Set {
+		if patch.Stock.Null {
+			return nil, errors.New("stock cannot be null")
+		}
+		if err := validateProductStock(patch.Stock.Value); err != nil {
+			return nil, err
+		}
+		set["stock"] = patch.Stock.Value
+	}
+	if patch.CategoryID.Set {
+		set["category_id"] = patch.CategoryID.Ptr()
+	}
+	if patch.Price.Set {
+		price := patch.Price.Value
+		if patch.Price.Null || price.Amount.Null || price.Currency.Null {
+			return nil, errors.New("price cannot be null")
+		}
+		// validatePrice needs a currency, which the product already has
+		// when the patch leaves it out
+		check := money.Money{Amount: price.Amount.Value, Currency: money.DefaultCurrency}
+		if price.Currency.Set {
+			check.Currency = strings.ToUpper(price.Currency.Value)
+		}
+		if err := validatePrice(&check); err != nil {
+			return nil, err
+		}
+		if price.Amount.Set {
+			set["price"] = check.Amount
+		}
+		if price.Currency.Set {
+			set["currency"] = check.Currency
+		}
+	}
+	if len(set) == 0 {
+		return nil, errors.New("patch has nothing to update")
+	}
+	return set, nil
+}
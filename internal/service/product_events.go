@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/illusi03/golearn/internal/event"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+// productEvents publishes the changes made to products once they are
+// committed. The changes are already saved when it runs, so failing to load
+// what to publish only loses the events.
+type productEvents struct {
+	productRepository *repository.ProductRepository
+	storeRepository   *repository.StoreRepository
+	bus               *event.Bus
+}
+
+// updated publishes the products as they are now. A product that was
+// deleted is published with its deleted_at set.
+func (e productEvents) updated(ctx context.Context, ids []int) {
+	if len(ids) == 0 {
+		return
+	}
+	products, err := e.productRepository.FindAll(ctx, repository.ProductFilter{IDs: ids, IncludeDeleted: true})
+	if err != nil {
+		log.Printf("Failed to load products for the event stream: %v", err)
+		return
+	}
+	for i := range products {
+		e.bus.Publish(event.ProductUpdated, nil, products[i])
+	}
+}
+
+// catalogStock publishes the stock written on the products themselves. That
+// stock goes to the default store, see migrations/015_create_stores.sql.
+func (e productEvents) catalogStock(ctx context.Context, ids []int) {
+	if len(ids) == 0 {
+		return
+	}
+	store, err := e.storeRepository.FindDefault(ctx)
+	if err != nil || store == nil {
+		if err != nil {
+			log.Printf("Failed to load the default store for the event stream: %v", err)
+		}
+		return
+	}
+	items := make([]event.StockItem, len(ids))
+	for i, id := range ids {
+		items[i] = event.StockItem{ProductID: id}
+	}
+	e.bus.PublishStockChange(store.ID, event.StockReasonCatalog, items)
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+
+	"github.com/illusi03/golearn/internal/imaging"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+	"github.com/illusi03/golearn/internal/storage"
+)
+
+const (
+	thumbnailSize = 320
+	// maxImagePixels guards against small files that decode into huge images.
+	maxImagePixels = 40_000_000
+)
+
+var (
+	ErrImageTooLarge        = errors.New("image is too large")
+	ErrUnsupportedImageType = errors.New("image must be a JPEG, PNG or GIF")
+)
+
+type ProductImageService struct {
+	productImageRepository *repository.ProductImageRepository
+	productRepository      *repository.ProductRepository
+	storage                storage.Storage
+	maxBytes               int64
+}
+
+func NewProductImageService(
+	productImageRepository *repository.ProductImageRepository,
+	productRepository *repository.ProductRepository,
+	storage storage.Storage,
+	maxBytes int64,
+) *ProductImageService {
+	return &ProductImageService{
+		productImageRepository: productImageRepository,
+		productRepository:      productRepository,
+		storage:                storage,
+		maxBytes:               maxBytes,
+	}
+}
+
+func (s *ProductImageService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// FindByProduct returns nil when the product does not exist.
+func (s *ProductImageService) FindByProduct(ctx context.Context, productID int) ([]model.ProductImageModel, error) {
+	product, err := s.productRepository.FindOne(ctx, productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+	return s.productImageRepository.FindByProduct(ctx, productID)
+}
+
+// Upload stores the image and a thumbnail of it. The content type is sniffed
+// from the data instead of trusting the client.
+func (s *ProductImageService) Upload(ctx context.Context, productID int, data []byte) (*model.ProductImageModel, error) {
+	if int64(len(data)) > s.maxBytes {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", ErrImageTooLarge, s.maxBytes)
+	}
+
+	contentType := http.DetectContentType(data)
+	var ext string
+	switch contentType {
+	case "image/jpeg":
+		ext = "jpg"
+	case "image/png":
+		ext = "png"
+	case "image/gif":
+		ext = "gif"
+	default:
+		return nil, ErrUnsupportedImageType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image cannot be read: %w", err)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrImageTooLarge, config.Width, config.Height)
+	}
+
+	product, err := s.productRepository.FindOne(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, repository.ErrProductNotFound
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image cannot be read: %w", err)
+	}
+	thumbnail, thumbnailType, thumbnailExt, err := encodeThumbnail(src)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	productImage := &model.ProductImageModel{
+		ProductID:    productID,
+		StorageKey:   fmt.Sprintf("products/%d/%s.%s", productID, name, ext),
+		ThumbnailKey: fmt.Sprintf("products/%d/%s_thumb.%s", productID, name, thumbnailExt),
+		ContentType:  contentType,
+		SizeBytes:    int64(len(data)),
+		Width:        config.Width,
+		Height:       config.Height,
+	}
+	productImage.URL = s.storage.URL(productImage.StorageKey)
+	productImage.ThumbnailURL = s.storage.URL(productImage.ThumbnailKey)
+
+	if err := s.storage.Put(ctx, productImage.StorageKey, data, contentType); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Put(ctx, productImage.ThumbnailKey, thumbnail, thumbnailType); err != nil {
+		s.removeFiles(ctx, productImage.StorageKey)
+		return nil, err
+	}
+
+	out, err := s.productImageRepository.Create(ctx, productImage)
+	if err != nil {
+		s.removeFiles(ctx, productImage.StorageKey, productImage.ThumbnailKey)
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *ProductImageService) Delete(ctx context.Context, productID int, id int) (bool, error) {
+	productImage, err := s.productImageRepository.Delete(ctx, productID, id)
+	if err != nil || productImage == nil {
+		return false, err
+	}
+	s.removeFiles(ctx, productImage.StorageKey, productImage.ThumbnailKey)
+	return true, nil
+}
+
+// removeFiles is best effort: the database row is the source of truth and a
+// leftover file is harmless.
+func (s *ProductImageService) removeFiles(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete stored file %s: %v", key, err)
+		}
+	}
+}
+
+// encodeThumbnail encodes opaque thumbnails as JPEG and keeps transparency
+// by falling back to PNG.
+func encodeThumbnail(src image.Image) ([]byte, string, string, error) {
+	thumbnail := imaging.Thumbnail(src, thumbnailSize)
+
+	var buf bytes.Buffer
+	if thumbnail.Opaque() {
+		if err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", "", err
+		}
+		return buf.Bytes(), "image/jpeg", "jpg", nil
+	}
+	if err := png.Encode(&buf, thumbnail); err != nil {
+		return nil, "", "", err
+	}
+	return buf.Bytes(), "image/png", "png", nil
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/illusi03/golearn/internal/event"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+// maxImportRows keeps a single import within one reasonable transaction.
+const maxImportRows = 10000
+
+// productCSVColumns is the layout written by Export and read by Import.
+// Prices are written in major units, e.g. 12.50 for USD or 15000 for IDR.
+var productCSVColumns = []string{"sku", "name", "description", "price", "currency", "stock", "category"}
+
+var requiredImportColumns = []string{"sku", "name", "price"}
+
+type ProductImportService struct {
+	productRepository *repository.ProductRepository
+	events            productEvents
+}
+
+func NewProductImportService(
+	productRepository *repository.ProductRepository,
+	storeRepository *repository.StoreRepository,
+	bus *event.Bus,
+) *ProductImportService {
+	return &ProductImportService{
+		productRepository: productRepository,
+		events: productEvents{
+			productRepository: productRepository,
+			storeRepository:   storeRepository,
+			bus:               bus,
+		},
+	}
+}
+
+// Import reads products from CSV and upserts them by SKU. Every row is
+// validated and reported; nothing is saved unless all rows are valid, and
+// nothing is saved at all in a dry run.
+func (s *ProductImportService) Import(
+	ctx context.Context,
+	r io.Reader,
+	dryRun bool,
+) (*model.ProductImportResultModel, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv file is empty")
+		}
+		return nil, fmt.Errorf("csv header cannot be read: %w", err)
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		index[name] = i
+	}
+	for _, name := range requiredImportColumns {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("csv column %q is required", name)
+		}
+	}
+	_, hasDescription := index["description"]
+	_, hasStock := index["stock"]
+	_, hasCategory := index["category"]
+	columns := repository.ProductImportColumns{
+		Description: hasDescription,
+		Stock:       hasStock,
+		Category:    hasCategory,
+	}
+
+	result := &model.ProductImportResultModel{
+		DryRun:            dryRun,
+		CategoriesCreated: []string{},
+		Rows:              []model.ProductImportRowModel{},
+	}
+	items := make([]repository.ProductImportItem, 0)
+	itemRows := make([]int, 0)
+	seenSKUs := make(map[string]int)
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv row %d cannot be read: %w", line, err)
+		}
+		if len(result.Rows) == maxImportRows {
+			return nil, fmt.Errorf("csv file has more than %d rows", maxImportRows)
+		}
+
+		field := func(name string) string {
+			i, ok := index[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		item, errs := parseImportRow(field)
+		row := model.ProductImportRowModel{
+			Row:    line,
+			SKU:    field("sku"),
+			Name:   field("name"),
+			Errors: errs,
+		}
+		if row.SKU != "" {
+			key := strings.ToUpper(row.SKU)
+			if first, ok := seenSKUs[key]; ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("sku is duplicate of row %d", first))
+			} else {
+				seenSKUs[key] = line
+			}
+		}
+
+		if len(row.Errors) > 0 {
+			row.Action = model.ImportActionError
+		} else {
+			items = append(items, item)
+			itemRows = append(itemRows, len(result.Rows))
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	result.TotalRows = len(result.Rows)
+	if result.TotalRows == 0 {
+		return nil, errors.New("csv file has no rows")
+	}
+
+	invalid := len(items) != result.TotalRows
+	outcomes, categoriesCreated, committed, err := s.productRepository.Import(ctx, items, columns, dryRun || invalid)
+	if err != nil {
+		return nil, err
+	}
+	result.Committed = committed
+	result.CategoriesCreated = categoriesCreated
+
+	for i, outcome := range outcomes {
+		row := &result.Rows[itemRows[i]]
+		if outcome.Err != nil {
+			row.Action = model.ImportActionError
+			row.Errors = append(row.Errors, outcome.Err.Error())
+			continue
+		}
+		productID := outcome.ProductID
+		row.ProductID = &productID
+		if outcome.Created {
+			row.Action = model.ImportActionCreate
+		} else {
+			row.Action = model.ImportActionUpdate
+		}
+	}
+
+	if committed {
+		imported := make([]int, len(outcomes))
+		for i, outcome := range outcomes {
+			imported[i] = outcome.ProductID
+		}
+		s.events.updated(ctx, imported)
+		if columns.Stock {
+			s.events.catalogStock(ctx, imported)
+		}
+	}
+
+	for _, row := range result.Rows {
+		switch row.Action {
+		case model.ImportActionCreate:
+			result.Created++
+		case model.ImportActionUpdate:
+			result.Updated++
+		default:
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+func parseImportRow(field func(string) string) (repository.ProductImportItem, []string) {
+	errs := make([]string, 0)
+	item := repository.ProductImportItem{
+		Product: model.ProductModel{
+			Name:        field("name"),
+			Description: field("description"),
+		},
+		CategoryName: field("category"),
+	}
+
+	sku := field("sku")
+	if sku == "" {
+		errs = append(errs, "sku is required")
+	} else if strings.ContainsAny(sku, " \t") {
+		errs = append(errs, "sku cannot contain spaces")
+	}
+	item.Product.SKU = &sku
+
+	if item.Product.Name == "" {
+		errs = append(errs, "name is required")
+	}
+
+	currency := strings.ToUpper(field("currency"))
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	price, err := money.Parse(field("price"), currency)
+	if err != nil {
+		errs = append(errs, "price: "+err.Error())
+	} else if price.IsNegative() {
+		errs = append(errs, "price cannot be negative")
+	}
+	item.Product.Price = price
+
+	if stock := field("stock"); stock != "" {
+		n, err := strconv.Atoi(stock)
+		if err != nil || n < 0 {
+			errs = append(errs, "stock must be a whole number of 0 or more")
+		}
+		item.Product.Stock = n
+	}
+	return item, errs
+}
+
+// Export writes every product as CSV in the layout Import reads and returns
+// how many products it left out. Import matches rows by SKU, so products
+// without a SKU cannot be read back and are skipped.
+func (s *ProductImportService) Export(ctx context.Context, w io.Writer) (int, error) {
+	products, err := s.productRepository.FindAll(ctx, repository.ProductFilter{})
+	if err != nil {
+		return 0, err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(productCSVColumns); err != nil {
+		return 0, err
+	}
+	skipped := 0
+	for _, p := range products {
+		if p.SKU == nil || *p.SKU == "" {
+			skipped++
+			continue
+		}
+		category := ""
+		if p.CategoryName != nil {
+			category = *p.CategoryName
+		}
+		record := []string{
+			*p.SKU,
+			p.Name,
+			p.Description,
+			p.Price.Decimal(),
+			p.Price.Currency,
+			strconv.Itoa(p.Stock),
+			category,
+		}
+		if err := writer.Write(record); err != nil {
+			return 0, err
+		}
+	}
+	writer.Flush()
+	return skipped, writer.Error()
+}
@@ -2,37 +2,401 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 
+	"github.com/illusi03/golearn/internal/barcode"
+	"github.com/illusi03/golearn/internal/event"
 	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
 	"github.com/illusi03/golearn/internal/repository"
 )
 
 type ProductService struct {
-	productRepository *repository.ProductRepository
+	productRepository  *repository.ProductRepository
+	variantRepository  *repository.VariantRepository
+	modifierRepository *repository.ModifierRepository
+	barcodeRepository  *repository.BarcodeRepository
+	imageRepository    *repository.ProductImageRepository
+	storeRepository    *repository.StoreRepository
+	events             productEvents
 }
 
-func NewProductService(productRepository *repository.ProductRepository) *ProductService {
+func NewProductService(
+	productRepository *repository.ProductRepository,
+	variantRepository *repository.VariantRepository,
+	modifierRepository *repository.ModifierRepository,
+	barcodeRepository *repository.BarcodeRepository,
+	imageRepository *repository.ProductImageRepository,
+	storeRepository *repository.StoreRepository,
+	bus *event.Bus,
+) *ProductService {
 	return &ProductService{
-		productRepository: productRepository,
+		productRepository:  productRepository,
+		variantRepository:  variantRepository,
+		modifierRepository: modifierRepository,
+		barcodeRepository:  barcodeRepository,
+		imageRepository:    imageRepository,
+		storeRepository:    storeRepository,
+		events: productEvents{
+			productRepository: productRepository,
+			storeRepository:   storeRepository,
+			bus:               bus,
+		},
 	}
 }
 
-func (a *ProductService) FindAll(ctx context.Context, name string) ([]model.ProductModel, error) {
-	return a.productRepository.FindAll(ctx, name)
+const (
+	defaultSearchLimit       = 20
+	maxSearchLimit           = 100
+	defaultAutocompleteLimit = 8
+	maxAutocompleteLimit     = 20
+)
+
+// FindAll lists the products matching filter, or the ones also matching name
+// ranked by relevance.
+func (a *ProductService) FindAll(ctx context.Context, name string, filter repository.ProductFilter) ([]model.ProductModel, error) {
+	if name == "" {
+		return a.productRepository.FindAll(ctx, filter)
+	}
+
+	results, err := a.productRepository.Search(ctx, name, maxSearchLimit, filter)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]model.ProductModel, len(results))
+	for i := range results {
+		list[i] = results[i].ProductModel
+	}
+	return list, nil
+}
+
+func (a *ProductService) Search(ctx context.Context, text string, limit int, filter repository.ProductFilter) ([]model.ProductSearchModel, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("search text is required")
+	}
+	return a.productRepository.Search(ctx, text, clampLimit(limit, defaultSearchLimit, maxSearchLimit), filter)
+}
+
+func (a *ProductService) Autocomplete(ctx context.Context, text string, limit int) ([]model.ProductSuggestionModel, error) {
+	return a.productRepository.Autocomplete(ctx, text, clampLimit(limit, defaultAutocompleteLimit, maxAutocompleteLimit))
+}
+
+func clampLimit(limit, def, max int) int {
+	if limit <= 0 {
+		return def
+	}
+	return min(limit, max)
 }
 
+// FindOne returns the product with its variants, modifier groups, barcodes
+// and images.
 func (a *ProductService) FindOne(ctx context.Context, id int) (*model.ProductModel, error) {
-	return a.productRepository.FindOne(ctx, id)
+	product, err := a.productRepository.FindOne(ctx, id)
+	if err != nil || product == nil {
+		return product, err
+	}
+
+	if product.Variants, err = a.variantRepository.FindByProduct(ctx, id); err != nil {
+		return nil, err
+	}
+	if product.ModifierGroups, err = a.modifierRepository.FindGroupsByProduct(ctx, id); err != nil {
+		return nil, err
+	}
+	if product.Barcodes, err = a.barcodeRepository.FindByProduct(ctx, id); err != nil {
+		return nil, err
+	}
+	if product.Images, err = a.imageRepository.FindByProduct(ctx, id); err != nil {
+		return nil, err
+	}
+	if product.Stocks, err = a.storeRepository.FindStocksByProduct(ctx, id); err != nil {
+		return nil, err
+	}
+	return product, nil
 }
 
-func (a *ProductService) Delete(ctx context.Context, id int) (bool, error) {
-	return a.productRepository.Delete(ctx, id)
+// Lookup resolves a scanned barcode, or a product or variant SKU, to its
+// product. It returns nil when nothing matches.
+func (a *ProductService) Lookup(ctx context.Context, code string, sku string) (*model.ProductLookupModel, error) {
+	var productID int
+	var variantID *int
+
+	switch {
+	case code != "":
+		b, err := a.findBarcode(ctx, code)
+		if err != nil || b == nil {
+			return nil, err
+		}
+		productID, variantID = b.ProductID, b.VariantID
+	case sku != "":
+		product, err := a.productRepository.FindBySKU(ctx, sku)
+		if err != nil {
+			return nil, err
+		}
+		if product != nil {
+			productID = product.ID
+			break
+		}
+		variant, err := a.variantRepository.FindBySKU(ctx, sku)
+		if err != nil || variant == nil {
+			return nil, err
+		}
+		productID, variantID = variant.ProductID, &variant.ID
+	default:
+		return nil, errors.New("barcode or sku is required")
+	}
+
+	product, err := a.FindOne(ctx, productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+	lookup := &model.ProductLookupModel{Product: product}
+	if variantID != nil {
+		for i := range product.Variants {
+			if product.Variants[i].ID == *variantID {
+				lookup.Variant = &product.Variants[i]
+			}
+		}
+	}
+	return lookup, nil
+}
+
+// resolveBarcode fills in the product, and the variant when the barcode
+// belongs to one, of an item that was scanned instead of picked by id. Ids
+// that are already set must agree with the barcode.
+func (a *ProductService) resolveBarcode(ctx context.Context, code string, productID *int, variantID **int) error {
+	if code == "" {
+		return nil
+	}
+
+	b, err := a.findBarcode(ctx, code)
+	if err != nil {
+		return fmt.Errorf("barcode %s: %w", code, err)
+	}
+	if b == nil {
+		return fmt.Errorf("product with barcode %s not found", code)
+	}
+	if *productID != 0 && *productID != b.ProductID {
+		return fmt.Errorf("barcode %s does not belong to product with id %d", code, *productID)
+	}
+	*productID = b.ProductID
+	if b.VariantID != nil {
+		if *variantID != nil && **variantID != *b.VariantID {
+			return fmt.Errorf("barcode %s does not belong to variant with id %d", code, **variantID)
+		}
+		*variantID = b.VariantID
+	}
+	return nil
+}
+
+func (a *ProductService) findBarcode(ctx context.Context, code string) (*model.ProductBarcodeModel, error) {
+	normalized, err := barcode.Normalize(code)
+	if err != nil {
+		return nil, err
+	}
+	return a.barcodeRepository.FindByBarcode(ctx, normalized)
+}
+
+// FindBarcodes returns nil when the product does not exist.
+func (a *ProductService) FindBarcodes(ctx context.Context, productID int) ([]model.ProductBarcodeModel, error) {
+	product, err := a.productRepository.FindOne(ctx, productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+	return a.barcodeRepository.FindByProduct(ctx, productID)
+}
+
+// SetBarcodes replaces the barcodes of a product. A barcode may point at one
+// of the product's variants so scanning it picks that variant.
+func (a *ProductService) SetBarcodes(
+	ctx context.Context,
+	productID int,
+	barcodes []model.ProductBarcodeModel,
+) (bool, error) {
+	product, err := a.productRepository.FindOne(ctx, productID)
+	if err != nil || product == nil {
+		return false, err
+	}
+	variants, err := a.variantRepository.FindByProduct(ctx, productID)
+	if err != nil {
+		return false, err
+	}
+
+	seen := make(map[string]bool)
+	for i := range barcodes {
+		b := &barcodes[i]
+		normalized, err := barcode.Normalize(b.Barcode)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", b.Barcode, err)
+		}
+		if seen[normalized] {
+			return false, fmt.Errorf("barcode %s was duplicate", b.Barcode)
+		}
+		seen[normalized] = true
+		b.Barcode = normalized
+
+		if b.VariantID != nil && !slices.ContainsFunc(variants, func(v model.ProductVariantModel) bool {
+			return v.ID == *b.VariantID
+		}) {
+			return false, fmt.Errorf("variant with id %d not found for product %s", *b.VariantID, product.Name)
+		}
+	}
+
+	if err := a.barcodeRepository.SetProductBarcodes(ctx, productID, barcodes); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *ProductService) Delete(ctx context.Context, id int, version int) (bool, error) {
+	found, err := a.productRepository.Delete(ctx, id, version)
+	if err != nil || !found {
+		return found, err
+	}
+	a.events.updated(ctx, []int{id})
+	return true, nil
+}
+
+func (a *ProductService) Restore(ctx context.Context, id int) (bool, error) {
+	found, err := a.productRepository.Restore(ctx, id)
+	if err != nil || !found {
+		return found, err
+	}
+	a.events.updated(ctx, []int{id})
+	return true, nil
 }
 
 func (a *ProductService) Update(ctx context.Context, model *model.ProductModel) (bool, error) {
-	return a.productRepository.Update(ctx, model)
+	if err := validateProduct(model); err != nil {
+		return false, err
+	}
+	current, err := a.productRepository.FindOne(ctx, model.ID)
+	if err != nil || current == nil {
+		return false, err
+	}
+	found, err := a.productRepository.Update(ctx, model)
+	if err != nil || !found {
+		return found, err
+	}
+	a.events.updated(ctx, []int{model.ID})
+	if model.Stock != current.Stock {
+		a.events.catalogStock(ctx, []int{model.ID})
+	}
+	return true, nil
+}
+
+// Patch applies a partial update to the current product and saves it. The
+// write is based on the version that was read, so a concurrent change fails
+// with repository.ErrVersionMismatch instead of being overwritten. It returns
+// nil when the product does not exist.
+func (a *ProductService) Patch(
+	ctx context.Context,
+	id int,
+	version int,
+	apply func(*model.ProductModel) error,
+) (*model.ProductModel, error) {
+	product, err := a.productRepository.FindOne(ctx, id)
+	if err != nil || product == nil {
+		return nil, err
+	}
+	if version != 0 && version != product.Version {
+		return nil, repository.ErrVersionMismatch
+	}
+
+	stock := product.Stock
+	if err := apply(product); err != nil {
+		return nil, err
+	}
+	if err := validateProduct(product); err != nil {
+		return nil, err
+	}
+	found, err := a.productRepository.Update(ctx, product)
+	if err != nil || !found {
+		return nil, err
+	}
+	updated, err := a.productRepository.FindOne(ctx, id)
+	if err != nil || updated == nil {
+		return updated, err
+	}
+	a.events.bus.Publish(event.ProductUpdated, nil, updated)
+	if updated.Stock != stock {
+		a.events.catalogStock(ctx, []int{id})
+	}
+	return updated, nil
 }
 
 func (a *ProductService) Create(ctx context.Context, model *model.ProductModel) (*model.ProductModel, error) {
-	return a.productRepository.Create(ctx, model)
+	if err := validateProduct(model); err != nil {
+		return nil, err
+	}
+	created, err := a.productRepository.Create(ctx, model)
+	if err != nil || created == nil {
+		return created, err
+	}
+	a.events.updated(ctx, []int{created.ID})
+	if created.Stock != 0 {
+		a.events.catalogStock(ctx, []int{created.ID})
+	}
+	return created, nil
+}
+
+// validateProduct checks a product before it is saved. Batch updates check
+// the fields they set with the same field rules.
+func validateProduct(p *model.ProductModel) error {
+	if err := validateProductName(p.Name); err != nil {
+		return err
+	}
+	sku, err := normalizeSKU(p.SKU)
+	if err != nil {
+		return err
+	}
+	p.SKU = sku
+	if err := validateProductStock(p.Stock); err != nil {
+		return err
+	}
+	return validatePrice(&p.Price)
+}
+
+func validateProductName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func validateProductStock(stock int) error {
+	if stock < 0 {
+		return errors.New("stock cannot be negative")
+	}
+	return nil
+}
+
+// normalizeSKU trims the SKU; a blank SKU is stored as none.
+func normalizeSKU(sku *string) (*string, error) {
+	if sku == nil {
+		return nil, nil
+	}
+	trimmed := strings.TrimSpace(*sku)
+	if trimmed == "" {
+		return nil, nil
+	}
+	if strings.ContainsAny(trimmed, " \t") {
+		return nil, errors.New("sku cannot contain spaces")
+	}
+	return &trimmed, nil
+}
+
+func validatePrice(price *money.Money) error {
+	if price.Currency == "" {
+		price.Currency = money.DefaultCurrency
+	}
+	if !money.IsSupported(price.Currency) {
+		return money.ErrInvalidCurrency
+	}
+	if price.IsNegative() {
+		return errors.New("price cannot be negative")
+	}
+	return nil
 }
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+type PromotionService struct {
+	promotionRepository *repository.PromotionRepository
+	couponRepository    *repository.CouponRepository
+}
+
+func NewPromotionService(
+	promotionRepository *repository.PromotionRepository,
+	couponRepository *repository.CouponRepository,
+) *PromotionService {
+	return &PromotionService{
+		promotionRepository: promotionRepository,
+		couponRepository:    couponRepository,
+	}
+}
+
+func (s *PromotionService) FindAll(ctx context.Context) ([]model.PromotionModel, error) {
+	return s.promotionRepository.FindAll(ctx)
+}
+
+func (s *PromotionService) FindOne(ctx context.Context, id int) (*model.PromotionModel, error) {
+	return s.promotionRepository.FindOne(ctx, id)
+}
+
+func (s *PromotionService) Delete(ctx context.Context, id int) (bool, error) {
+	return s.promotionRepository.Delete(ctx, id)
+}
+
+func (s *PromotionService) Update(ctx context.Context, promotion *model.PromotionModel) (bool, error) {
+	if err := validatePromotion(promotion); err != nil {
+		return false, err
+	}
+	return s.promotionRepository.Update(ctx, promotion)
+}
+
+func (s *PromotionService) Create(ctx context.Context, promotion *model.PromotionModel) (*model.PromotionModel, error) {
+	if err := validatePromotion(promotion); err != nil {
+		return nil, err
+	}
+	return s.promotionRepository.Create(ctx, promotion)
+}
+
+func validatePromotion(p *model.PromotionModel) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("promotion name is required")
+	}
+	if p.Scope != model.PromotionScopeLine && p.Scope != model.PromotionScopeCart {
+		return fmt.Errorf("unsupported promotion scope %q", p.Scope)
+	}
+
+	// Both money fields share the currency of the promotion.
+	currency := p.Amount.Currency
+	if currency == "" {
+		currency = p.MinSubtotal.Currency
+	}
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	if (p.Amount.Currency != "" && p.Amount.Currency != currency) ||
+		(p.MinSubtotal.Currency != "" && p.MinSubtotal.Currency != currency) {
+		return errors.New("amount and min_subtotal must use the same currency")
+	}
+	p.Amount.Currency = currency
+	p.MinSubtotal.Currency = currency
+
+	switch p.Type {
+	case model.PromotionTypePercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return errors.New("percentage value must be between 1 and 100")
+		}
+	case model.PromotionTypeFixed:
+		if p.Amount.Amount <= 0 {
+			return errors.New("fixed discount amount must be greater than 0")
+		}
+	case model.PromotionTypeBuyXGetY:
+		if p.Scope != model.PromotionScopeLine {
+			return errors.New("buy x get y promotions must use the line scope")
+		}
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return errors.New("buy_quantity and get_quantity must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("unsupported promotion type %q", p.Type)
+	}
+
+	if p.MinSubtotal.IsNegative() {
+		return errors.New("min_subtotal cannot be negative")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if (p.DailyStartTime == nil) != (p.DailyEndTime == nil) {
+		return errors.New("daily_start_time and daily_end_time must be set together")
+	}
+	if p.DailyStartTime != nil {
+		if _, err := parseClock(*p.DailyStartTime); err != nil {
+			return errors.New("invalid daily_start_time format, use HH:MM")
+		}
+		if _, err := parseClock(*p.DailyEndTime); err != nil {
+			return errors.New("invalid daily_end_time format, use HH:MM")
+		}
+	}
+	return nil
+}
+
+// parseClock converts an HH:MM time of day into minutes since midnight.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// isRunning reports whether a promotion applies at the given moment, taking
+// both the date range and the daily time window into account. Windows where
+// the end is before the start wrap around midnight.
+func isRunning(p *model.PromotionModel, now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	if p.DailyStartTime == nil || p.DailyEndTime == nil {
+		return true
+	}
+
+	start, err := parseClock(*p.DailyStartTime)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(*p.DailyEndTime)
+	if err != nil {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// promotionLine is a checkout line as seen by the promotion engine.
+type promotionLine struct {
+	detail     *model.TransactionDetailModel
+	categoryID *int
+	unitPrice  money.Money
+}
+
+func (l promotionLine) netAmount() (money.Money, error) {
+	return l.detail.Subtotal.Sub(l.detail.DiscountAmount)
+}
+
+func promotionMatches(p *model.PromotionModel, line promotionLine) bool {
+	if p.ProductID != nil && *p.ProductID != line.detail.ProductID {
+		return false
+	}
+	if p.CategoryID != nil && (line.categoryID == nil || *line.categoryID != *p.CategoryID) {
+		return false
+	}
+	return true
+}
+
+// clampDiscount keeps a discount between zero and the amount it applies to.
+func clampDiscount(discount money.Money, limit money.Money) money.Money {
+	if discount.Amount < 0 {
+		return money.Zero(limit.Currency)
+	}
+	if discount.Amount > limit.Amount {
+		return limit
+	}
+	return discount
+}
+
+func lineDiscount(p *model.PromotionModel, line promotionLine) (money.Money, error) {
+	gross := line.detail.Subtotal
+	discount := money.Zero(gross.Currency)
+	var err error
+
+	switch p.Type {
+	case model.PromotionTypePercentage:
+		discount, err = gross.MulDiv(int64(p.Value), 100)
+	case model.PromotionTypeFixed:
+		discount, err = p.Amount.Mul(int64(line.detail.Quantity))
+	case model.PromotionTypeBuyXGetY:
+		group := p.BuyQuantity + p.GetQuantity
+		free := (line.detail.Quantity / group) * p.GetQuantity
+		discount, err = line.unitPrice.Mul(int64(free))
+	}
+	if err != nil {
+		return money.Money{}, err
+	}
+	return clampDiscount(discount, gross), nil
+}
+
+func cartDiscount(p *model.PromotionModel, lines []promotionLine, currency string) (money.Money, error) {
+	base := money.Zero(currency)
+	for _, line := range lines {
+		if !promotionMatches(p, line) {
+			continue
+		}
+		net, err := line.netAmount()
+		if err != nil {
+			return money.Money{}, err
+		}
+		if base, err = base.Add(net); err != nil {
+			return money.Money{}, err
+		}
+	}
+
+	discount := money.Zero(currency)
+	var err error
+	switch p.Type {
+	case model.PromotionTypePercentage:
+		discount, err = base.MulDiv(int64(p.Value), 100)
+	case model.PromotionTypeFixed:
+		discount = p.Amount
+	}
+	if err != nil {
+		return money.Money{}, err
+	}
+	return clampDiscount(discount, base), nil
+}
+
+// applyPromotions prices the lines of a transaction. Discounts do not stack:
+// each line gets the best line promotion and the cart gets the best cart
+// promotion on top of the discounted lines. A presented coupon always wins at
+// its own level when it gives any discount, and fails the checkout when it
+// does not apply at all. Promotions only apply to carts in their currency.
+func (s *PromotionService) applyPromotions(
+	ctx context.Context,
+	transaction *model.TransactionModel,
+	lines []promotionLine,
+	couponCode string,
+	now time.Time,
+) error {
+	currency := transaction.Currency
+	subtotal := money.Zero(currency)
+	for _, line := range lines {
+		var err error
+		if subtotal, err = subtotal.Add(line.detail.Subtotal); err != nil {
+			return err
+		}
+	}
+
+	running, err := s.promotionRepository.FindRunning(ctx, now)
+	if err != nil {
+		return err
+	}
+	promotions := make([]model.PromotionModel, 0, len(running))
+	for _, p := range running {
+		if p.Amount.Currency == currency && isRunning(&p, now) && subtotal.Amount >= p.MinSubtotal.Amount {
+			promotions = append(promotions, p)
+		}
+	}
+
+	var coupon *model.CouponModel
+	var couponPromotion *model.PromotionModel
+	if couponCode != "" {
+		coupon, couponPromotion, err = s.resolveCoupon(ctx, couponCode, now)
+		if err != nil {
+			return err
+		}
+		if couponPromotion.Amount.Currency != currency {
+			return fmt.Errorf("coupon %s is not valid for %s purchases", coupon.Code, currency)
+		}
+		if subtotal.Amount < couponPromotion.MinSubtotal.Amount {
+			return fmt.Errorf("coupon %s requires a minimum purchase of %s", coupon.Code, couponPromotion.MinSubtotal)
+		}
+	}
+	couponUsed := false
+
+	totalDiscount := money.Zero(currency)
+	for _, line := range lines {
+		best, bestID := money.Zero(currency), (*int)(nil)
+		for i := range promotions {
+			p := &promotions[i]
+			if p.Scope != model.PromotionScopeLine || !promotionMatches(p, line) {
+				continue
+			}
+			d, err := lineDiscount(p, line)
+			if err != nil {
+				return err
+			}
+			if d.Amount > best.Amount {
+				best, bestID = d, &p.ID
+			}
+		}
+		if couponPromotion != nil && couponPromotion.Scope == model.PromotionScopeLine &&
+			promotionMatches(couponPromotion, line) {
+			d, err := lineDiscount(couponPromotion, line)
+			if err != nil {
+				return err
+			}
+			if d.Amount > 0 {
+				best, bestID = d, &couponPromotion.ID
+				couponUsed = true
+			}
+		}
+		line.detail.DiscountAmount = best
+		line.detail.PromotionID = bestID
+		if totalDiscount, err = totalDiscount.Add(best); err != nil {
+			return err
+		}
+	}
+
+	cartBest, cartBestID := money.Zero(currency), (*int)(nil)
+	for i := range promotions {
+		p := &promotions[i]
+		if p.Scope != model.PromotionScopeCart {
+			continue
+		}
+		d, err := cartDiscount(p, lines, currency)
+		if err != nil {
+			return err
+		}
+		if d.Amount > cartBest.Amount {
+			cartBest, cartBestID = d, &p.ID
+		}
+	}
+	if couponPromotion != nil && couponPromotion.Scope == model.PromotionScopeCart {
+		d, err := cartDiscount(couponPromotion, lines, currency)
+		if err != nil {
+			return err
+		}
+		if d.Amount > 0 {
+			cartBest, cartBestID = d, &couponPromotion.ID
+			couponUsed = true
+		}
+	}
+
+	if coupon != nil && !couponUsed {
+		return fmt.Errorf("coupon %s is not applicable to this cart", coupon.Code)
+	}
+
+	if totalDiscount, err = totalDiscount.Add(cartBest); err != nil {
+		return err
+	}
+	transaction.SubtotalAmount = subtotal
+	transaction.DiscountAmount = totalDiscount
+	if transaction.TotalAmount, err = subtotal.Sub(totalDiscount); err != nil {
+		return err
+	}
+	transaction.PromotionID = cartBestID
+	if couponUsed {
+		transaction.CouponID = &coupon.ID
+	}
+	return nil
+}
+
+func (s *PromotionService) resolveCoupon(
+	ctx context.Context,
+	code string,
+	now time.Time,
+) (*model.CouponModel, *model.PromotionModel, error) {
+	coupon, err := s.couponRepository.FindByCode(ctx, code)
+	if err != nil {
+		return nil, nil, err
+	}
+	if coupon == nil || !coupon.Active {
+		return nil, nil, fmt.Errorf("coupon %s is not valid", code)
+	}
+	if (coupon.StartsAt != nil && now.Before(*coupon.StartsAt)) ||
+		(coupon.EndsAt != nil && !now.Before(*coupon.EndsAt)) {
+		return nil, nil, fmt.Errorf("coupon %s is not valid at this time", coupon.Code)
+	}
+	if coupon.UsageLimit != nil && coupon.UsedCount >= *coupon.UsageLimit {
+		return nil, nil, repository.ErrCouponLimitReached
+	}
+
+	promotion, err := s.promotionRepository.FindOne(ctx, coupon.PromotionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if promotion == nil || !isRunning(promotion, now) {
+		return nil, nil, fmt.Errorf("coupon %s is not valid at this time", coupon.Code)
+	}
+	return coupon, promotion, nil
+}
@@ -16,6 +16,13 @@ func NewReportService(reportRepository *repository.ReportRepository) *ReportServ
 	return &ReportService{reportRepository: reportRepository}
 }
 
-func (s *ReportService) GetReport(ctx context.Context, startDate, endDate time.Time) (*model.ReportModel, error) {
-	return s.reportRepository.GetReport(ctx, startDate, endDate)
+// GetReport reports on one store, or on all stores together when storeID is
+// nil.
+func (s *ReportService) GetReport(
+	ctx context.Context,
+	startDate, endDate time.Time,
+	currency string,
+	storeID *int,
+) (*model.ReportModel, error) {
+	return s.reportRepository.GetReport(ctx, startDate, endDate, currency, storeID)
 }
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+type ShiftService struct {
+	shiftRepository *repository.ShiftRepository
+	storeService    *StoreService
+}
+
+func NewShiftService(shiftRepository *repository.ShiftRepository, storeService *StoreService) *ShiftService {
+	return &ShiftService{
+		shiftRepository: shiftRepository,
+		storeService:    storeService,
+	}
+}
+
+func (s *ShiftService) FindAll(ctx context.Context, storeID *int) ([]model.ShiftModel, error) {
+	return s.shiftRepository.FindAll(ctx, storeID)
+}
+
+func (s *ShiftService) FindOne(ctx context.Context, id int) (*model.ShiftModel, error) {
+	return s.shiftRepository.FindOne(ctx, id)
+}
+
+// FindCurrent returns the open shift of a store, or of the default store
+// when storeID is nil.
+func (s *ShiftService) FindCurrent(ctx context.Context, storeID *int) (*model.ShiftModel, error) {
+	store, err := s.storeService.resolve(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.shiftRepository.FindOpen(ctx, store.ID)
+}
+
+func (s *ShiftService) Open(ctx context.Context, shift *model.ShiftModel, storeID *int) (*model.ShiftModel, error) {
+	if err := validateOpeningFloat(&shift.OpeningFloat); err != nil {
+		return nil, err
+	}
+	store, err := s.storeService.resolve(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	shift.StoreID = store.ID
+	return s.shiftRepository.Open(ctx, shift)
+}
+
+func (s *ShiftService) Update(ctx context.Context, shift *model.ShiftModel) (bool, error) {
+	if err := validateOpeningFloat(&shift.OpeningFloat); err != nil {
+		return false, err
+	}
+	return s.shiftRepository.Update(ctx, shift)
+}
+
+func (s *ShiftService) Close(
+	ctx context.Context,
+	id int,
+	countedCash money.Money,
+	note string,
+) (*model.ShiftModel, error) {
+	if countedCash.IsNegative() {
+		return nil, errors.New("counted cash cannot be negative")
+	}
+	if countedCash.Currency == "" {
+		countedCash.Currency = money.DefaultCurrency
+	}
+	return s.shiftRepository.Close(ctx, id, countedCash, note)
+}
+
+// validateOpeningFloat defaults an omitted float to zero in the default
+// currency, which also decides the currency the shift reconciles in.
+func validateOpeningFloat(openingFloat *money.Money) error {
+	if openingFloat.Currency == "" {
+		openingFloat.Currency = money.DefaultCurrency
+	}
+	if !money.IsSupported(openingFloat.Currency) {
+		return money.ErrInvalidCurrency
+	}
+	if openingFloat.IsNegative() {
+		return errors.New("opening float cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/illusi03/golearn/internal/event"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+type StockTransferService struct {
+	transferRepository *repository.StockTransferRepository
+	storeService       *StoreService
+	bus                *event.Bus
+}
+
+func NewStockTransferService(
+	transferRepository *repository.StockTransferRepository,
+	storeService *StoreService,
+	bus *event.Bus,
+) *StockTransferService {
+	return &StockTransferService{
+		transferRepository: transferRepository,
+		storeService:       storeService,
+		bus:                bus,
+	}
+}
+
+var transferStatuses = []string{
+	model.TransferStatusRequested,
+	model.TransferStatusInTransit,
+	model.TransferStatusReceived,
+	model.TransferStatusCancelled,
+}
+
+func (s *StockTransferService) FindAll(ctx context.Context, storeID *int, status string) ([]model.StockTransferModel, error) {
+	if status != "" && !slices.Contains(transferStatuses, status) {
+		return nil, fmt.Errorf("unknown transfer status %q", status)
+	}
+	return s.transferRepository.FindAll(ctx, storeID, status)
+}
+
+func (s *StockTransferService) FindOne(ctx context.Context, id int) (*model.StockTransferModel, error) {
+	return s.transferRepository.FindOne(ctx, id)
+}
+
+func (s *StockTransferService) Create(ctx context.Context, transfer *model.StockTransferModel) (*model.StockTransferModel, error) {
+	if transfer.SourceStoreID == transfer.DestinationStoreID {
+		return nil, errors.New("source and destination store must differ")
+	}
+	for _, storeID := range []int{transfer.SourceStoreID, transfer.DestinationStoreID} {
+		if _, err := s.storeService.resolve(ctx, &storeID); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(transfer.Items) == 0 {
+		return nil, errors.New("transfer items cannot be empty")
+	}
+	seen := make(map[[2]int]bool, len(transfer.Items))
+	for _, item := range transfer.Items {
+		if item.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than 0")
+		}
+		key := [2]int{item.ProductID, 0}
+		if item.VariantID != nil {
+			key[1] = *item.VariantID
+		}
+		if seen[key] {
+			return nil, errors.New("the requested transfer item was duplicate")
+		}
+		seen[key] = true
+	}
+
+	return s.transferRepository.Create(ctx, transfer)
+}
+
+func (s *StockTransferService) Dispatch(ctx context.Context, id int) (*model.StockTransferModel, error) {
+	transfer, err := s.transferRepository.Dispatch(ctx, id)
+	if err != nil || transfer == nil {
+		return transfer, err
+	}
+	s.publishStockChange(transfer.SourceStoreID, transfer)
+	return transfer, nil
+}
+
+func (s *StockTransferService) Receive(ctx context.Context, id int) (*model.StockTransferModel, error) {
+	transfer, err := s.transferRepository.Receive(ctx, id)
+	if err != nil || transfer == nil {
+		return transfer, err
+	}
+	s.publishStockChange(transfer.DestinationStoreID, transfer)
+	return transfer, nil
+}
+
+// Cancel drops a transfer. The stock of a transfer that was in transit goes
+// back to the source store.
+func (s *StockTransferService) Cancel(ctx context.Context, id int) (*model.StockTransferModel, error) {
+	transfer, err := s.transferRepository.Cancel(ctx, id)
+	if err != nil || transfer == nil {
+		return transfer, err
+	}
+	if transfer.DispatchedAt != nil {
+		s.publishStockChange(transfer.SourceStoreID, transfer)
+	}
+	return transfer, nil
+}
+
+func (s *StockTransferService) publishStockChange(storeID int, transfer *model.StockTransferModel) {
+	items := make([]event.StockItem, len(transfer.Items))
+	for i, item := range transfer.Items {
+		items[i] = event.StockItem{ProductID: item.ProductID, VariantID: item.VariantID}
+	}
+	s.bus.PublishStockChange(storeID, event.StockReasonTransfer, items)
+}
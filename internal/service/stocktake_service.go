@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/illusi03/golearn/internal/event"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+	"github.com/illusi03/golearn/internal/request"
+)
+
+type StocktakeService struct {
+	stocktakeRepository *repository.StocktakeRepository
+	storeService        *StoreService
+	productService      *ProductService
+	bus                 *event.Bus
+}
+
+func NewStocktakeService(
+	stocktakeRepository *repository.StocktakeRepository,
+	storeService *StoreService,
+	productService *ProductService,
+	bus *event.Bus,
+) *StocktakeService {
+	return &StocktakeService{
+		stocktakeRepository: stocktakeRepository,
+		storeService:        storeService,
+		productService:      productService,
+		bus:                 bus,
+	}
+}
+
+func (s *StocktakeService) FindAll(ctx context.Context, storeID *int) ([]model.StocktakeModel, error) {
+	return s.stocktakeRepository.FindAll(ctx, storeID)
+}
+
+func (s *StocktakeService) FindOne(ctx context.Context, id int) (*model.StocktakeModel, error) {
+	return s.stocktakeRepository.FindOne(ctx, id)
+}
+
+// Create opens a stocktake in the given store, or in the default store when
+// storeID is nil.
+func (s *StocktakeService) Create(ctx context.Context, storeID *int, note string) (*model.StocktakeModel, error) {
+	store, err := s.storeService.resolve(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	return s.stocktakeRepository.Create(ctx, &model.StocktakeModel{
+		StoreID: store.ID,
+		Note:    note,
+	})
+}
+
+// Count records a batch of counts. Items may be scanned by barcode; in add
+// mode an item scanned several times in one batch is counted once per scan.
+// It returns false when the stocktake does not exist.
+func (s *StocktakeService) Count(ctx context.Context, id int, req *request.StocktakeCountRequest) (bool, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = model.StocktakeCountAdd
+	}
+	if mode != model.StocktakeCountAdd && mode != model.StocktakeCountSet {
+		return false, fmt.Errorf("unknown count mode %q", mode)
+	}
+	if len(req.Items) == 0 {
+		return false, errors.New("count items cannot be empty")
+	}
+
+	items := make([]model.StocktakeItemModel, 0, len(req.Items))
+	index := make(map[[2]int]int, len(req.Items))
+	for _, item := range req.Items {
+		if err := s.productService.resolveBarcode(ctx, item.Barcode, &item.ProductID, &item.VariantID); err != nil {
+			return false, err
+		}
+		if item.ProductID == 0 {
+			return false, errors.New("product_id or barcode is required")
+		}
+		if mode == model.StocktakeCountSet && item.Quantity < 0 {
+			return false, errors.New("counted quantity cannot be negative")
+		}
+
+		key := [2]int{item.ProductID, 0}
+		if item.VariantID != nil {
+			key[1] = *item.VariantID
+		}
+		if i, ok := index[key]; ok {
+			if mode == model.StocktakeCountSet {
+				return false, errors.New("the counted item was duplicate")
+			}
+			*items[i].Counted += item.Quantity
+			continue
+		}
+
+		quantity := item.Quantity
+		index[key] = len(items)
+		items = append(items, model.StocktakeItemModel{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Counted:   &quantity,
+		})
+	}
+
+	return s.stocktakeRepository.Count(ctx, id, items, mode == model.StocktakeCountSet)
+}
+
+func (s *StocktakeService) Finalize(ctx context.Context, id int, zeroUncounted bool) (*model.StocktakeModel, error) {
+	stocktake, err := s.stocktakeRepository.Finalize(ctx, id, zeroUncounted)
+	if err != nil || stocktake == nil {
+		return stocktake, err
+	}
+
+	adjustments, err := s.stocktakeRepository.FindAdjustments(ctx, id)
+	if err != nil {
+		log.Printf("Failed to load adjustments of stocktake %d for the event stream: %v", id, err)
+		return stocktake, nil
+	}
+	items := make([]event.StockItem, len(adjustments))
+	for i, a := range adjustments {
+		items[i] = event.StockItem{ProductID: a.ProductID, VariantID: a.VariantID}
+	}
+	s.bus.PublishStockChange(stocktake.StoreID, event.StockReasonStocktake, items)
+	return stocktake, nil
+}
+
+func (s *StocktakeService) Cancel(ctx context.Context, id int) (*model.StocktakeModel, error) {
+	return s.stocktakeRepository.Cancel(ctx, id)
+}
+
+// FindAdjustments returns nil when the stocktake does not exist.
+func (s *StocktakeService) FindAdjustments(ctx context.Context, id int) ([]model.StockAdjustmentModel, error) {
+	stocktake, err := s.stocktakeRepository.FindOne(ctx, id)
+	if err != nil || stocktake == nil {
+		return nil, err
+	}
+	return s.stocktakeRepository.FindAdjustments(ctx, id)
+}
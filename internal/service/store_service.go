@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/illusi03/golearn/internal/event"
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+type StoreService struct {
+	storeRepository *repository.StoreRepository
+	bus             *event.Bus
+}
+
+func NewStoreService(storeRepository *repository.StoreRepository, bus *event.Bus) *StoreService {
+	return &StoreService{
+		storeRepository: storeRepository,
+		bus:             bus,
+	}
+}
+
+func (s *StoreService) FindAll(ctx context.Context) ([]model.StoreModel, error) {
+	return s.storeRepository.FindAll(ctx)
+}
+
+func (s *StoreService) FindOne(ctx context.Context, id int) (*model.StoreModel, error) {
+	return s.storeRepository.FindOne(ctx, id)
+}
+
+func (s *StoreService) Create(ctx context.Context, store *model.StoreModel) (*model.StoreModel, error) {
+	if err := validateStore(store); err != nil {
+		return nil, err
+	}
+	return s.storeRepository.Create(ctx, store)
+}
+
+func (s *StoreService) Update(ctx context.Context, store *model.StoreModel) (bool, error) {
+	if err := validateStore(store); err != nil {
+		return false, err
+	}
+	return s.storeRepository.Update(ctx, store)
+}
+
+func (s *StoreService) Delete(ctx context.Context, id int) (bool, error) {
+	return s.storeRepository.Delete(ctx, id)
+}
+
+func validateStore(store *model.StoreModel) error {
+	store.Name = strings.TrimSpace(store.Name)
+	store.Code = strings.ToUpper(strings.TrimSpace(store.Code))
+	if store.Name == "" {
+		return errors.New("store name is required")
+	}
+	if store.Code == "" {
+		return errors.New("store code is required")
+	}
+	return nil
+}
+
+// resolve returns the store with the given id, or the default store when id
+// is nil.
+func (s *StoreService) resolve(ctx context.Context, id *int) (*model.StoreModel, error) {
+	if id == nil {
+		store, err := s.storeRepository.FindDefault(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if store == nil {
+			return nil, errors.New("no default store is configured")
+		}
+		return store, nil
+	}
+
+	store, err := s.storeRepository.FindOne(ctx, *id)
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return nil, fmt.Errorf("store with id %d not found", *id)
+	}
+	return store, nil
+}
+
+// stock returns the stock a store can sell of a product, or of one of its
+// variants when variantID is set, counting the stock reserved for orderID
+// and cartID as available.
+func (s *StoreService) stock(ctx context.Context, storeID, productID int, variantID *int, orderID, cartID *int) (int, error) {
+	return s.storeRepository.FindStock(ctx, storeID, productID, variantID, orderID, cartID)
+}
+
+// FindStocks returns nil when the store does not exist.
+func (s *StoreService) FindStocks(ctx context.Context, storeID int) ([]model.StoreStockModel, error) {
+	store, err := s.storeRepository.FindOne(ctx, storeID)
+	if err != nil || store == nil {
+		return nil, err
+	}
+	return s.storeRepository.FindStocks(ctx, storeID)
+}
+
+// SetStocks overwrites the stock levels of the listed items in a store. It
+// returns false when the store does not exist.
+func (s *StoreService) SetStocks(ctx context.Context, storeID int, items []model.StoreStockModel) (bool, error) {
+	if len(items) == 0 {
+		return false, errors.New("stock items cannot be empty")
+	}
+
+	seen := make(map[[2]int]bool, len(items))
+	for _, item := range items {
+		if item.Stock < 0 {
+			return false, errors.New("stock cannot be negative")
+		}
+		key := [2]int{item.ProductID, 0}
+		if item.VariantID != nil {
+			key[1] = *item.VariantID
+		}
+		if seen[key] {
+			return false, errors.New("the requested stock item was duplicate")
+		}
+		seen[key] = true
+	}
+
+	err := s.storeRepository.SetStocks(ctx, storeID, items)
+	if errors.Is(err, repository.ErrStoreNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	changed := make([]event.StockItem, len(items))
+	for i, item := range items {
+		changed[i] = event.StockItem{ProductID: item.ProductID, VariantID: item.VariantID}
+	}
+	s.bus.PublishStockChange(storeID, event.StockReasonSet, changed)
+	return true, nil
+}
+
+// reservationKey identifies the reserved stock of a product, or of one of its
+// variants when the second value is not zero.
+type reservationKey [2]int
+
+func newReservationKey(productID int, variantID *int) reservationKey {
+	key := reservationKey{productID, 0}
+	if variantID != nil {
+		key[1] = *variantID
+	}
+	return key
+}
+
+// publishReserved publishes the items of a store whose reserved quantity
+// differs between before and after.
+func (s *StoreService) publishReserved(storeID int, before, after map[reservationKey]int) {
+	items := make([]event.StockItem, 0)
+	add := func(key reservationKey) {
+		item := event.StockItem{ProductID: key[0]}
+		if key[1] != 0 {
+			variantID := key[1]
+			item.VariantID = &variantID
+		}
+		items = append(items, item)
+	}
+	for key, quantity := range before {
+		if after[key] != quantity {
+			add(key)
+		}
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			add(key)
+		}
+	}
+	s.bus.PublishStockChange(storeID, event.StockReasonReservation, items)
+}
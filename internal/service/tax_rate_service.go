@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+type TaxRateService struct {
+	taxRateRepository  *repository.TaxRateRepository
+	productRepository  *repository.ProductRepository
+	categoryRepository *repository.CategoryRepository
+}
+
+func NewTaxRateService(
+	taxRateRepository *repository.TaxRateRepository,
+	productRepository *repository.ProductRepository,
+	categoryRepository *repository.CategoryRepository,
+) *TaxRateService {
+	return &TaxRateService{
+		taxRateRepository:  taxRateRepository,
+		productRepository:  productRepository,
+		categoryRepository: categoryRepository,
+	}
+}
+
+func (s *TaxRateService) FindAll(ctx context.Context) ([]model.TaxRateModel, error) {
+	return s.taxRateRepository.FindAll(ctx)
+}
+
+func (s *TaxRateService) FindOne(ctx context.Context, id int) (*model.TaxRateModel, error) {
+	return s.taxRateRepository.FindOne(ctx, id)
+}
+
+func (s *TaxRateService) Delete(ctx context.Context, id int) (bool, error) {
+	return s.taxRateRepository.Delete(ctx, id)
+}
+
+func (s *TaxRateService) Update(ctx context.Context, taxRate *model.TaxRateModel) (bool, error) {
+	if err := validateTaxRate(taxRate); err != nil {
+		return false, err
+	}
+	return s.taxRateRepository.Update(ctx, taxRate)
+}
+
+func (s *TaxRateService) Create(ctx context.Context, taxRate *model.TaxRateModel) (*model.TaxRateModel, error) {
+	if err := validateTaxRate(taxRate); err != nil {
+		return nil, err
+	}
+	return s.taxRateRepository.Create(ctx, taxRate)
+}
+
+func validateTaxRate(t *model.TaxRateModel) error {
+	if strings.TrimSpace(t.Name) == "" {
+		return errors.New("tax rate name is required")
+	}
+	if t.RateBps < 0 || t.RateBps > 10000 {
+		return errors.New("rate_bps must be between 0 and 10000")
+	}
+	if t.Inclusive && t.Compound {
+		return errors.New("inclusive tax rates cannot be compound")
+	}
+	return nil
+}
+
+// FindProductRates returns nil when the product does not exist.
+func (s *TaxRateService) FindProductRates(ctx context.Context, productID int) ([]model.TaxRateModel, error) {
+	product, err := s.productRepository.FindOne(ctx, productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+	return s.taxRateRepository.FindByProduct(ctx, productID)
+}
+
+func (s *TaxRateService) SetProductRates(ctx context.Context, productID int, taxRateIDs []int) (bool, error) {
+	product, err := s.productRepository.FindOne(ctx, productID)
+	if err != nil || product == nil {
+		return false, err
+	}
+	if err := s.taxRateRepository.SetProductRates(ctx, productID, uniqueIDs(taxRateIDs)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FindCategoryRates returns nil when the category does not exist.
+func (s *TaxRateService) FindCategoryRates(ctx context.Context, categoryID int) ([]model.TaxRateModel, error) {
+	category, err := s.categoryRepository.FindOne(ctx, categoryID)
+	if err != nil || category == nil {
+		return nil, err
+	}
+	return s.taxRateRepository.FindByCategory(ctx, categoryID)
+}
+
+func (s *TaxRateService) SetCategoryRates(ctx context.Context, categoryID int, taxRateIDs []int) (bool, error) {
+	category, err := s.categoryRepository.FindOne(ctx, categoryID)
+	if err != nil || category == nil {
+		return false, err
+	}
+	if err := s.taxRateRepository.SetCategoryRates(ctx, categoryID, uniqueIDs(taxRateIDs)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func uniqueIDs(ids []int) []int {
+	out := slices.Clone(ids)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// applyTaxes computes the taxes of every line once discounts are known. Cart
+// discounts are spread over the lines in proportion to their net amount, so
+// the taxable amount of each line matches what the customer actually pays.
+// Inclusive taxes are extracted from the line amount, exclusive taxes are
+// added to the transaction total.
+func (s *TaxRateService) applyTaxes(ctx context.Context, transaction *model.TransactionModel) error {
+	details := transaction.Details
+	if len(details) == 0 {
+		return nil
+	}
+	currency := transaction.Currency
+
+	productIDs := make([]int, len(details))
+	for i, d := range details {
+		productIDs[i] = d.ProductID
+	}
+	rates, err := s.taxRateRepository.FindForProducts(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+
+	lineDiscountTotal := money.Zero(currency)
+	for _, d := range details {
+		if lineDiscountTotal, err = lineDiscountTotal.Add(d.DiscountAmount); err != nil {
+			return err
+		}
+	}
+	cartDiscount, err := transaction.DiscountAmount.Sub(lineDiscountTotal)
+	if err != nil {
+		return err
+	}
+	netTotal, err := transaction.SubtotalAmount.Sub(lineDiscountTotal)
+	if err != nil {
+		return err
+	}
+
+	taxTotal := money.Zero(currency)
+	exclusiveTotal := money.Zero(currency)
+	allocated := money.Zero(currency)
+	for i := range details {
+		d := &details[i]
+		net, err := d.Subtotal.Sub(d.DiscountAmount)
+		if err != nil {
+			return err
+		}
+
+		share := money.Zero(currency)
+		if i == len(details)-1 {
+			share, err = cartDiscount.Sub(allocated)
+		} else if netTotal.Amount > 0 {
+			share, err = cartDiscount.MulDiv(net.Amount, netTotal.Amount)
+		}
+		if err != nil {
+			return err
+		}
+		if allocated, err = allocated.Add(share); err != nil {
+			return err
+		}
+
+		taxable, err := net.Sub(share)
+		if err != nil {
+			return err
+		}
+		taxes, exclusive, err := lineTaxes(taxable, rates[d.ProductID])
+		if err != nil {
+			return err
+		}
+
+		d.Taxes = taxes
+		d.TaxAmount = money.Zero(currency)
+		for _, t := range taxes {
+			if d.TaxAmount, err = d.TaxAmount.Add(t.TaxAmount); err != nil {
+				return err
+			}
+		}
+		if taxTotal, err = taxTotal.Add(d.TaxAmount); err != nil {
+			return err
+		}
+		if exclusiveTotal, err = exclusiveTotal.Add(exclusive); err != nil {
+			return err
+		}
+	}
+
+	transaction.TaxAmount = taxTotal
+	total, err := transaction.SubtotalAmount.Sub(transaction.DiscountAmount)
+	if err != nil {
+		return err
+	}
+	transaction.TotalAmount, err = total.Add(exclusiveTotal)
+	return err
+}
+
+// lineTaxes splits a line amount into its taxes and returns them together
+// with the exclusive part that has to be added to the line amount.
+func lineTaxes(
+	amount money.Money,
+	rates []model.TaxRateModel,
+) ([]model.TransactionDetailTaxModel, money.Money, error) {
+	taxes := make([]model.TransactionDetailTaxModel, 0, len(rates))
+	exclusive := money.Zero(amount.Currency)
+	if amount.Amount <= 0 || len(rates) == 0 {
+		return taxes, exclusive, nil
+	}
+
+	inclusiveBps := int64(0)
+	lastInclusive := -1
+	for i, r := range rates {
+		if r.Inclusive {
+			inclusiveBps += int64(r.RateBps)
+			lastInclusive = i
+		}
+	}
+	base, err := amount.MulDiv(10000, 10000+inclusiveBps)
+	if err != nil {
+		return nil, exclusive, err
+	}
+
+	// The last inclusive rate takes the rounding remainder, so the inclusive
+	// taxes always add up to the amount minus its base.
+	inclusiveLeft, err := amount.Sub(base)
+	if err != nil {
+		return nil, exclusive, err
+	}
+	for i, r := range rates {
+		if !r.Inclusive {
+			continue
+		}
+		taxAmount := inclusiveLeft
+		if i != lastInclusive {
+			if taxAmount, err = base.MulDiv(int64(r.RateBps), 10000); err != nil {
+				return nil, exclusive, err
+			}
+		}
+		if inclusiveLeft, err = inclusiveLeft.Sub(taxAmount); err != nil {
+			return nil, exclusive, err
+		}
+		taxes = append(taxes, newDetailTax(r, base, taxAmount))
+	}
+
+	// Rates are ordered with compound rates last, so the non-compound
+	// exclusive taxes are known before any compound rate is applied.
+	simple := money.Zero(amount.Currency)
+	for _, r := range rates {
+		if r.Inclusive {
+			continue
+		}
+		taxable := base
+		if r.Compound {
+			if taxable, err = taxable.Add(simple); err != nil {
+				return nil, exclusive, err
+			}
+		}
+		taxAmount, err := taxable.MulDiv(int64(r.RateBps), 10000)
+		if err != nil {
+			return nil, exclusive, err
+		}
+		if !r.Compound {
+			if simple, err = simple.Add(taxAmount); err != nil {
+				return nil, exclusive, err
+			}
+		}
+		if exclusive, err = exclusive.Add(taxAmount); err != nil {
+			return nil, exclusive, err
+		}
+		taxes = append(taxes, newDetailTax(r, taxable, taxAmount))
+	}
+
+	return taxes, exclusive, nil
+}
+
+func newDetailTax(r model.TaxRateModel, taxable money.Money, taxAmount money.Money) model.TransactionDetailTaxModel {
+	id := r.ID
+	return model.TransactionDetailTaxModel{
+		TaxRateID:     &id,
+		Name:          r.Name,
+		RateBps:       r.RateBps,
+		Inclusive:     r.Inclusive,
+		TaxableAmount: taxable,
+		TaxAmount:     taxAmount,
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/illusi03/golearn/internal/event"
 	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/money"
 	"github.com/illusi03/golearn/internal/repository"
 	"github.com/illusi03/golearn/internal/request"
 )
@@ -13,39 +17,112 @@ import (
 type TransactionService struct {
 	transactionRepository *repository.TransactionRepository
 	productRepository     *repository.ProductRepository
+	variantRepository     *repository.VariantRepository
+	modifierRepository    *repository.ModifierRepository
+	productService        *ProductService
+	promotionService      *PromotionService
+	taxRateService        *TaxRateService
+	storeService          *StoreService
+	customerRepository    *repository.CustomerRepository
+	loyaltyService        *LoyaltyService
+	bus                   *event.Bus
 }
 
 func NewTransactionService(
 	transactionRepository *repository.TransactionRepository,
 	productRepository *repository.ProductRepository,
+	variantRepository *repository.VariantRepository,
+	modifierRepository *repository.ModifierRepository,
+	productService *ProductService,
+	promotionService *PromotionService,
+	taxRateService *TaxRateService,
+	storeService *StoreService,
+	customerRepository *repository.CustomerRepository,
+	loyaltyService *LoyaltyService,
+	bus *event.Bus,
 ) *TransactionService {
 	return &TransactionService{
 		transactionRepository: transactionRepository,
 		productRepository:     productRepository,
+		variantRepository:     variantRepository,
+		modifierRepository:    modifierRepository,
+		productService:        productService,
+		promotionService:      promotionService,
+		taxRateService:        taxRateService,
+		storeService:          storeService,
+		customerRepository:    customerRepository,
+		loyaltyService:        loyaltyService,
+		bus:                   bus,
 	}
 }
 
 func (s *TransactionService) Checkout(
 	ctx context.Context,
 	req *request.CheckoutRequest,
+) (*model.TransactionModel, error) {
+	return s.checkout(ctx, req, nil)
+}
+
+// checkout prices and records a sale. When order is set the sale settles
+// that order: the stock it reserved counts as available to it, and the sale
+// is refused if the order changed since it was loaded.
+func (s *TransactionService) checkout(
+	ctx context.Context,
+	req *request.CheckoutRequest,
+	order *model.OrderModel,
 ) (*model.TransactionModel, error) {
 	if len(req.Items) == 0 {
 		return nil, errors.New("checkout items cannot be empty")
 	}
 
-	seenProducts := make(map[int]bool)
+	store, err := s.storeService.resolve(ctx, req.StoreID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CustomerID != nil {
+		customer, err := s.customerRepository.FindOne(ctx, *req.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		if customer == nil {
+			return nil, fmt.Errorf("customer with id %d not found", *req.CustomerID)
+		}
+	}
+
+	for i := range req.Items {
+		if err := s.resolveBarcode(ctx, &req.Items[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	// The same product may appear on several lines as long as the variant or
+	// modifiers differ
+	seenItems := make(map[string]bool)
 	for _, item := range req.Items {
 		if item.Quantity <= 0 {
 			return nil, errors.New("quantity must be greater than 0")
 		}
-		if seenProducts[item.ProductID] {
+		modifierIDs := slices.Clone(item.ModifierIDs)
+		slices.Sort(modifierIDs)
+		if len(slices.Compact(modifierIDs)) != len(item.ModifierIDs) {
+			return nil, errors.New("the requested modifier was duplicate")
+		}
+		variantID := 0
+		if item.VariantID != nil {
+			variantID = *item.VariantID
+		}
+		key := fmt.Sprint(item.ProductID, variantID, modifierIDs)
+		if seenItems[key] {
 			return nil, errors.New("the requested product was duplicate")
 		}
-		seenProducts[item.ProductID] = true
+		seenItems[key] = true
 	}
 
-	var totalAmount int
-	var details []model.TransactionDetailModel
+	details := make([]model.TransactionDetailModel, 0, len(req.Items))
+	categoryIDs := make([]*int, 0, len(req.Items))
+	unitPrices := make([]money.Money, 0, len(req.Items))
+	currency := ""
 
 	for _, item := range req.Items {
 		productID := item.ProductID
@@ -58,32 +135,322 @@ func (s *TransactionService) Checkout(
 			return nil, fmt.Errorf("product with id %d not found", productID)
 		}
 
-		if product.Stock < quantity {
-			return nil, fmt.Errorf("insufficient stock for product %s (available: %d, requested: %d)",
-				product.Name, product.Stock, quantity)
+		if currency == "" {
+			currency = product.Price.Currency
+		}
+		if product.Price.Currency != currency {
+			return nil, fmt.Errorf("all items must be priced in the same currency (%s is priced in %s, expected %s)",
+				product.Name, product.Price.Currency, currency)
 		}
 
-		subtotal := product.Price * quantity
-		totalAmount += subtotal
-
-		details = append(details, model.TransactionDetailModel{
+		detail := model.TransactionDetailModel{
 			ProductID:   productID,
 			ProductName: &product.Name,
 			Quantity:    quantity,
-			Subtotal:    subtotal,
-		})
+			UnitPrice:   product.Price,
+		}
+
+		variant, err := s.resolveVariant(ctx, product, item.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		var variantID *int
+		if variant != nil {
+			variantID = &variant.ID
+		}
+		var orderID *int
+		if order != nil {
+			orderID = &order.ID
+		}
+		stock, err := s.storeService.stock(ctx, store.ID, productID, variantID, orderID, req.CartID)
+		if err != nil {
+			return nil, err
+		}
+		// The items of an order that reserves stock are all reserved, so a
+		// shortfall means the store lost stock the order was promised
+		if order != nil && order.ReserveStock && stock < quantity {
+			return nil, fmt.Errorf("%w (product %d, on hand: %d, reserved: %d)",
+				repository.ErrOrderReservationLost, productID, max(stock, 0), quantity)
+		}
+		if variant != nil {
+			if stock < quantity {
+				return nil, fmt.Errorf("insufficient stock for product %s %s at %s (available: %d, requested: %d)",
+					product.Name, variant.Name, store.Name, stock, quantity)
+			}
+			detail.VariantID = &variant.ID
+			detail.VariantName = &variant.Name
+			detail.UnitPrice = variant.Price
+		} else if stock < quantity {
+			return nil, fmt.Errorf("insufficient stock for product %s at %s (available: %d, requested: %d)",
+				product.Name, store.Name, stock, quantity)
+		}
+
+		groups, err := s.modifierRepository.FindGroupsByProduct(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		detail.Modifiers, err = resolveModifiers(product, groups, item.ModifierIDs, quantity)
+		if err != nil {
+			return nil, err
+		}
+		for _, modifier := range detail.Modifiers {
+			if detail.UnitPrice, err = detail.UnitPrice.Add(modifier.PriceDelta); err != nil {
+				return nil, fmt.Errorf("unit price of product %s is too large: %w", product.Name, err)
+			}
+		}
+
+		detail.Subtotal, err = detail.UnitPrice.Mul(int64(quantity))
+		if err != nil {
+			return nil, fmt.Errorf("subtotal of product %s is too large: %w", product.Name, err)
+		}
+
+		details = append(details, detail)
+		categoryIDs = append(categoryIDs, product.CategoryID)
+		unitPrices = append(unitPrices, detail.UnitPrice)
 	}
 
 	transaction := &model.TransactionModel{
-		TotalAmount: totalAmount,
-		Details:     details,
+		Currency:   currency,
+		StoreID:    store.ID,
+		CustomerID: req.CustomerID,
+		CartID:     req.CartID,
+		Details:    details,
+	}
+	if order != nil {
+		transaction.OrderID = &order.ID
+		transaction.OrderVersion = order.Version
 	}
 
-	return s.transactionRepository.Create(ctx, transaction)
+	lines := make([]promotionLine, len(details))
+	for i := range details {
+		lines[i] = promotionLine{
+			detail:     &details[i],
+			categoryID: categoryIDs[i],
+			unitPrice:  unitPrices[i],
+		}
+	}
+	if err := s.promotionService.applyPromotions(ctx, transaction, lines, req.CouponCode, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.taxRateService.applyTaxes(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	payments, err := buildPayments(req.Payments, transaction.TotalAmount)
+	if err != nil {
+		return nil, err
+	}
+	transaction.Payments = payments
+	if err := s.loyaltyService.applyPoints(ctx, transaction, categoryIDs); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.transactionRepository.Create(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(event.TransactionCreated, &saved.StoreID, saved)
+	items := make([]event.StockItem, len(saved.Details))
+	for i, d := range saved.Details {
+		items[i] = event.StockItem{ProductID: d.ProductID, VariantID: d.VariantID}
+	}
+	s.bus.PublishStockChange(saved.StoreID, event.StockReasonSale, items)
+	return saved, nil
+}
+
+// validateItem checks an item the way checkout does, without pricing it or
+// looking at stock, so it can wait on an order until the order is settled.
+func (s *TransactionService) validateItem(ctx context.Context, item *request.CheckoutItem) error {
+	if err := s.resolveBarcode(ctx, item); err != nil {
+		return err
+	}
+	if item.Quantity <= 0 {
+		return errors.New("quantity must be greater than 0")
+	}
+	modifierIDs := slices.Clone(item.ModifierIDs)
+	slices.Sort(modifierIDs)
+	if len(slices.Compact(modifierIDs)) != len(item.ModifierIDs) {
+		return errors.New("the requested modifier was duplicate")
+	}
+
+	product, err := s.productRepository.FindOne(ctx, item.ProductID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return fmt.Errorf("product with id %d not found", item.ProductID)
+	}
+	if _, err := s.resolveVariant(ctx, product, item.VariantID); err != nil {
+		return err
+	}
+	groups, err := s.modifierRepository.FindGroupsByProduct(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	_, err = resolveModifiers(product, groups, item.ModifierIDs, item.Quantity)
+	return err
+}
+
+// resolveBarcode fills in the product, and the variant when the barcode
+// belongs to one, of an item that was scanned instead of picked by id.
+func (s *TransactionService) resolveBarcode(ctx context.Context, item *request.CheckoutItem) error {
+	return s.productService.resolveBarcode(ctx, item.Barcode, &item.ProductID, &item.VariantID)
+}
+
+// resolveVariant returns the variant picked for a line. Products that have
+// variants can only be sold through one of them.
+func (s *TransactionService) resolveVariant(
+	ctx context.Context,
+	product *model.ProductModel,
+	variantID *int,
+) (*model.ProductVariantModel, error) {
+	if variantID == nil {
+		variants, err := s.variantRepository.FindByProduct(ctx, product.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(variants) > 0 {
+			return nil, fmt.Errorf("a variant is required for product %s", product.Name)
+		}
+		return nil, nil
+	}
+
+	variant, err := s.variantRepository.FindOne(ctx, product.ID, *variantID)
+	if err != nil {
+		return nil, err
+	}
+	if variant == nil {
+		return nil, fmt.Errorf("variant with id %d not found for product %s", *variantID, product.Name)
+	}
+	return variant, nil
+}
+
+// resolveModifiers checks the picked modifiers against the modifier groups of
+// the product and snapshots them for the transaction line.
+func resolveModifiers(
+	product *model.ProductModel,
+	groups []model.ModifierGroupModel,
+	modifierIDs []int,
+	quantity int,
+) ([]model.TransactionDetailModifierModel, error) {
+	picked := make([]model.TransactionDetailModifierModel, 0, len(modifierIDs))
+	matched := 0
+
+	for _, group := range groups {
+		count := 0
+		for _, modifier := range group.Modifiers {
+			if !slices.Contains(modifierIDs, modifier.ID) {
+				continue
+			}
+			if modifier.Stock != nil && *modifier.Stock < quantity {
+				return nil, fmt.Errorf("insufficient stock for modifier %s (available: %d, requested: %d)",
+					modifier.Name, *modifier.Stock, quantity)
+			}
+			picked = append(picked, model.TransactionDetailModifierModel{
+				ModifierID: &modifier.ID,
+				GroupName:  group.Name,
+				Name:       modifier.Name,
+				PriceDelta: modifier.PriceDelta,
+			})
+			count++
+		}
+
+		if count < group.MinSelect {
+			return nil, fmt.Errorf("pick at least %d of %s for product %s", group.MinSelect, group.Name, product.Name)
+		}
+		if group.MaxSelect != nil && count > *group.MaxSelect {
+			return nil, fmt.Errorf("pick at most %d of %s for product %s", *group.MaxSelect, group.Name, product.Name)
+		}
+		matched += count
+	}
+
+	if matched != len(modifierIDs) {
+		return nil, fmt.Errorf("some modifiers do not belong to product %s", product.Name)
+	}
+	return picked, nil
 }
 
 func (s *TransactionService) FindAll(
 	ctx context.Context,
+	filter repository.TransactionFilter,
 ) ([]model.TransactionModel, error) {
-	return s.transactionRepository.FindAll(ctx)
+	return s.transactionRepository.FindAll(ctx, filter)
+}
+
+// buildPayments validates the tenders of a checkout. Payment amounts must add
+// up to the transaction total exactly; overpaying is only possible in cash,
+// through a tendered amount larger than the payment amount. A sale that
+// totals zero needs no payments.
+func buildPayments(
+	reqPayments []request.CheckoutPayment,
+	totalAmount money.Money,
+) ([]model.PaymentModel, error) {
+	if len(reqPayments) == 0 && !totalAmount.IsZero() {
+		return nil, errors.New("checkout payments cannot be empty")
+	}
+
+	payments := make([]model.PaymentModel, 0, len(reqPayments))
+	paidAmount := money.Zero(totalAmount.Currency)
+
+	for _, p := range reqPayments {
+		if p.Amount.Currency != totalAmount.Currency {
+			return nil, fmt.Errorf("payment currency %s does not match the transaction currency %s",
+				p.Amount.Currency, totalAmount.Currency)
+		}
+		if p.Amount.Amount <= 0 {
+			return nil, errors.New("payment amount must be greater than 0")
+		}
+
+		payment := model.PaymentModel{
+			Method:         p.Method,
+			Amount:         p.Amount,
+			TenderedAmount: p.Amount,
+			ChangeAmount:   money.Zero(p.Amount.Currency),
+			Reference:      p.Reference,
+		}
+
+		switch p.Method {
+		case model.PaymentMethodCash:
+			if !p.TenderedAmount.IsZero() {
+				if p.TenderedAmount.Currency != p.Amount.Currency {
+					return nil, errors.New("tendered cash must be in the payment currency")
+				}
+				if p.TenderedAmount.Amount < p.Amount.Amount {
+					return nil, fmt.Errorf("tendered cash (%s) is less than the payment amount (%s)",
+						p.TenderedAmount, p.Amount)
+				}
+				change, err := p.TenderedAmount.Sub(p.Amount)
+				if err != nil {
+					return nil, err
+				}
+				payment.TenderedAmount = p.TenderedAmount
+				payment.ChangeAmount = change
+			}
+		case model.PaymentMethodCard, model.PaymentMethodPoints:
+		case model.PaymentMethodQRIS, model.PaymentMethodEWallet:
+			if p.Reference == "" {
+				return nil, fmt.Errorf("reference is required for %s payments", p.Method)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported payment method %q", p.Method)
+		}
+
+		var err error
+		if paidAmount, err = paidAmount.Add(p.Amount); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	if paidAmount.Amount < totalAmount.Amount {
+		return nil, fmt.Errorf("payments do not cover the total (total: %s, paid: %s)",
+			totalAmount, paidAmount)
+	}
+	if paidAmount.Amount > totalAmount.Amount {
+		return nil, fmt.Errorf("payments exceed the total (total: %s, paid: %s), use tendered_amount for cash change",
+			totalAmount, paidAmount)
+	}
+
+	return payments, nil
 }
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/illusi03/golearn/internal/model"
+	"github.com/illusi03/golearn/internal/repository"
+)
+
+type VariantService struct {
+	variantRepository *repository.VariantRepository
+	productRepository *repository.ProductRepository
+}
+
+func NewVariantService(
+	variantRepository *repository.VariantRepository,
+	productRepository *repository.ProductRepository,
+) *VariantService {
+	return &VariantService{
+		variantRepository: variantRepository,
+		productRepository: productRepository,
+	}
+}
+
+// FindByProduct returns nil when the product does not exist.
+func (s *VariantService) FindByProduct(ctx context.Context, productID int) ([]model.ProductVariantModel, error) {
+	product, err := s.productRepository.FindOne(ctx, productID)
+	if err != nil || product == nil {
+		return nil, err
+	}
+	return s.variantRepository.FindByProduct(ctx, productID)
+}
+
+func (s *VariantService) Delete(ctx context.Context, productID int, id int) (bool, error) {
+	return s.variantRepository.Delete(ctx, productID, id)
+}
+
+func (s *VariantService) Update(ctx context.Context, variant *model.ProductVariantModel) (bool, error) {
+	if err := s.validate(ctx, variant); err != nil {
+		return false, err
+	}
+	return s.variantRepository.Update(ctx, variant)
+}
+
+func (s *VariantService) Create(ctx context.Context, variant *model.ProductVariantModel) (*model.ProductVariantModel, error) {
+	if err := s.validate(ctx, variant); err != nil {
+		return nil, err
+	}
+	return s.variantRepository.Create(ctx, variant)
+}
+
+func (s *VariantService) validate(ctx context.Context, v *model.ProductVariantModel) error {
+	v.Name = strings.TrimSpace(v.Name)
+	if v.Name == "" {
+		return errors.New("variant name is required")
+	}
+	if v.SKU != nil {
+		sku := strings.TrimSpace(*v.SKU)
+		if sku == "" {
+			v.SKU = nil
+		} else {
+			v.SKU = &sku
+		}
+	}
+	if v.Stock < 0 {
+		return errors.New("stock cannot be negative")
+	}
+
+	product, err := s.productRepository.FindOne(ctx, v.ProductID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return repository.ErrProductNotFound
+	}
+
+	if v.Price.Currency == "" {
+		v.Price.Currency = product.Price.Currency
+	}
+	if v.Price.Currency != product.Price.Currency {
+		return fmt.Errorf("variant must be priced in the product currency %s", product.Price.Currency)
+	}
+	if v.Price.IsNegative() {
+		return errors.New("price cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Package storage keeps uploaded files behind a small interface so the
+// backend can be switched between the local filesystem and an S3-compatible
+// object store without touching the services that use it.
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+type Storage interface {
+	// Put stores data under key, replacing any existing object.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete removes the object under key. Deleting a missing object is not
+	// an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL the object under key is served from.
+	URL(key string) string
+}
+
+// validateKey only accepts relative slash separated keys without dot
+// segments, so a key can never escape the storage root.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
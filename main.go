@@ -16,7 +16,26 @@ func main() {
 		_ = viper.ReadInConfig()
 	}
 	cmd.InitApi(&cmd.ApiConfig{
-		Port:   viper.GetInt("PORT"),
-		DBConn: viper.GetString("DB_CONN"),
+		Port:     viper.GetInt("PORT"),
+		DBConn:   viper.GetString("DB_CONN"),
+		Currency: viper.GetString("CURRENCY"),
+
+		AdminToken: viper.GetString("ADMIN_TOKEN"),
+
+		StorageDriver: viper.GetString("STORAGE_DRIVER"),
+		StorageDir:    viper.GetString("STORAGE_DIR"),
+		StorageURL:    viper.GetString("STORAGE_URL"),
+		S3Endpoint:    viper.GetString("S3_ENDPOINT"),
+		S3Region:      viper.GetString("S3_REGION"),
+		S3Bucket:      viper.GetString("S3_BUCKET"),
+		S3AccessKey:   viper.GetString("S3_ACCESS_KEY"),
+		S3SecretKey:   viper.GetString("S3_SECRET_KEY"),
+		S3PublicURL:   viper.GetString("S3_PUBLIC_URL"),
+		ImageMaxBytes: viper.GetInt64("IMAGE_MAX_BYTES"),
+
+		PriceWorkerInterval:   viper.GetDuration("PRICE_WORKER_INTERVAL"),
+		LoyaltyWorkerInterval: viper.GetDuration("LOYALTY_WORKER_INTERVAL"),
+		CartReservationTTL:    viper.GetDuration("CART_RESERVATION_TTL"),
+		CartWorkerInterval:    viper.GetDuration("CART_WORKER_INTERVAL"),
 	})
 }
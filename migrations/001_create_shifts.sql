@@ -0,0 +1,26 @@
+-- Cashier shifts: a shift is opened with a starting cash float and closed
+-- with the counted cash in the drawer. Every checkout is linked to the shift
+-- that was open when it happened.
+CREATE TABLE IF NOT EXISTS shifts (
+	id SERIAL PRIMARY KEY,
+	status VARCHAR(16) NOT NULL DEFAULT 'open',
+	opening_float INT NOT NULL DEFAULT 0,
+	expected_cash INT,
+	counted_cash INT,
+	variance INT,
+	opening_note TEXT NOT NULL DEFAULT '',
+	closing_note TEXT NOT NULL DEFAULT '',
+	opened_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	closed_at TIMESTAMPTZ,
+	CONSTRAINT shifts_status_check CHECK (status IN ('open', 'closed'))
+);
+
+-- Only one shift may be open at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS shifts_single_open_idx
+	ON shifts (status)
+	WHERE status = 'open';
+
+ALTER TABLE transactions
+	ADD COLUMN IF NOT EXISTS shift_id INT REFERENCES shifts(id);
+
+CREATE INDEX IF NOT EXISTS transactions_shift_id_idx ON transactions (shift_id);
@@ -0,0 +1,23 @@
+-- Payments record how a transaction was settled. A transaction may be split
+-- across several tenders; cash payments keep the tendered amount and change.
+CREATE TABLE IF NOT EXISTS payments (
+	id SERIAL PRIMARY KEY,
+	transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+	method VARCHAR(32) NOT NULL,
+	amount INT NOT NULL,
+	tendered_amount INT NOT NULL,
+	change_amount INT NOT NULL DEFAULT 0,
+	reference VARCHAR(128) NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT payments_method_check CHECK (method IN ('cash', 'card', 'qris', 'e_wallet')),
+	CONSTRAINT payments_amount_check CHECK (amount > 0 AND tendered_amount >= amount)
+);
+
+CREATE INDEX IF NOT EXISTS payments_transaction_id_idx ON payments (transaction_id);
+
+-- Transactions created before payments were tracked were settled in cash.
+INSERT INTO payments (transaction_id, method, amount, tendered_amount, created_at)
+SELECT t.id, 'cash', t.total_amount, t.total_amount, t.created_at
+FROM transactions t
+WHERE t.total_amount > 0
+	AND NOT EXISTS (SELECT 1 FROM payments p WHERE p.transaction_id = t.id);
@@ -0,0 +1,53 @@
+-- Promotions give line-level or cart-level discounts at checkout. A promotion
+-- can be limited to a product or category, to a date range and to a daily
+-- time window (happy hour). Promotions that require a coupon are only applied
+-- when one of their coupon codes is presented.
+CREATE TABLE IF NOT EXISTS promotions (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	type VARCHAR(32) NOT NULL,
+	scope VARCHAR(16) NOT NULL,
+	value INT NOT NULL DEFAULT 0,
+	buy_quantity INT NOT NULL DEFAULT 0,
+	get_quantity INT NOT NULL DEFAULT 0,
+	product_id INT REFERENCES products(id) ON DELETE CASCADE,
+	category_id INT REFERENCES categories(id) ON DELETE CASCADE,
+	min_subtotal INT NOT NULL DEFAULT 0,
+	requires_coupon BOOLEAN NOT NULL DEFAULT FALSE,
+	starts_at TIMESTAMPTZ,
+	ends_at TIMESTAMPTZ,
+	daily_start_time TIME,
+	daily_end_time TIME,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT promotions_type_check CHECK (type IN ('percentage', 'fixed', 'buy_x_get_y')),
+	CONSTRAINT promotions_scope_check CHECK (scope IN ('line', 'cart'))
+);
+
+CREATE TABLE IF NOT EXISTS coupons (
+	id SERIAL PRIMARY KEY,
+	code VARCHAR(64) NOT NULL,
+	promotion_id INT NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+	usage_limit INT,
+	used_count INT NOT NULL DEFAULT 0,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	starts_at TIMESTAMPTZ,
+	ends_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT coupons_usage_check CHECK (usage_limit IS NULL OR used_count <= usage_limit)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS coupons_code_idx ON coupons (UPPER(code));
+
+ALTER TABLE transactions
+	ADD COLUMN IF NOT EXISTS subtotal_amount INT NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS discount_amount INT NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS promotion_id INT REFERENCES promotions(id) ON DELETE SET NULL,
+	ADD COLUMN IF NOT EXISTS coupon_id INT REFERENCES coupons(id) ON DELETE SET NULL;
+
+UPDATE transactions SET subtotal_amount = total_amount WHERE subtotal_amount = 0;
+
+ALTER TABLE transaction_details
+	ADD COLUMN IF NOT EXISTS discount_amount INT NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS promotion_id INT REFERENCES promotions(id) ON DELETE SET NULL;
@@ -0,0 +1,50 @@
+-- Tax rates (PPN, PB1, service charge, ...) in basis points: 1100 = 11%.
+-- Inclusive rates are already part of the selling price; exclusive rates are
+-- added on top. Compound rates are charged on the price plus the non-compound
+-- exclusive taxes, e.g. PB1 on top of a service charge.
+CREATE TABLE IF NOT EXISTS tax_rates (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	code VARCHAR(32) NOT NULL DEFAULT '',
+	rate_bps INT NOT NULL,
+	inclusive BOOLEAN NOT NULL DEFAULT FALSE,
+	compound BOOLEAN NOT NULL DEFAULT FALSE,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT tax_rates_rate_check CHECK (rate_bps >= 0 AND rate_bps <= 10000)
+);
+
+-- Rates assigned to a product replace the rates of its category.
+CREATE TABLE IF NOT EXISTS product_tax_rates (
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	tax_rate_id INT NOT NULL REFERENCES tax_rates(id) ON DELETE CASCADE,
+	PRIMARY KEY (product_id, tax_rate_id)
+);
+
+CREATE TABLE IF NOT EXISTS category_tax_rates (
+	category_id INT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+	tax_rate_id INT NOT NULL REFERENCES tax_rates(id) ON DELETE CASCADE,
+	PRIMARY KEY (category_id, tax_rate_id)
+);
+
+ALTER TABLE transactions
+	ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0;
+
+ALTER TABLE transaction_details
+	ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0;
+
+-- Taxes charged per line, kept with the rate that applied at the time of sale
+-- so reports stay correct after a rate is changed.
+CREATE TABLE IF NOT EXISTS transaction_detail_taxes (
+	id SERIAL PRIMARY KEY,
+	transaction_detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+	tax_rate_id INT REFERENCES tax_rates(id) ON DELETE SET NULL,
+	name VARCHAR(255) NOT NULL,
+	rate_bps INT NOT NULL,
+	inclusive BOOLEAN NOT NULL,
+	taxable_amount INT NOT NULL,
+	tax_amount INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS transaction_detail_taxes_detail_id_idx
+	ON transaction_detail_taxes (transaction_detail_id);
@@ -0,0 +1,43 @@
+-- Money is stored as BIGINT minor units next to an ISO 4217 currency code.
+-- Amounts that belong to a transaction (details, payments, taxes) use the
+-- currency of their transaction.
+ALTER TABLE products
+	ALTER COLUMN price TYPE BIGINT,
+	ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
+
+ALTER TABLE transactions
+	ALTER COLUMN subtotal_amount TYPE BIGINT,
+	ALTER COLUMN discount_amount TYPE BIGINT,
+	ALTER COLUMN tax_amount TYPE BIGINT,
+	ALTER COLUMN total_amount TYPE BIGINT,
+	ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
+
+ALTER TABLE transaction_details
+	ALTER COLUMN subtotal TYPE BIGINT,
+	ALTER COLUMN discount_amount TYPE BIGINT,
+	ALTER COLUMN tax_amount TYPE BIGINT;
+
+ALTER TABLE transaction_detail_taxes
+	ALTER COLUMN taxable_amount TYPE BIGINT,
+	ALTER COLUMN tax_amount TYPE BIGINT;
+
+ALTER TABLE payments
+	ALTER COLUMN amount TYPE BIGINT,
+	ALTER COLUMN tendered_amount TYPE BIGINT,
+	ALTER COLUMN change_amount TYPE BIGINT;
+
+ALTER TABLE shifts
+	ALTER COLUMN opening_float TYPE BIGINT,
+	ALTER COLUMN expected_cash TYPE BIGINT,
+	ALTER COLUMN counted_cash TYPE BIGINT,
+	ALTER COLUMN variance TYPE BIGINT,
+	ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
+
+-- Fixed promotions keep their amount in a money column; value is now only
+-- used as the percentage of percentage promotions.
+ALTER TABLE promotions
+	ADD COLUMN IF NOT EXISTS amount BIGINT NOT NULL DEFAULT 0,
+	ALTER COLUMN min_subtotal TYPE BIGINT,
+	ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
+
+UPDATE promotions SET amount = value, value = 0 WHERE type = 'fixed';
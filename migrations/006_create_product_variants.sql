@@ -0,0 +1,71 @@
+-- Variants are sellable versions of a product (sizes, colours, ...) with their
+-- own SKU, price and stock. A product that has variants is always sold through
+-- one of them, so its own price and stock are not used at checkout.
+CREATE TABLE IF NOT EXISTS product_variants (
+	id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL,
+	sku VARCHAR(64),
+	price BIGINT NOT NULL DEFAULT 0,
+	stock INT NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT product_variants_price_check CHECK (price >= 0),
+	CONSTRAINT product_variants_stock_check CHECK (stock >= 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_variants_sku_key ON product_variants (UPPER(sku)) WHERE sku IS NOT NULL;
+CREATE INDEX IF NOT EXISTS product_variants_product_id_idx ON product_variants (product_id);
+
+-- Modifier groups hold the add-ons of a product (extra shot, no sugar, ...).
+-- min_select/max_select bound how many modifiers of the group a line may pick;
+-- a NULL max_select means no upper bound.
+CREATE TABLE IF NOT EXISTS modifier_groups (
+	id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL,
+	min_select INT NOT NULL DEFAULT 0,
+	max_select INT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT modifier_groups_select_check CHECK (
+		min_select >= 0 AND (max_select IS NULL OR max_select >= min_select)
+	)
+);
+
+CREATE INDEX IF NOT EXISTS modifier_groups_product_id_idx ON modifier_groups (product_id);
+
+-- A modifier adds price_delta to the unit price. Stock is only tracked when
+-- it is not NULL, e.g. for add-ons that consume an ingredient.
+CREATE TABLE IF NOT EXISTS modifiers (
+	id SERIAL PRIMARY KEY,
+	group_id INT NOT NULL REFERENCES modifier_groups(id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL,
+	price_delta BIGINT NOT NULL DEFAULT 0,
+	stock INT,
+	CONSTRAINT modifiers_price_delta_check CHECK (price_delta >= 0),
+	CONSTRAINT modifiers_stock_check CHECK (stock IS NULL OR stock >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS modifiers_group_id_idx ON modifiers (group_id);
+
+-- Lines keep the variant and unit price they were sold at.
+ALTER TABLE transaction_details
+	ADD COLUMN IF NOT EXISTS variant_id INT REFERENCES product_variants(id) ON DELETE SET NULL,
+	ADD COLUMN IF NOT EXISTS variant_name VARCHAR(255),
+	ADD COLUMN IF NOT EXISTS unit_price BIGINT NOT NULL DEFAULT 0;
+
+UPDATE transaction_details
+SET unit_price = subtotal / quantity
+WHERE unit_price = 0 AND quantity > 0;
+
+-- Modifiers picked per line, kept by name and price at the time of sale.
+CREATE TABLE IF NOT EXISTS transaction_detail_modifiers (
+	id SERIAL PRIMARY KEY,
+	transaction_detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+	modifier_id INT REFERENCES modifiers(id) ON DELETE SET NULL,
+	group_name VARCHAR(255) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	price_delta BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS transaction_detail_modifiers_detail_id_idx
+	ON transaction_detail_modifiers (transaction_detail_id);
@@ -0,0 +1,17 @@
+ALTER TABLE products
+	ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS products_sku_key ON products (UPPER(sku)) WHERE sku IS NOT NULL;
+
+-- Barcodes are stored normalized (UPC-A widened to EAN-13) so one code maps
+-- to exactly one product, or to one variant of it.
+CREATE TABLE IF NOT EXISTS product_barcodes (
+	id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE,
+	barcode VARCHAR(14) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT product_barcodes_barcode_key UNIQUE (barcode)
+);
+
+CREATE INDEX IF NOT EXISTS product_barcodes_product_id_idx ON product_barcodes (product_id);
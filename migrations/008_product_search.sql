@@ -0,0 +1,44 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Weighted search document: name and SKU rank above the category name, which
+-- ranks above the description. The 'simple' configuration does no stemming,
+-- which suits a catalogue that mixes Indonesian and English names.
+ALTER TABLE products
+	ADD COLUMN IF NOT EXISTS search_vector TSVECTOR NOT NULL DEFAULT ''::tsvector;
+
+CREATE OR REPLACE FUNCTION products_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+	NEW.search_vector :=
+		setweight(to_tsvector('simple', COALESCE(NEW.name, '')), 'A') ||
+		setweight(to_tsvector('simple', COALESCE(NEW.sku, '')), 'A') ||
+		setweight(to_tsvector('simple', COALESCE((SELECT c.name FROM categories c WHERE c.id = NEW.category_id), '')), 'B') ||
+		setweight(to_tsvector('simple', COALESCE(NEW.description, '')), 'C');
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_search_vector_trigger ON products;
+CREATE TRIGGER products_search_vector_trigger
+	BEFORE INSERT OR UPDATE OF name, sku, description, category_id ON products
+	FOR EACH ROW EXECUTE FUNCTION products_search_vector_update();
+
+-- Renaming a category refreshes the documents of its products.
+CREATE OR REPLACE FUNCTION categories_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+	UPDATE products SET category_id = category_id WHERE category_id = NEW.id;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS categories_search_vector_trigger ON categories;
+CREATE TRIGGER categories_search_vector_trigger
+	AFTER UPDATE OF name ON categories
+	FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+	EXECUTE FUNCTION categories_search_vector_update();
+
+UPDATE products SET name = name;
+
+CREATE INDEX IF NOT EXISTS products_search_vector_idx ON products USING GIN (search_vector);
+
+-- Trigram index for the typo-tolerant fallback and autocomplete.
+CREATE INDEX IF NOT EXISTS products_name_trgm_idx ON products USING GIN (name gin_trgm_ops);
@@ -0,0 +1,19 @@
+-- Uploaded product images. The storage keys are kept to delete the files, the
+-- URLs are what clients get. The image with the lowest position is the
+-- primary image shown in product lists.
+CREATE TABLE IF NOT EXISTS product_images (
+	id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	storage_key VARCHAR(512) NOT NULL,
+	thumbnail_key VARCHAR(512) NOT NULL,
+	url TEXT NOT NULL,
+	thumbnail_url TEXT NOT NULL,
+	content_type VARCHAR(64) NOT NULL,
+	size_bytes BIGINT NOT NULL,
+	width INT NOT NULL,
+	height INT NOT NULL,
+	position INT NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS product_images_product_id_idx ON product_images (product_id, position);
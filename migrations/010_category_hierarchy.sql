@@ -0,0 +1,11 @@
+-- Categories form a tree of arbitrary depth. Moves are checked for cycles in
+-- the application; the check constraint only rules out the trivial one.
+ALTER TABLE categories
+	ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES categories(id) ON DELETE SET NULL;
+
+ALTER TABLE categories
+	DROP CONSTRAINT IF EXISTS categories_parent_check;
+ALTER TABLE categories
+	ADD CONSTRAINT categories_parent_check CHECK (parent_id <> id);
+
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id);
@@ -0,0 +1,15 @@
+-- Products must never point at a category that no longer exists. Deleting a
+-- category in use is handled explicitly by the application (reject, reassign
+-- or nullify), so the foreign key only has to refuse the raw delete.
+UPDATE products p
+SET category_id = NULL
+WHERE p.category_id IS NOT NULL
+	AND NOT EXISTS (SELECT 1 FROM categories c WHERE c.id = p.category_id);
+
+ALTER TABLE products
+	DROP CONSTRAINT IF EXISTS products_category_id_fkey;
+ALTER TABLE products
+	ADD CONSTRAINT products_category_id_fkey
+	FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS products_category_id_idx ON products (category_id);
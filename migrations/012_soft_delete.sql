@@ -0,0 +1,34 @@
+-- Products and categories are soft deleted so past transactions keep their
+-- product and category names. Deleted rows stay until they are restored.
+ALTER TABLE products
+	ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE categories
+	ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS products_deleted_at_idx ON products (deleted_at);
+CREATE INDEX IF NOT EXISTS categories_deleted_at_idx ON categories (deleted_at);
+
+-- A deleted product no longer holds on to its SKU.
+DROP INDEX IF EXISTS products_sku_key;
+CREATE UNIQUE INDEX IF NOT EXISTS products_sku_key ON products (UPPER(sku))
+	WHERE sku IS NOT NULL AND deleted_at IS NULL;
+
+-- Products cannot be put in a deleted category. Rows already pointing at one
+-- are left alone so deleted products keep their history.
+CREATE OR REPLACE FUNCTION products_category_live_check() RETURNS TRIGGER AS $$
+BEGIN
+	IF NEW.category_id IS NOT NULL
+		AND (TG_OP = 'INSERT' OR NEW.category_id IS DISTINCT FROM OLD.category_id)
+		AND EXISTS (SELECT 1 FROM categories c WHERE c.id = NEW.category_id AND c.deleted_at IS NOT NULL)
+	THEN
+		RAISE EXCEPTION 'category % is deleted', NEW.category_id
+			USING ERRCODE = 'foreign_key_violation';
+	END IF;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_category_live_trigger ON products;
+CREATE TRIGGER products_category_live_trigger
+	BEFORE INSERT OR UPDATE OF category_id ON products
+	FOR EACH ROW EXECUTE FUNCTION products_category_live_check();
@@ -0,0 +1,6 @@
+-- Row versions for optimistic concurrency. Every write bumps the version and
+-- clients send the version they read back in If-Match.
+ALTER TABLE products
+	ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE categories
+	ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
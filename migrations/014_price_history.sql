@@ -0,0 +1,62 @@
+-- Every price a product had, written by a trigger so no code path can change
+-- a price without leaving a trace. The source of a change is taken from the
+-- app.price_change_source setting of the transaction, 'manual' by default.
+CREATE TABLE IF NOT EXISTS price_history (
+	id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	old_price BIGINT,
+	old_currency VARCHAR(3),
+	new_price BIGINT NOT NULL,
+	new_currency VARCHAR(3) NOT NULL,
+	source VARCHAR(32) NOT NULL DEFAULT 'manual',
+	changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS price_history_product_id_idx ON price_history (product_id, changed_at);
+
+CREATE OR REPLACE FUNCTION products_price_history() RETURNS TRIGGER AS $$
+DECLARE
+	change_source VARCHAR(32) := COALESCE(NULLIF(current_setting('app.price_change_source', true), ''), 'manual');
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO price_history (product_id, new_price, new_currency, source)
+		VALUES (NEW.id, NEW.price, NEW.currency, change_source);
+	ELSIF NEW.price IS DISTINCT FROM OLD.price OR NEW.currency IS DISTINCT FROM OLD.currency THEN
+		INSERT INTO price_history (product_id, old_price, old_currency, new_price, new_currency, source)
+		VALUES (NEW.id, OLD.price, OLD.currency, NEW.price, NEW.currency, change_source);
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_price_history_trigger ON products;
+CREATE TRIGGER products_price_history_trigger
+	AFTER INSERT OR UPDATE OF price, currency ON products
+	FOR EACH ROW EXECUTE FUNCTION products_price_history();
+
+-- Products that existed before the history start with their current price.
+INSERT INTO price_history (product_id, new_price, new_currency, source)
+SELECT p.id, p.price, p.currency, 'initial'
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM price_history h WHERE h.product_id = p.id);
+
+-- Price changes scheduled for later. The price worker applies pending changes
+-- once effective_at has passed, in effective_at order.
+CREATE TABLE IF NOT EXISTS scheduled_price_changes (
+	id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	price BIGINT NOT NULL,
+	effective_at TIMESTAMPTZ NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'pending',
+	note TEXT NOT NULL DEFAULT '',
+	failure_reason TEXT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	applied_at TIMESTAMPTZ,
+	CONSTRAINT scheduled_price_changes_price_check CHECK (price >= 0),
+	CONSTRAINT scheduled_price_changes_status_check CHECK (status IN ('pending', 'applied', 'cancelled', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_price_changes_due_idx
+	ON scheduled_price_changes (effective_at)
+	WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS scheduled_price_changes_product_id_idx ON scheduled_price_changes (product_id);
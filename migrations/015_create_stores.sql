@@ -0,0 +1,172 @@
+-- Outlets of the business. Stock, shifts and transactions belong to a store;
+-- the default store takes everything that existed before stores did and
+-- every request that does not name a store.
+CREATE TABLE IF NOT EXISTS stores (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	code VARCHAR(32) NOT NULL,
+	address TEXT NOT NULL DEFAULT '',
+	is_default BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT stores_code_key UNIQUE (code)
+);
+
+-- Exactly one store is the default.
+CREATE UNIQUE INDEX IF NOT EXISTS stores_single_default_idx
+	ON stores (is_default)
+	WHERE is_default;
+
+INSERT INTO stores (name, code, is_default)
+SELECT 'Main store', 'MAIN', TRUE
+WHERE NOT EXISTS (SELECT 1 FROM stores WHERE is_default);
+
+-- Stock of a product, or of one of its variants, held by a store. A missing
+-- row is the same as no stock.
+CREATE TABLE IF NOT EXISTS store_stocks (
+	id SERIAL PRIMARY KEY,
+	store_id INT NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE,
+	stock INT NOT NULL DEFAULT 0,
+	CONSTRAINT store_stocks_stock_check CHECK (stock >= 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS store_stocks_item_idx
+	ON store_stocks (store_id, product_id, COALESCE(variant_id, 0));
+
+CREATE INDEX IF NOT EXISTS store_stocks_product_id_idx ON store_stocks (product_id);
+
+-- Everything in stock so far sits in the default store.
+INSERT INTO store_stocks (store_id, product_id, stock)
+SELECT s.id, p.id, p.stock
+FROM products p, stores s
+WHERE s.is_default
+ON CONFLICT DO NOTHING;
+
+INSERT INTO store_stocks (store_id, product_id, variant_id, stock)
+SELECT s.id, v.product_id, v.id, v.stock
+FROM product_variants v, stores s
+WHERE s.is_default
+ON CONFLICT DO NOTHING;
+
+-- products.stock and product_variants.stock hold the total over all stores.
+CREATE OR REPLACE FUNCTION store_stocks_sync_total() RETURNS TRIGGER AS $$
+DECLARE
+	item store_stocks%ROWTYPE;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		item := OLD;
+	ELSE
+		item := NEW;
+	END IF;
+
+	IF item.variant_id IS NULL THEN
+		UPDATE products p
+		SET stock = t.total
+		FROM (
+			SELECT COALESCE(SUM(stock), 0) AS total
+			FROM store_stocks
+			WHERE product_id = item.product_id AND variant_id IS NULL
+		) t
+		WHERE p.id = item.product_id AND p.stock IS DISTINCT FROM t.total;
+	ELSE
+		UPDATE product_variants v
+		SET stock = t.total
+		FROM (
+			SELECT COALESCE(SUM(stock), 0) AS total
+			FROM store_stocks
+			WHERE variant_id = item.variant_id
+		) t
+		WHERE v.id = item.variant_id AND v.stock IS DISTINCT FROM t.total;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS store_stocks_sync_total_trigger ON store_stocks;
+CREATE TRIGGER store_stocks_sync_total_trigger
+	AFTER INSERT OR UPDATE OR DELETE ON store_stocks
+	FOR EACH ROW EXECUTE FUNCTION store_stocks_sync_total();
+
+-- Stock written straight to a product or variant, from the catalog endpoints
+-- or an import, is applied to the default store as the difference with the
+-- previous total. Taking more than the default store holds violates
+-- store_stocks_stock_check. Updates made by the sync trigger above run at a
+-- deeper trigger level and are skipped.
+CREATE OR REPLACE FUNCTION catalog_stock_to_default_store() RETURNS TRIGGER AS $$
+DECLARE
+	delta INT;
+	item_product_id INT;
+	item_variant_id INT;
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		delta := NEW.stock;
+	ELSE
+		delta := NEW.stock - OLD.stock;
+	END IF;
+
+	IF TG_TABLE_NAME = 'product_variants' THEN
+		item_product_id := NEW.product_id;
+		item_variant_id := NEW.id;
+	ELSE
+		item_product_id := NEW.id;
+	END IF;
+
+	INSERT INTO store_stocks (store_id, product_id, variant_id, stock)
+	SELECT s.id, item_product_id, item_variant_id, delta
+	FROM stores s
+	WHERE s.is_default
+	ON CONFLICT (store_id, product_id, COALESCE(variant_id, 0))
+	DO UPDATE SET stock = store_stocks.stock + EXCLUDED.stock;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_stock_insert_trigger ON products;
+CREATE TRIGGER products_stock_insert_trigger
+	AFTER INSERT ON products
+	FOR EACH ROW WHEN (pg_trigger_depth() = 0)
+	EXECUTE FUNCTION catalog_stock_to_default_store();
+
+DROP TRIGGER IF EXISTS products_stock_update_trigger ON products;
+CREATE TRIGGER products_stock_update_trigger
+	AFTER UPDATE OF stock ON products
+	FOR EACH ROW WHEN (pg_trigger_depth() = 0 AND NEW.stock IS DISTINCT FROM OLD.stock)
+	EXECUTE FUNCTION catalog_stock_to_default_store();
+
+DROP TRIGGER IF EXISTS product_variants_stock_insert_trigger ON product_variants;
+CREATE TRIGGER product_variants_stock_insert_trigger
+	AFTER INSERT ON product_variants
+	FOR EACH ROW WHEN (pg_trigger_depth() = 0)
+	EXECUTE FUNCTION catalog_stock_to_default_store();
+
+DROP TRIGGER IF EXISTS product_variants_stock_update_trigger ON product_variants;
+CREATE TRIGGER product_variants_stock_update_trigger
+	AFTER UPDATE OF stock ON product_variants
+	FOR EACH ROW WHEN (pg_trigger_depth() = 0 AND NEW.stock IS DISTINCT FROM OLD.stock)
+	EXECUTE FUNCTION catalog_stock_to_default_store();
+
+-- Shifts and transactions happen in a store.
+ALTER TABLE shifts
+	ADD COLUMN IF NOT EXISTS store_id INT REFERENCES stores(id);
+
+UPDATE shifts SET store_id = (SELECT id FROM stores WHERE is_default)
+WHERE store_id IS NULL;
+
+ALTER TABLE shifts ALTER COLUMN store_id SET NOT NULL;
+
+-- One open shift per store instead of one overall.
+DROP INDEX IF EXISTS shifts_single_open_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS shifts_store_open_idx
+	ON shifts (store_id)
+	WHERE status = 'open';
+
+ALTER TABLE transactions
+	ADD COLUMN IF NOT EXISTS store_id INT REFERENCES stores(id);
+
+UPDATE transactions SET store_id = (SELECT id FROM stores WHERE is_default)
+WHERE store_id IS NULL;
+
+ALTER TABLE transactions ALTER COLUMN store_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS transactions_store_id_idx ON transactions (store_id, created_at);
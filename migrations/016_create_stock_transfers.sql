@@ -0,0 +1,42 @@
+-- Transfer orders move stock between stores. A transfer is requested, then
+-- dispatched, which takes the stock out of the source store, and finally
+-- received, which puts it into the destination store. While a transfer is
+-- in transit its stock is counted as incoming at the destination store and
+-- is not part of the product totals.
+CREATE TABLE IF NOT EXISTS stock_transfers (
+	id SERIAL PRIMARY KEY,
+	source_store_id INT NOT NULL REFERENCES stores(id),
+	destination_store_id INT NOT NULL REFERENCES stores(id),
+	status VARCHAR(16) NOT NULL DEFAULT 'requested',
+	note TEXT NOT NULL DEFAULT '',
+	requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	dispatched_at TIMESTAMPTZ,
+	received_at TIMESTAMPTZ,
+	cancelled_at TIMESTAMPTZ,
+	CONSTRAINT stock_transfers_status_check CHECK (status IN ('requested', 'in_transit', 'received', 'cancelled')),
+	CONSTRAINT stock_transfers_stores_check CHECK (source_store_id <> destination_store_id)
+);
+
+CREATE INDEX IF NOT EXISTS stock_transfers_source_store_id_idx ON stock_transfers (source_store_id, requested_at);
+CREATE INDEX IF NOT EXISTS stock_transfers_destination_store_id_idx ON stock_transfers (destination_store_id, requested_at);
+
+CREATE TABLE IF NOT EXISTS stock_transfer_items (
+	id SERIAL PRIMARY KEY,
+	transfer_id INT NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+	product_id INT NOT NULL REFERENCES products(id),
+	variant_id INT REFERENCES product_variants(id),
+	quantity INT NOT NULL,
+	CONSTRAINT stock_transfer_items_quantity_check CHECK (quantity > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS stock_transfer_items_item_idx
+	ON stock_transfer_items (transfer_id, product_id, COALESCE(variant_id, 0));
+
+CREATE INDEX IF NOT EXISTS stock_transfer_items_product_id_idx ON stock_transfer_items (product_id);
+
+ALTER TABLE store_stocks
+	ADD COLUMN IF NOT EXISTS incoming INT NOT NULL DEFAULT 0;
+
+ALTER TABLE store_stocks DROP CONSTRAINT IF EXISTS store_stocks_incoming_check;
+ALTER TABLE store_stocks
+	ADD CONSTRAINT store_stocks_incoming_check CHECK (incoming >= 0);
@@ -0,0 +1,51 @@
+-- Stocktakes reconcile the stock of a store with a physical count. Opening a
+-- stocktake snapshots the stock of every live product and variant in the
+-- store; counts are added up as staff submit them, from any number of
+-- devices. Finalizing applies the variance between count and snapshot to the
+-- current stock, so sales made during the count are kept, and records every
+-- change as a stock adjustment.
+CREATE TABLE IF NOT EXISTS stocktakes (
+	id SERIAL PRIMARY KEY,
+	store_id INT NOT NULL REFERENCES stores(id),
+	status VARCHAR(16) NOT NULL DEFAULT 'open',
+	note TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	finalized_at TIMESTAMPTZ,
+	cancelled_at TIMESTAMPTZ,
+	CONSTRAINT stocktakes_status_check CHECK (status IN ('open', 'finalized', 'cancelled'))
+);
+
+-- Only one stocktake may be open per store.
+CREATE UNIQUE INDEX IF NOT EXISTS stocktakes_store_open_idx
+	ON stocktakes (store_id)
+	WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS stocktake_items (
+	id SERIAL PRIMARY KEY,
+	stocktake_id INT NOT NULL REFERENCES stocktakes(id) ON DELETE CASCADE,
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE,
+	expected INT NOT NULL,
+	counted INT,
+	counted_at TIMESTAMPTZ,
+	CONSTRAINT stocktake_items_counted_check CHECK (counted IS NULL OR counted >= 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS stocktake_items_item_idx
+	ON stocktake_items (stocktake_id, product_id, COALESCE(variant_id, 0));
+
+-- Every correction made to the stock of a store outside of sales and
+-- transfers.
+CREATE TABLE IF NOT EXISTS stock_adjustments (
+	id SERIAL PRIMARY KEY,
+	store_id INT NOT NULL REFERENCES stores(id),
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE,
+	quantity INT NOT NULL,
+	reason VARCHAR(32) NOT NULL,
+	stocktake_id INT REFERENCES stocktakes(id),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS stock_adjustments_store_id_idx ON stock_adjustments (store_id, created_at);
+CREATE INDEX IF NOT EXISTS stock_adjustments_stocktake_id_idx ON stock_adjustments (stocktake_id);
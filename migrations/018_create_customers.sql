@@ -0,0 +1,22 @@
+-- Customers can be attached to a checkout to keep their purchase history.
+-- Phone numbers are stored with digits only, emails in lower case.
+CREATE TABLE IF NOT EXISTS customers (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	phone VARCHAR(32),
+	email VARCHAR(255),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS customers_phone_key ON customers (phone) WHERE phone IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS customers_email_key ON customers (email) WHERE email IS NOT NULL;
+
+-- Customer search matches any part of the name, phone or email.
+CREATE INDEX IF NOT EXISTS customers_name_trgm_idx ON customers USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS customers_phone_trgm_idx ON customers USING GIN (phone gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS customers_email_trgm_idx ON customers USING GIN (email gin_trgm_ops);
+
+ALTER TABLE transactions
+	ADD COLUMN IF NOT EXISTS customer_id INT REFERENCES customers(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS transactions_customer_id_idx ON transactions (customer_id, created_at);
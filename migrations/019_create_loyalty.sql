@@ -0,0 +1,56 @@
+-- Loyalty program of a currency: customers earn earn_points for every full
+-- earn_amount spent, and every point is worth point_value when redeemed.
+-- Earned points expire after expiry_days, or never when it is NULL.
+CREATE TABLE IF NOT EXISTS loyalty_programs (
+	currency CHAR(3) PRIMARY KEY,
+	earn_amount BIGINT NOT NULL,
+	earn_points INT NOT NULL,
+	point_value BIGINT NOT NULL,
+	expiry_days INT,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT loyalty_programs_earn_check CHECK (earn_amount > 0 AND earn_points > 0),
+	CONSTRAINT loyalty_programs_point_value_check CHECK (point_value > 0),
+	CONSTRAINT loyalty_programs_expiry_check CHECK (expiry_days IS NULL OR expiry_days > 0)
+);
+
+-- Sales of a category earn points times multiplier_bps / 10000: 20000 earns
+-- double, 0 earns nothing. Categories without a row earn the normal rate.
+CREATE TABLE IF NOT EXISTS loyalty_category_multipliers (
+	category_id INT PRIMARY KEY REFERENCES categories(id) ON DELETE CASCADE,
+	multiplier_bps INT NOT NULL,
+	CONSTRAINT loyalty_category_multipliers_check CHECK (multiplier_bps >= 0 AND multiplier_bps <= 100000)
+);
+
+-- Every change to the points of a customer. The balance is the sum of the
+-- points still remaining on credit entries that have not expired; debits
+-- (redeem, expire, negative adjust) consume credits soonest-expiring first.
+CREATE TABLE IF NOT EXISTS loyalty_ledger (
+	id SERIAL PRIMARY KEY,
+	customer_id INT NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+	transaction_id INT REFERENCES transactions(id) ON DELETE SET NULL,
+	kind VARCHAR(16) NOT NULL,
+	points INT NOT NULL,
+	remaining INT NOT NULL DEFAULT 0,
+	expires_at TIMESTAMPTZ,
+	note TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT loyalty_ledger_kind_check CHECK (kind IN ('earn', 'redeem', 'expire', 'adjust')),
+	CONSTRAINT loyalty_ledger_points_check CHECK (points <> 0),
+	CONSTRAINT loyalty_ledger_remaining_check CHECK (remaining >= 0 AND remaining <= GREATEST(points, 0))
+);
+
+CREATE INDEX IF NOT EXISTS loyalty_ledger_customer_id_idx ON loyalty_ledger (customer_id, created_at);
+
+CREATE INDEX IF NOT EXISTS loyalty_ledger_expiry_idx
+	ON loyalty_ledger (expires_at)
+	WHERE remaining > 0 AND expires_at IS NOT NULL;
+
+ALTER TABLE transactions
+	ADD COLUMN IF NOT EXISTS points_earned INT NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS points_redeemed INT NOT NULL DEFAULT 0;
+
+-- Points can settle a transaction like any other tender.
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_method_check;
+ALTER TABLE payments
+	ADD CONSTRAINT payments_method_check CHECK (method IN ('cash', 'card', 'qris', 'e_wallet', 'points'));
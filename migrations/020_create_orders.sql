@@ -0,0 +1,96 @@
+-- Orders are tabs built up over time and settled into a transaction later.
+-- An open order takes new items, a held order is parked until it is resumed;
+-- both can be settled or cancelled. Version goes up with every change so a
+-- settlement only goes through for the items the cashier saw.
+CREATE TABLE IF NOT EXISTS orders (
+	id SERIAL PRIMARY KEY,
+	store_id INT NOT NULL REFERENCES stores(id),
+	customer_id INT REFERENCES customers(id) ON DELETE SET NULL,
+	label VARCHAR(64) NOT NULL DEFAULT '',
+	note TEXT NOT NULL DEFAULT '',
+	status VARCHAR(16) NOT NULL DEFAULT 'open',
+	reserve_stock BOOLEAN NOT NULL DEFAULT FALSE,
+	version INT NOT NULL DEFAULT 1,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	settled_at TIMESTAMPTZ,
+	cancelled_at TIMESTAMPTZ,
+	CONSTRAINT orders_status_check CHECK (status IN ('open', 'held', 'settled', 'cancelled'))
+);
+
+CREATE INDEX IF NOT EXISTS orders_store_id_idx ON orders (store_id, status, created_at);
+
+-- Lines of an order. The same product, variant and modifiers make one line;
+-- prices are only fixed when the order is settled.
+CREATE TABLE IF NOT EXISTS order_items (
+	id SERIAL PRIMARY KEY,
+	order_id INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE,
+	modifier_ids INT[] NOT NULL DEFAULT '{}',
+	quantity INT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT order_items_quantity_check CHECK (quantity > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS order_items_line_idx
+	ON order_items (order_id, product_id, COALESCE(variant_id, 0), modifier_ids);
+
+-- Stock set aside for an order. Reserved stock stays on hand but cannot be
+-- sold to anyone else.
+CREATE TABLE IF NOT EXISTS stock_reservations (
+	id SERIAL PRIMARY KEY,
+	order_id INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+	store_id INT NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+	product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE,
+	quantity INT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CONSTRAINT stock_reservations_quantity_check CHECK (quantity > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS stock_reservations_item_idx
+	ON stock_reservations (order_id, product_id, COALESCE(variant_id, 0));
+
+CREATE INDEX IF NOT EXISTS stock_reservations_stock_idx
+	ON stock_reservations (store_id, product_id, variant_id);
+
+ALTER TABLE store_stocks
+	ADD COLUMN IF NOT EXISTS reserved INT NOT NULL DEFAULT 0;
+
+ALTER TABLE store_stocks DROP CONSTRAINT IF EXISTS store_stocks_reserved_check;
+ALTER TABLE store_stocks
+	ADD CONSTRAINT store_stocks_reserved_check CHECK (reserved >= 0);
+
+-- store_stocks.reserved holds the sum of the reservations of the item,
+-- including when reservations go away with a deleted order or variant.
+CREATE OR REPLACE FUNCTION stock_reservations_sync_reserved() RETURNS TRIGGER AS $$
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') THEN
+		UPDATE store_stocks
+		SET reserved = GREATEST(reserved - OLD.quantity, 0)
+		WHERE store_id = OLD.store_id AND product_id = OLD.product_id
+			AND variant_id IS NOT DISTINCT FROM OLD.variant_id;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		UPDATE store_stocks
+		SET reserved = reserved + NEW.quantity
+		WHERE store_id = NEW.store_id AND product_id = NEW.product_id
+			AND variant_id IS NOT DISTINCT FROM NEW.variant_id;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_reservations_sync_reserved_trigger ON stock_reservations;
+CREATE TRIGGER stock_reservations_sync_reserved_trigger
+	AFTER INSERT OR UPDATE OR DELETE ON stock_reservations
+	FOR EACH ROW EXECUTE FUNCTION stock_reservations_sync_reserved();
+
+-- A settled order points at the transaction it became.
+ALTER TABLE transactions
+	ADD COLUMN IF NOT EXISTS order_id INT REFERENCES orders(id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_order_id_key
+	ON transactions (order_id)
+	WHERE order_id IS NOT NULL;
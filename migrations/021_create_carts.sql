@@ -0,0 +1,41 @@
+-- Carts reserve stock for a sale that is still being rung up, so another
+-- till cannot sell the last unit in the meantime. A cart holds its stock
+-- until it expires, is released, or is checked out; expired carts are swept
+-- by a background worker.
+CREATE TABLE IF NOT EXISTS carts (
+	id SERIAL PRIMARY KEY,
+	store_id INT NOT NULL REFERENCES stores(id),
+	status VARCHAR(16) NOT NULL DEFAULT 'active',
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	closed_at TIMESTAMPTZ,
+	CONSTRAINT carts_status_check CHECK (status IN ('active', 'checked_out', 'released', 'expired'))
+);
+
+CREATE INDEX IF NOT EXISTS carts_store_id_idx ON carts (store_id, status, created_at);
+
+CREATE INDEX IF NOT EXISTS carts_expiry_idx
+	ON carts (expires_at)
+	WHERE status = 'active';
+
+-- Stock reservations belong to either an order or a cart.
+ALTER TABLE stock_reservations
+	ALTER COLUMN order_id DROP NOT NULL,
+	ADD COLUMN IF NOT EXISTS cart_id INT REFERENCES carts(id) ON DELETE CASCADE;
+
+ALTER TABLE stock_reservations DROP CONSTRAINT IF EXISTS stock_reservations_owner_check;
+ALTER TABLE stock_reservations
+	ADD CONSTRAINT stock_reservations_owner_check CHECK ((order_id IS NULL) <> (cart_id IS NULL));
+
+CREATE UNIQUE INDEX IF NOT EXISTS stock_reservations_cart_item_idx
+	ON stock_reservations (cart_id, product_id, COALESCE(variant_id, 0))
+	WHERE cart_id IS NOT NULL;
+
+-- A checked out cart points at the transaction that consumed it.
+ALTER TABLE transactions
+	ADD COLUMN IF NOT EXISTS cart_id INT REFERENCES carts(id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_cart_id_key
+	ON transactions (cart_id)
+	WHERE cart_id IS NOT NULL;
@@ -0,0 +1,39 @@
+-- Variants carry their own price, so their price changes go to the price
+-- history as well, with the variant in variant_id. Rows without a variant are
+-- changes of the product price. Variants are priced in the currency of their
+-- product.
+ALTER TABLE price_history
+	ADD COLUMN IF NOT EXISTS variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS price_history_variant_id_idx
+	ON price_history (variant_id, changed_at)
+	WHERE variant_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION product_variants_price_history() RETURNS TRIGGER AS $$
+DECLARE
+	change_source VARCHAR(32) := COALESCE(NULLIF(current_setting('app.price_change_source', true), ''), 'manual');
+	product_currency VARCHAR(3);
+BEGIN
+	SELECT currency INTO product_currency FROM products WHERE id = NEW.product_id;
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO price_history (product_id, variant_id, new_price, new_currency, source)
+		VALUES (NEW.product_id, NEW.id, NEW.price, product_currency, change_source);
+	ELSIF NEW.price IS DISTINCT FROM OLD.price THEN
+		INSERT INTO price_history (product_id, variant_id, old_price, old_currency, new_price, new_currency, source)
+		VALUES (NEW.product_id, NEW.id, OLD.price, product_currency, NEW.price, product_currency, change_source);
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS product_variants_price_history_trigger ON product_variants;
+CREATE TRIGGER product_variants_price_history_trigger
+	AFTER INSERT OR UPDATE OF price ON product_variants
+	FOR EACH ROW EXECUTE FUNCTION product_variants_price_history();
+
+-- Variants that existed before start with their current price.
+INSERT INTO price_history (product_id, variant_id, new_price, new_currency, source)
+SELECT v.product_id, v.id, v.price, p.currency, 'initial'
+FROM product_variants v
+JOIN products p ON p.id = v.product_id
+WHERE NOT EXISTS (SELECT 1 FROM price_history h WHERE h.variant_id = v.id);
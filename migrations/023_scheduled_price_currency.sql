@@ -0,0 +1,19 @@
+-- A scheduled price keeps the currency it was scheduled in, so it is not
+-- read in another currency when the product changed its currency in the
+-- meantime; such a change fails instead of being applied. A change with a
+-- variant_id sets the price of that variant instead of the product.
+ALTER TABLE scheduled_price_changes
+	ADD COLUMN IF NOT EXISTS currency VARCHAR(3),
+	ADD COLUMN IF NOT EXISTS variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE;
+
+UPDATE scheduled_price_changes s
+SET currency = p.currency
+FROM products p
+WHERE p.id = s.product_id AND s.currency IS NULL;
+
+ALTER TABLE scheduled_price_changes
+	ALTER COLUMN currency SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS scheduled_price_changes_variant_id_idx
+	ON scheduled_price_changes (variant_id)
+	WHERE variant_id IS NOT NULL;
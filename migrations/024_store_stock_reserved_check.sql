@@ -0,0 +1,9 @@
+-- A store cannot hold less stock than it has reserved for orders and carts,
+-- or the reservations could not be honoured. Writes that would drop the
+-- stock below the reserved quantity fail on this constraint, which the
+-- application reports as a conflict. Rows that already break it are left
+-- alone (NOT VALID) until their reservations are released, but any later
+-- write to them has to satisfy it.
+ALTER TABLE store_stocks DROP CONSTRAINT IF EXISTS store_stocks_reserved_stock_check;
+ALTER TABLE store_stocks
+	ADD CONSTRAINT store_stocks_reserved_stock_check CHECK (stock >= reserved) NOT VALID;
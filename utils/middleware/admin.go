@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	AdminTokenHeader = "X-Admin-Token"
+	AdminTokenQuery  = "token"
+	adminLocalKey    = "admin"
+)
+
+// Admin marks requests carrying the admin token as admin requests. With an
+// empty token no request is ever an admin request.
+func Admin(token string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		given := c.Get(AdminTokenHeader)
+		if token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			c.Locals(adminLocalKey, true)
+		}
+		return c.Next()
+	}
+}
+
+// AdminFromQuery also accepts the admin token from the token query
+// parameter, for clients such as a browser EventSource or WebSocket that
+// cannot set request headers.
+func AdminFromQuery(token string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		given := c.Query(AdminTokenQuery)
+		if token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			c.Locals(adminLocalKey, true)
+		}
+		return c.Next()
+	}
+}
+
+// RequireAdmin rejects requests that were not marked by Admin.
+func RequireAdmin() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if !IsAdmin(c) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"message": "Admin access required",
+				"error":   nil,
+			})
+		}
+		return c.Next()
+	}
+}
+
+func IsAdmin(c fiber.Ctx) bool {
+	admin, _ := c.Locals(adminLocalKey).(bool)
+	return admin
+}